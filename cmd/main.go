@@ -1,20 +1,72 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/hyperproperties/sopher/pkg/faults"
+	"github.com/hyperproperties/sopher/pkg/filesx"
 	"github.com/hyperproperties/sopher/pkg/language"
+	"github.com/hyperproperties/sopher/pkg/lsp"
 )
 
 var (
-	sourceFlag string
+	sourceFlag          string
+	selfCompositionFlag bool
+	runsFlag            int
+	seedFlag            uint64
+	faultsFlag          bool
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "faults" {
+		if err := campaign(os.Args[2:]); err != nil {
+			log.Fatalln("Fault-injection campaign failed:", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "lsp" {
+		// The protocol is spoken over standard input and output.
+		if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+			log.Fatalln("Language server failed:", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		if err := format(os.Args[2:]); err != nil {
+			log.Fatalln("Formatting failed:", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		if err := check(os.Args[2:]); err != nil {
+			log.Fatalln("Checking failed:", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "mutants" {
+		if err := mutants(os.Args[2:]); err != nil {
+			log.Fatalln("Mutation testing failed:", err)
+		}
+		return
+	}
+
 	flag.StringVar(&sourceFlag, "source", "", "the source file or directory")
+	flag.BoolVar(&selfCompositionFlag, "self-composition", false, "generate self-composition tests for 2-safety guarantees")
+	flag.IntVar(&runsFlag, "runs", 100, "the number of runs of the self-composition tests")
+	flag.Uint64Var(&seedFlag, "seed", 0, "the seed of the self-composition tests")
+	flag.BoolVar(&faultsFlag, "faults", false, "instrument contracted functions with bit-flip fault sites")
 	flag.Parse()
 
 	// If the default source flag is used then we use working directory.
@@ -33,10 +85,256 @@ func main() {
 	}
 
 	injector := language.NewGoInjector()
-	injector.Files(files.Iterator())
+	if err := injector.Sidecars(files.Sidecars()); err != nil {
+		log.Fatalln("Failed reading sidecar contracts", err)
+	}
+	if err := inherit(&injector, sourceFlag); err != nil {
+		log.Fatalln("Failed inheriting interface contracts", err)
+	}
+
+	if selfCompositionFlag {
+		if err := injector.SelfCompositions(files.Iterator(), runsFlag, seedFlag); err != nil {
+			log.Fatalln("Failed generating self-composition tests", err)
+		}
+		return
+	}
+
+	if faultsFlag {
+		faultInjector := language.NewGoFaultInjector()
+		if err := faultInjector.Sidecars(files.Sidecars()); err != nil {
+			log.Fatalln("Failed reading sidecar contracts", err)
+		}
+		if err := inherit(faultInjector, sourceFlag); err != nil {
+			log.Fatalln("Failed inheriting interface contracts", err)
+		}
+		faultInjector.Files(files.Iterator())
+	} else {
+		injector.Files(files.Iterator())
+	}
 
 	// Run tests.
 	time.Sleep(15 * time.Second)
 
 	injector.Restore(files.Iterator())
 }
+
+// inherit inherits the contracts of the interface methods declared in the
+// module of the source onto their implementations in the module. Sources
+// outside of a module have no interfaces to inherit from.
+func inherit(injector interface {
+	Interfaces(directory string, patterns ...string) error
+}, source string) error {
+	directory := strings.TrimSuffix(source, "...")
+	if info, err := os.Stat(directory); err == nil && !info.IsDir() {
+		directory = filepath.Dir(directory)
+	}
+
+	root, _, err := filesx.Module(directory)
+	if errors.Is(err, filesx.ErrNoModule) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return injector.Interfaces(root, "./...")
+}
+
+// campaign runs the "faults" command which instruments the package with fault
+// sites, runs its tests for every site and fault model, and reports how many
+// of the faults the contracts detect.
+func campaign(arguments []string) error {
+	set := flag.NewFlagSet("faults", flag.ExitOnError)
+	source := set.String("source", ".", "the package directory")
+	models := set.String("models", "bit-flip,skip,stuck-at-zero", "the comma-separated fault models")
+	runs := set.Int("runs", 1, "the number of runs per site and fault model")
+	rate := set.Float64("rate", 1, "the probability of injecting a fault when a site is reached")
+	seed := set.Uint64("seed", 0, "the seed of the first run")
+	timeout := set.Duration("timeout", time.Minute, "the duration after which a run is considered crashed")
+	set.Parse(arguments)
+
+	directory := *source
+	if info, err := os.Stat(directory); err != nil {
+		return err
+	} else if !info.IsDir() {
+		directory = filepath.Dir(directory)
+	}
+
+	campaign := faults.NewCampaign(directory, nil)
+	campaign.Models = nil
+	for _, name := range strings.Split(*models, ",") {
+		model, ok := faults.ParseModel(strings.TrimSpace(name))
+		if !ok {
+			return fmt.Errorf("unknown fault model %q", name)
+		}
+		campaign.Models = append(campaign.Models, model)
+	}
+	campaign.Runs = *runs
+	campaign.Rate = *rate
+	campaign.Seed = *seed
+	campaign.Timeout = *timeout
+	campaign.Progress = os.Stderr
+
+	files := language.NewFiles()
+	if err := files.Add(directory); err != nil {
+		return err
+	}
+
+	// Tests drive the campaign and are therefore not instrumented.
+	paths := slices.DeleteFunc(slices.Collect(files.Iterator()), func(path string) bool {
+		return strings.HasSuffix(path, "_test.go")
+	})
+
+	injector := language.NewGoFaultInjector()
+	if err := injector.Sidecars(files.Sidecars()); err != nil {
+		return err
+	}
+	if err := inherit(injector, directory); err != nil {
+		return err
+	}
+	injector.Files(slices.Values(paths))
+	defer language.NewGoInjector().Restore(slices.Values(paths))
+
+	campaign.Sites = injector.Sites()
+	attacks, err := campaign.Run()
+	if err != nil {
+		return err
+	}
+
+	return faults.FprintAttacks(os.Stdout, attacks)
+}
+
+// mutants runs the "mutants" command which mutates the contracted functions of
+// the package and reports the mutants surviving each guarantee.
+func mutants(arguments []string) error {
+	set := flag.NewFlagSet("mutants", flag.ExitOnError)
+	source := set.String("source", ".", "the source file or directory")
+	seed := set.Uint64("seed", 0, "the seed of the property harness")
+	timeout := set.Duration("timeout", time.Minute, "the duration after which a mutant is considered crashed")
+	set.Parse(arguments)
+
+	files := language.NewFiles()
+	if err := files.Add(*source); err != nil {
+		return err
+	}
+
+	paths := slices.DeleteFunc(slices.Collect(files.Iterator()), func(path string) bool {
+		return strings.HasSuffix(path, "_test.go")
+	})
+
+	mutator := language.NewMutator()
+	mutator.Seed = *seed
+	mutator.Timeout = *timeout
+	mutator.Progress = os.Stderr
+
+	scores, err := mutator.Files(slices.Values(paths))
+	if err != nil {
+		return err
+	}
+
+	return language.FprintMutations(os.Stdout, scores)
+}
+
+// check runs the "check" command which parses the contracts of the files and
+// warns about obligations which can never be violated at runtime.
+func check(arguments []string) error {
+	set := flag.NewFlagSet("check", flag.ExitOnError)
+	set.Parse(arguments)
+
+	files := language.NewFiles()
+	paths := set.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	for _, path := range paths {
+		if err := files.Add(path); err != nil {
+			return err
+		}
+	}
+
+	var warnings []language.Warning
+	for path := range files.Iterator() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		found, err := language.CheckSource(path, source)
+		if err != nil {
+			return err
+		}
+		warnings = append(warnings, found...)
+	}
+
+	for path := range files.Sidecars() {
+		sidecars, err := language.ReadSidecar(path)
+		if err != nil {
+			return err
+		}
+		for _, sidecar := range sidecars {
+			contract, err := sidecar.Parse()
+			if err != nil {
+				return err
+			}
+			warnings = append(warnings, contract.Warnings(sidecar.Path, sidecar.Line, sidecar.Function)...)
+		}
+	}
+
+	for _, warning := range warnings {
+		fmt.Println(warning)
+	}
+
+	return nil
+}
+
+// format runs the "fmt" command which rewrites the contracts of the files into
+// their canonical layout like gofmt. The formatted files are printed unless
+// they are listed, diffed or written.
+func format(arguments []string) error {
+	set := flag.NewFlagSet("fmt", flag.ExitOnError)
+	list := set.Bool("l", false, "list files whose contracts differ from their canonical layout")
+	diff := set.Bool("d", false, "display diffs instead of rewriting files")
+	write := set.Bool("w", false, "write the result to the source files")
+	width := set.Int("width", language.DefaultWidth, "the width beyond which contract lines are wrapped")
+	set.Parse(arguments)
+
+	files := language.NewFiles()
+	paths := set.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	for _, path := range paths {
+		if err := files.Add(path); err != nil {
+			return err
+		}
+	}
+
+	for path := range files.Iterator() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := language.Format(source, *width)
+		if err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+
+		changed := string(source) != string(formatted)
+		if *list && changed {
+			fmt.Println(path)
+		}
+		if *diff && changed {
+			os.Stdout.Write(filesx.Diff(path+".orig", path, source, formatted))
+		}
+		if *write && changed {
+			if err := os.WriteFile(path, formatted, 0o644); err != nil {
+				return err
+			}
+		}
+		if !*list && !*diff && !*write {
+			os.Stdout.Write(formatted)
+		}
+	}
+
+	return nil
+}
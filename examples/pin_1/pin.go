@@ -55,7 +55,7 @@ var attempt int = 0
 // assume: exists e. e.attempt == 0																// The Initial Attempt
 // assume: forall e. e.attempt >= 0																// Valid Attempt
 // assume: forall e0. exists e1. e0.attempt > 1; -> e1.attempt == e0.attempt - 1				// Continous Attempts
-// assume: forall e0 e1. e0._time < e1._time; <-> e0.attempt < e1.attempt						// Attempts Increment on Consecutive Calls
+// assume: forall e0 e1. next(e0, e1); -> e1.attempt == e0.attempt + 1						// Attempts Increment on Consecutive Calls
 // assume: forall e0 e1. e0._id != e1._id; <-> e0.attempt != e1.attempt							// Unique Attempts
 // guarantee: exists e. e.ret0 && e.ret1 == nil													// There Is A Check Which Passes
 // guarantee: forall e. e.attempt > 3; -> !e.re0 && e.ret1 != nil								// Exceeds Attempt
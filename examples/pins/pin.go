@@ -1,5 +1,7 @@
 package examples
 
+import "math/rand/v2"
+
 func SlicesEqual[S1, S2 ~[]E, E comparable](s1 S1, s2 S2) bool {
 	if len(s1) != len(s2) {
 		return false
@@ -22,9 +24,14 @@ func (pin Pin) Valid() bool {
 		pin[0] <= 9 && pin[1] <= 9 && pin[2] <= 9 && pin[3] <= 9
 }
 
+// Generate implements quick.Generatable such that only valid PINs are generated.
+func (Pin) Generate(rand *rand.Rand, size int) Pin {
+	return Pin{rand.UintN(10), rand.UintN(10), rand.UintN(10), rand.UintN(10)}
+}
+
 // assume: forall e. pin.Valid() && e.attempt > 0												// Valid PIN and Attempt
 // assume: forall e0. exists e1. e0.attempt > 1; -> e1.attempt == e0.attempt - 1				// Continous Attempts
-// assume: forall e0 e1. e0._time < e1._time; <-> e0.attempt < e1.attempt						// Attempts Increment on Consecutive Calls
+// assume: forall e0 e1. next(e0, e1); -> e1.attempt == e0.attempt + 1						// Attempts Increment on Consecutive Calls
 // assume: forall e0 e1. e0._id != e1._id; <-> e0.attempt != e1.attempt							// Unique Attempts
 // guarantee: forall e. e.ret0; <-> e.attempt <= 3 && SlicesEqual(e.pin, []uint{3, 1, 4, 1})	// Successful Check
 // guarantee: forall e0 e1. e0.ret0 && e1.ret0; -> SlicesEqual(e0.pin, e1.pin)					// Exactly One Correct PIN
@@ -35,7 +42,7 @@ func CheckPIN(attempt uint, pin Pin) bool {
 
 // assume: forall e. pin.Valid() && e.attempt > 0												// Valid PIN and Attempt
 // assume: forall e0. exists e1. e0.attempt > 1; -> e1.attempt == e0.attempt - 1				// Continous Attempts
-// assume: forall e0 e1. e0._time + time.Minute <= e1._time; -> e1.attempt == 1					// Reset After 1 Minute
+// assume: forall e0 e1. next(e0, e1) && !within(e0, e1, time.Minute); -> e1.attempt == 1		// Reset After 1 Minute
 // guarantee: forall e. e.ret0; <-> e.attempt <= 3 && SlicesEqual(e.pin, []uint{3, 1, 4, 1})	// Successful Check
 // guarantee: forall e0 e1. e0.ret0 && e1.ret0; -> SlicesEqual(e0.pin, e1.pin)					// Exactly One Correct PIN
 // guarantee: forall e0 e1. math.Abs(e0._duration - e1._duration) <= 0.1 * time.Second			// No Timing Side Channel
@@ -45,7 +52,7 @@ func WithResetCheckPIN(attempt uint, pin Pin) bool {
 
 // assume: forall e. pin.Valid() && e.attempt > 0										// Valid PIN and Attempt
 // assume: forall e0. exists e1. e0.attempt > 1; -> e1.attempt == e0.attempt - 1		// Continous Attempts
-// assume: forall e0 e1. e0._time < e1._time; <-> e0.attempt < e1.attempt				// Attempts Increment on Consecutive Calls
+// assume: forall e0 e1. next(e0, e1); -> e1.attempt == e0.attempt + 1				// Attempts Increment on Consecutive Calls
 // assume: forall e0 e1. e0._id != e1._id; <-> e0.attempt != e1.attempt					// Unique Attempts
 // guarantee: forall e0 e1. e0.ret0 && e1.ret0; -> SlicesEqual(e0.pin, e1.pin)			// Exactly One Correct PIN
 // guarantee: forall e0 e1. math.Abs(e0._duration - e1._duration) <= 0.1 * time.Second	// No Timing Side Channel
@@ -65,9 +72,7 @@ func CheckContinouslyChangingPIN(pin Pin) bool {
 // guarantee: forall e0. e0.counter == 0; -> SlicesEqual(e0.pin, []uint{0, 0, 0, 0})	// Inital PIN 0000
 // guarantee: forall e0 e1. math.Abs(e0._duration - e1._duration) <= 0.1 * time.Second	// No Timing Side Channel
 // guarantee: "TODO: No pin must be the reversal of another."							//
-// guarantee: forall e0. e0.ret0 -> forall e1. e1.ret0 && e1._time < e0._time;
-//
-//	-> e1._time + 15 * time.Minute <= e0._time			// Atleast 15 Minute Between Successful Changes
+// guarantee: forall e0 e1. e0.ret0 && e1.ret0 && before(e0, e1); -> !within(e0, e1, 15 * time.Minute)	// Atleast 15 Minute Between Successful Changes
 func ChangePIN(counter uint, pin Pin) bool {
 	panic("not implemented yet")
 }
@@ -3,15 +3,15 @@ module github.com/hyperproperties/sopher
 go 1.23.1
 
 require (
+	github.com/dave/dst v0.27.3
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/mod v0.22.0
 	golang.org/x/tools v0.27.0
 )
 
 require (
-	github.com/dave/dst v0.27.3 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/mod v0.22.0 // indirect
 	golang.org/x/sync v0.9.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
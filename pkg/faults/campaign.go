@@ -0,0 +1,286 @@
+package faults
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Outcome is how a single run of a campaign ended.
+type Outcome uint8
+
+const (
+	// Inactive runs had no fault injected at the site, even if they crashed.
+	Inactive = Outcome(iota)
+	// Detected runs had a contract monitor detect a violation after a fault.
+	Detected
+	// Silent runs had faults injected which no contract monitor detected.
+	Silent
+	// Crashed runs panicked or timed out after a fault.
+	Crashed
+)
+
+func (outcome Outcome) String() string {
+	switch outcome {
+	case Inactive:
+		return "inactive"
+	case Detected:
+		return "detected"
+	case Silent:
+		return "silent"
+	case Crashed:
+		return "crash"
+	}
+	return strconv.Itoa(int(outcome))
+}
+
+// Attack is the outcomes of all runs injecting faults of a model at a site.
+type Attack struct {
+	Site
+	Model    Model
+	Outcomes map[Outcome]int
+	// Detections counts the detections by each obligation of the contract.
+	Detections map[string]int
+}
+
+// Activated returns the number of runs where faults were injected.
+func (attack Attack) Activated() int {
+	return attack.Outcomes[Detected] + attack.Outcomes[Silent] + attack.Outcomes[Crashed]
+}
+
+// Campaign runs the tests of an instrumented package once per run for every
+// site and every fault model applicable to it with faults only enabled at the
+// site, like the attack matrices of FISSC.
+type Campaign struct {
+	// Directory is where the tests are run.
+	Directory string
+	// Package is the package pattern passed to "go test".
+	Package string
+	// Sites are the sites of the instrumented package.
+	Sites []Site
+	// Models are the fault models of the campaign.
+	Models []Model
+	// Runs is the number of runs per site and model with different seeds.
+	Runs int
+	// Rate is the injection rate at the site.
+	Rate float64
+	// Seed is the seed of the first run.
+	Seed uint64
+	// Timeout is the duration after which a run is considered crashed.
+	Timeout time.Duration
+	// Progress is written a line for each attack if not nil.
+	Progress io.Writer
+}
+
+// NewCampaign creates a campaign of all fault models with one run per attack.
+func NewCampaign(directory string, sites []Site) Campaign {
+	return Campaign{
+		Directory: directory,
+		Package:   ".",
+		Sites:     sites,
+		Models:    Models,
+		Runs:      1,
+		Rate:      1,
+		Timeout:   time.Minute,
+	}
+}
+
+// Run runs every attack of the campaign. An error is returned if the tests
+// could not be run at all, e.g. because the instrumented package does not build.
+func (campaign Campaign) Run() ([]Attack, error) {
+	temporary, err := os.MkdirTemp("", "sopher-faults")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(temporary)
+
+	var attacks []Attack
+	for _, site := range campaign.Sites {
+		for _, model := range campaign.Models {
+			if !model.Applies(site.Kind) {
+				continue
+			}
+
+			attack := Attack{
+				Site:       site,
+				Model:      model,
+				Outcomes:   make(map[Outcome]int),
+				Detections: make(map[string]int),
+			}
+
+			for run := range campaign.Runs {
+				report := filepath.Join(temporary, fmt.Sprintf("%v-%v-%v.json", site.ID, model, run))
+				outcome, statistics, err := campaign.run(site, model, campaign.Seed+uint64(run), report)
+				if err != nil {
+					return attacks, err
+				}
+				attack.Outcomes[outcome]++
+				if outcome == Detected {
+					for obligation, detections := range statistics.Detections {
+						attack.Detections[obligation] += detections
+					}
+				}
+			}
+
+			if campaign.Progress != nil {
+				fmt.Fprintf(campaign.Progress, "site %v (%v) %v: %v/%v detected\n",
+					site.ID, site.Kind, model, attack.Outcomes[Detected], attack.Activated())
+			}
+
+			attacks = append(attacks, attack)
+		}
+	}
+
+	return attacks, nil
+}
+
+func (campaign Campaign) run(site Site, model Model, seed uint64, report string) (Outcome, Statistics, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*campaign.Timeout)
+	defer cancel()
+
+	// The test binary panics on its own timeout before the context kills it.
+	command := exec.CommandContext(ctx, "go", "test", "-count=1", "-timeout="+campaign.Timeout.String(), campaign.Package)
+	command.Dir = campaign.Directory
+	command.WaitDelay = time.Second
+	command.Env = append(os.Environ(),
+		RateVariable+"="+strconv.FormatFloat(campaign.Rate, 'g', -1, 64),
+		SitesVariable+"="+strconv.Itoa(site.ID),
+		SeedVariable+"="+strconv.FormatUint(seed, 10),
+		ModelVariable+"="+model.String(),
+		ReportVariable+"="+report,
+	)
+
+	var output bytes.Buffer
+	command.Stdout = &output
+	command.Stderr = &output
+	err := command.Run()
+
+	if err != nil && strings.Contains(output.String(), "[build failed]") {
+		return Inactive, Statistics{}, fmt.Errorf("instrumented package does not build:\n%s", output.String())
+	}
+
+	var statistics Statistics
+	reports, readErr := ReadReports(report)
+	if readErr != nil && !errors.Is(readErr, os.ErrNotExist) {
+		return Inactive, Statistics{}, readErr
+	}
+	for _, report := range reports {
+		if report.ID == site.ID {
+			statistics = report.Statistics
+		}
+	}
+
+	// Failing tests without panics are left to the contract monitors to
+	// classify.
+	crashed := ctx.Err() != nil || (err != nil && strings.Contains(output.String(), "panic:"))
+	return classify(statistics, crashed), statistics, nil
+}
+
+// classify returns the outcome of a run with the statistics of the attacked
+// site which panicked or timed out if it crashed. A crash is only blamed on the
+// fault if one was injected as it is unrelated otherwise.
+func classify(statistics Statistics, crashed bool) Outcome {
+	switch {
+	case statistics.Injected == 0:
+		return Inactive
+	case crashed:
+		return Crashed
+	case statistics.Detected > 0:
+		return Detected
+	case statistics.Undetected > 0:
+		return Silent
+	}
+	return Inactive
+}
+
+// FprintAttacks writes the outcomes of the attacks as a table grouped by the
+// contracted function followed by the detection coverage of each contract.
+func FprintAttacks(writer io.Writer, attacks []Attack) error {
+	attacks = slices.Clone(attacks)
+	slices.SortStableFunc(attacks, func(a, b Attack) int {
+		return strings.Compare(a.Function, b.Function)
+	})
+
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "CONTRACT\tSITE\tKIND\tMODEL\tPOSITION\tEXPRESSION\tDETECTED\tSILENT\tCRASH\tINACTIVE\tDETECTED BY")
+	for _, attack := range attacks {
+		fmt.Fprintf(
+			table, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			attack.Function, attack.ID, attack.Kind, attack.Model, attack.Position, attack.Expression,
+			attack.Outcomes[Detected], attack.Outcomes[Silent], attack.Outcomes[Crashed], attack.Outcomes[Inactive],
+			detectors(attack.Detections),
+		)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(writer)
+
+	table = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "CONTRACT\tDETECTED\tSILENT\tCRASH\tCOVERAGE")
+	for function, group := range groupByFunction(attacks) {
+		var detected, silent, crashed int
+		for _, attack := range group {
+			detected += attack.Outcomes[Detected]
+			silent += attack.Outcomes[Silent]
+			crashed += attack.Outcomes[Crashed]
+		}
+
+		coverage := "-"
+		if activated := detected + silent + crashed; activated > 0 {
+			coverage = fmt.Sprintf("%.1f%%", 100*float64(detected)/float64(activated))
+		}
+
+		fmt.Fprintf(table, "%v\t%v\t%v\t%v\t%v\n", function, detected, silent, crashed, coverage)
+	}
+	return table.Flush()
+}
+
+// groupByFunction yields the consecutive attacks of the same function.
+func groupByFunction(attacks []Attack) iter.Seq2[string, []Attack] {
+	return func(yield func(string, []Attack) bool) {
+		for start := 0; start < len(attacks); {
+			end := start + 1
+			for end < len(attacks) && attacks[end].Function == attacks[start].Function {
+				end++
+			}
+			if !yield(attacks[start].Function, attacks[start:end]) {
+				return
+			}
+			start = end
+		}
+	}
+}
+
+// detectors lists the obligations by how many faults they detected.
+func detectors(detections map[string]int) string {
+	if len(detections) == 0 {
+		return "-"
+	}
+
+	obligations := slices.Collect(maps.Keys(detections))
+	slices.SortFunc(obligations, func(a, b string) int {
+		if detections[a] != detections[b] {
+			return detections[b] - detections[a]
+		}
+		return strings.Compare(a, b)
+	})
+
+	for idx, obligation := range obligations {
+		obligations[idx] = fmt.Sprintf("%v (%v)", obligation, detections[obligation])
+	}
+	return strings.Join(obligations, ", ")
+}
@@ -0,0 +1,317 @@
+package faults
+
+import (
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Kind is the kind of program location a fault is injected at.
+type Kind uint8
+
+const (
+	VariableSite = Kind(iota)
+	ConditionSite
+	ReturnSite
+	StatementSite
+)
+
+func (kind Kind) String() string {
+	switch kind {
+	case VariableSite:
+		return "variable"
+	case ConditionSite:
+		return "condition"
+	case ReturnSite:
+		return "return"
+	case StatementSite:
+		return "statement"
+	}
+	return strconv.Itoa(int(kind))
+}
+
+// Model is the fault model deciding how a fault manifests at a site.
+type Model uint8
+
+const (
+	// BitFlip flips a single bit of variables and return values and negates
+	// branch conditions.
+	BitFlip = Model(iota)
+	// Skip skips the execution of statements, i.e. instruction skips.
+	Skip
+	// StuckAtZero sets variables, return values and branch conditions to
+	// their zero value.
+	StuckAtZero
+)
+
+// Models are all fault models.
+var Models = []Model{BitFlip, Skip, StuckAtZero}
+
+func (model Model) String() string {
+	switch model {
+	case BitFlip:
+		return "bit-flip"
+	case Skip:
+		return "skip"
+	case StuckAtZero:
+		return "stuck-at-zero"
+	}
+	return strconv.Itoa(int(model))
+}
+
+// ParseModel returns the model with the name as returned by String.
+func ParseModel(name string) (Model, bool) {
+	for _, model := range Models {
+		if model.String() == name {
+			return model, true
+		}
+	}
+	return 0, false
+}
+
+// Applies reports whether faults of the model can be injected at the kind of site.
+func (model Model) Applies(kind Kind) bool {
+	if model == Skip {
+		return kind == StatementSite
+	}
+	return kind != StatementSite
+}
+
+// Site is a program location where a fault can be injected.
+type Site struct {
+	ID         int
+	Kind       Kind
+	Function   string
+	Position   string
+	Expression string
+}
+
+// Configuration decides which sites faults are injected at and how often.
+type Configuration struct {
+	// Rate is the probability of injecting a fault when a site is reached.
+	Rate float64
+	// Sites are the identifiers of the sites faults are injected at. All
+	// sites are enabled if it is empty.
+	Sites []int
+	// Model is the fault model of the injected faults.
+	Model Model
+	// Seed is the seed of the random source deciding injections.
+	Seed uint64
+	// Report is the path of a file the report is written to after every
+	// injection and observation. Nothing is written if it is empty.
+	Report string
+}
+
+// Environment variables read by the default configuration.
+const (
+	RateVariable   = "SOPHER_FAULT_RATE"
+	SitesVariable  = "SOPHER_FAULT_SITES"
+	SeedVariable   = "SOPHER_FAULT_SEED"
+	ModelVariable  = "SOPHER_FAULT_MODEL"
+	ReportVariable = "SOPHER_FAULT_REPORT"
+)
+
+// DefaultRate is the injection rate when none is configured.
+const DefaultRate = 0.01
+
+type state struct {
+	mutex         sync.Mutex
+	configuration Configuration
+	enabled       map[int]struct{}
+	random        *rand.Rand
+	sites         map[int]Site
+	statistics    map[int]*Statistics
+	// pending are the sites injected since the last observation.
+	pending []int
+}
+
+var global = newState(ConfigurationFromEnvironment())
+
+func newState(configuration Configuration) *state {
+	state := &state{
+		sites:      make(map[int]Site),
+		statistics: make(map[int]*Statistics),
+	}
+	state.configure(configuration)
+	return state
+}
+
+func (state *state) configure(configuration Configuration) {
+	state.configuration = configuration
+	state.random = rand.New(rand.NewPCG(configuration.Seed, configuration.Seed))
+	state.enabled = nil
+	if len(configuration.Sites) > 0 {
+		state.enabled = make(map[int]struct{}, len(configuration.Sites))
+		for _, site := range configuration.Sites {
+			state.enabled[site] = struct{}{}
+		}
+	}
+}
+
+// ConfigurationFromEnvironment reads the configuration from the environment
+// variables such that instrumented programs can be configured externally.
+func ConfigurationFromEnvironment() Configuration {
+	configuration := Configuration{
+		Rate:   DefaultRate,
+		Seed:   rand.Uint64(),
+		Report: os.Getenv(ReportVariable),
+	}
+
+	if rate, err := strconv.ParseFloat(os.Getenv(RateVariable), 64); err == nil {
+		configuration.Rate = rate
+	}
+
+	if seed, err := strconv.ParseUint(os.Getenv(SeedVariable), 10, 64); err == nil {
+		configuration.Seed = seed
+	}
+
+	if model, ok := ParseModel(os.Getenv(ModelVariable)); ok {
+		configuration.Model = model
+	}
+
+	for _, field := range strings.Split(os.Getenv(SitesVariable), ",") {
+		if site, err := strconv.Atoi(strings.TrimSpace(field)); err == nil {
+			configuration.Sites = append(configuration.Sites, site)
+		}
+	}
+
+	return configuration
+}
+
+// Configure replaces the configuration and resets all statistics.
+func Configure(configuration Configuration) {
+	global.mutex.Lock()
+	defer global.mutex.Unlock()
+
+	global.configure(configuration)
+	global.pending = nil
+	for id := range global.statistics {
+		global.statistics[id] = &Statistics{}
+	}
+}
+
+// Register makes a site known such that it is part of the report even if no
+// fault was injected at it.
+func Register(site Site) {
+	global.mutex.Lock()
+	defer global.mutex.Unlock()
+
+	global.sites[site.ID] = site
+	if _, exists := global.statistics[site.ID]; !exists {
+		global.statistics[site.ID] = &Statistics{}
+	}
+}
+
+// inject decides whether a fault is injected at the site of the kind and if so
+// records it as pending until the next observation.
+func inject(site int, kind Kind) (*rand.Rand, Model, bool) {
+	global.mutex.Lock()
+	defer global.mutex.Unlock()
+
+	model := global.configuration.Model
+	if !model.Applies(kind) {
+		return nil, model, false
+	}
+
+	if global.enabled != nil {
+		if _, enabled := global.enabled[site]; !enabled {
+			return nil, model, false
+		}
+	}
+
+	if global.random.Float64() >= global.configuration.Rate {
+		return nil, model, false
+	}
+
+	statistics, exists := global.statistics[site]
+	if !exists {
+		statistics = &Statistics{}
+		global.statistics[site] = statistics
+	}
+	statistics.Injected++
+	global.pending = append(global.pending, site)
+
+	// The injection is reported before it is observed as the fault may crash
+	// the program first.
+	if global.configuration.Report != "" {
+		_ = global.write(global.configuration.Report)
+	}
+
+	return global.random, model, true
+}
+
+// Variable flips a random bit of the variable or sets it to zero if a fault is
+// injected at the site.
+func Variable[T any](site int, variable *T) {
+	if random, model, ok := inject(site, VariableSite); ok {
+		if model == StuckAtZero {
+			var zero T
+			*variable = zero
+		} else {
+			flip(random, variable)
+		}
+	}
+}
+
+// Condition negates the branch condition or makes it false if a fault is
+// injected at the site.
+func Condition(site int, condition bool) bool {
+	if _, model, ok := inject(site, ConditionSite); ok {
+		return model != StuckAtZero && !condition
+	}
+	return condition
+}
+
+// Value returns the value with a random bit flipped or zero if a fault is
+// injected at the site.
+func Value[T any](site int, value T) T {
+	if random, model, ok := inject(site, ReturnSite); ok {
+		if model == StuckAtZero {
+			var zero T
+			return zero
+		}
+		flip(random, &value)
+	}
+	return value
+}
+
+// Skipped reports whether the statement at the site is skipped because a fault
+// is injected at it.
+func Skipped(site int) bool {
+	_, _, ok := inject(site, StatementSite)
+	return ok
+}
+
+// Observe records the obligations the contract monitors found violated for the
+// faults injected since the last observation and returns whether any was.
+func Observe(obligations ...string) bool {
+	global.mutex.Lock()
+	defer global.mutex.Unlock()
+
+	violated := len(obligations) > 0
+	for _, site := range global.pending {
+		statistics := global.statistics[site]
+		if !violated {
+			statistics.Undetected++
+			continue
+		}
+
+		statistics.Detected++
+		if statistics.Detections == nil {
+			statistics.Detections = make(map[string]int)
+		}
+		for _, obligation := range obligations {
+			statistics.Detections[obligation]++
+		}
+	}
+	global.pending = nil
+
+	if global.configuration.Report != "" {
+		// The report is best effort and must not disturb the program.
+		_ = global.write(global.configuration.Report)
+	}
+
+	return violated
+}
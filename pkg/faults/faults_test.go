@@ -0,0 +1,159 @@
+package faults
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlipSingleBit(t *testing.T) {
+	random := rand.New(rand.NewPCG(1, 1))
+
+	for range 100 {
+		value := uint32(0xA5A5A5A5)
+		flip(random, &value)
+		assert.Equal(t, 1, bits.OnesCount32(value^0xA5A5A5A5))
+	}
+
+	type pair struct {
+		flag  bool
+		count int8
+	}
+
+	for range 100 {
+		value := pair{flag: true, count: 5}
+		flip(random, &value)
+		assert.NotEqual(t, pair{flag: true, count: 5}, value)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	Register(Site{ID: 0, Kind: ConditionSite, Function: "Foo"})
+	Register(Site{ID: 1, Kind: ReturnSite, Function: "Foo"})
+	Configure(Configuration{Rate: 1, Sites: []int{0}, Seed: 1})
+	defer Configure(ConfigurationFromEnvironment())
+
+	assert.False(t, Condition(0, true))
+	assert.Equal(t, 5, Value(1, 5), "only enabled sites are injected")
+	Observe("No Timing Side Channel", "#1")
+
+	assert.True(t, Condition(0, false))
+	Observe()
+
+	reports := Reports()
+	assert.Len(t, reports, 2)
+	assert.Equal(t, Statistics{
+		Injected: 2, Detected: 1, Undetected: 1,
+		Detections: map[string]int{"No Timing Side Channel": 1, "#1": 1},
+	}, reports[0].Statistics)
+	assert.Equal(t, Statistics{}, reports[1].Statistics)
+}
+
+func TestReportInjection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	Register(Site{ID: 0, Kind: ConditionSite, Function: "Foo"})
+	Configure(Configuration{Rate: 1, Sites: []int{0}, Seed: 1, Report: path})
+	defer Configure(ConfigurationFromEnvironment())
+
+	// The injection is reported even if the program crashes before the
+	// contract monitors observe it.
+	Condition(0, true)
+	reports, err := ReadReports(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reports[0].Injected)
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		description string
+		statistics  Statistics
+		crashed     bool
+		outcome     Outcome
+	}{
+		{
+			description: "never injected",
+			outcome:     Inactive,
+		},
+		{
+			description: "crashed without injection",
+			crashed:     true,
+			outcome:     Inactive,
+		},
+		{
+			description: "crashed after injection",
+			statistics:  Statistics{Injected: 1},
+			crashed:     true,
+			outcome:     Crashed,
+		},
+		{
+			description: "crashed after detection",
+			statistics:  Statistics{Injected: 1, Detected: 1},
+			crashed:     true,
+			outcome:     Crashed,
+		},
+		{
+			description: "detected",
+			statistics:  Statistics{Injected: 2, Detected: 1, Undetected: 1},
+			outcome:     Detected,
+		},
+		{
+			description: "silent",
+			statistics:  Statistics{Injected: 1, Undetected: 1},
+			outcome:     Silent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.Equal(t, tt.outcome, classify(tt.statistics, tt.crashed))
+		})
+	}
+}
+
+func TestModels(t *testing.T) {
+	Register(Site{ID: 0, Kind: VariableSite})
+	Register(Site{ID: 1, Kind: StatementSite})
+	defer Configure(ConfigurationFromEnvironment())
+
+	Configure(Configuration{Rate: 1, Model: StuckAtZero})
+	value := 42
+	Variable(0, &value)
+	assert.Equal(t, 0, value)
+	assert.False(t, Condition(0, true))
+	assert.False(t, Skipped(1), "statements are only skipped by the skip model")
+
+	Configure(Configuration{Rate: 1, Model: Skip})
+	value = 42
+	Variable(0, &value)
+	assert.Equal(t, 42, value, "variables are not faulted by the skip model")
+	assert.True(t, Skipped(1))
+}
+
+func TestFprintAttacks(t *testing.T) {
+	attacks := []Attack{
+		{
+			Site:       Site{ID: 1, Kind: ReturnSite, Function: "B"},
+			Model:      BitFlip,
+			Outcomes:   map[Outcome]int{Detected: 2, Silent: 1},
+			Detections: map[string]int{"#0": 1, "Successful Check": 2},
+		},
+		{
+			Site:     Site{ID: 0, Kind: StatementSite, Function: "A"},
+			Model:    Skip,
+			Outcomes: map[Outcome]int{Crashed: 1, Inactive: 1},
+		},
+	}
+
+	var builder strings.Builder
+	assert.NoError(t, FprintAttacks(&builder, attacks))
+
+	lines := strings.Split(builder.String(), "\n")
+	assert.Regexp(t, `^A\s+0\s+statement\s+skip\s+0\s+0\s+1\s+1\s+-$`, lines[1])
+	assert.Regexp(t, `^B\s+1\s+return\s+bit-flip\s+2\s+1\s+0\s+0\s+Successful Check \(2\), #0 \(1\)$`, lines[2])
+	assert.Regexp(t, `^A\s+0\s+0\s+1\s+0\.0%$`, lines[5])
+	assert.Regexp(t, `^B\s+2\s+1\s+0\s+66\.7%$`, lines[6])
+}
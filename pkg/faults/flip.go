@@ -0,0 +1,64 @@
+package faults
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"unsafe"
+)
+
+// flip flips a single random bit of the value. Only the bits of booleans,
+// numbers and strings, including those in arrays and structs, are considered
+// as flipping bits of pointers is not a meaningful model of data faults.
+func flip[T any](random *rand.Rand, value *T) {
+	var candidates []reflect.Value
+	var collect func(value reflect.Value)
+	collect = func(value reflect.Value) {
+		switch value.Kind() {
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64:
+			candidates = append(candidates, value)
+		case reflect.Array:
+			for idx := 0; idx < value.Len(); idx++ {
+				collect(value.Index(idx))
+			}
+		case reflect.Struct:
+			for idx := 0; idx < value.NumField(); idx++ {
+				collect(value.Field(idx))
+			}
+		}
+	}
+	collect(reflect.ValueOf(value).Elem())
+
+	if len(candidates) == 0 {
+		return
+	}
+
+	candidate := candidates[random.IntN(len(candidates))]
+	// Unexported fields are made settable through their address.
+	target := reflect.NewAt(candidate.Type(), unsafe.Pointer(candidate.UnsafeAddr())).Elem()
+
+	switch target.Kind() {
+	case reflect.Bool:
+		target.SetBool(!target.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		target.SetInt(target.Int() ^ int64(1)<<random.IntN(target.Type().Bits()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		target.SetUint(target.Uint() ^ uint64(1)<<random.IntN(target.Type().Bits()))
+	case reflect.Float32:
+		bits := math.Float32bits(float32(target.Float()))
+		target.SetFloat(float64(math.Float32frombits(bits ^ uint32(1)<<random.IntN(32))))
+	case reflect.Float64:
+		bits := math.Float64bits(target.Float())
+		target.SetFloat(math.Float64frombits(bits ^ uint64(1)<<random.IntN(64)))
+	case reflect.String:
+		bytes := []byte(target.String())
+		if len(bytes) == 0 {
+			return
+		}
+		bytes[random.IntN(len(bytes))] ^= byte(1) << random.IntN(8)
+		target.SetString(string(bytes))
+	}
+}
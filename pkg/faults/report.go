@@ -0,0 +1,91 @@
+package faults
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"text/tabwriter"
+)
+
+// Statistics counts the faults injected at a site and whether the contract
+// monitors detected a violation in the executions they were injected in.
+type Statistics struct {
+	Injected   int
+	Detected   int
+	Undetected int
+	// Detections counts the detections by each obligation of the contract.
+	Detections map[string]int `json:",omitempty"`
+}
+
+// Report is the statistics of a single site.
+type Report struct {
+	Site
+	Statistics
+}
+
+// Reports returns the report of every known site ordered by identifier.
+func Reports() []Report {
+	global.mutex.Lock()
+	defer global.mutex.Unlock()
+
+	return global.reports()
+}
+
+func (state *state) reports() []Report {
+	reports := make([]Report, 0, len(state.statistics))
+	for id, statistics := range state.statistics {
+		site, exists := state.sites[id]
+		if !exists {
+			site = Site{ID: id}
+		}
+		copied := *statistics
+		copied.Detections = maps.Clone(statistics.Detections)
+		reports = append(reports, Report{
+			Site:       site,
+			Statistics: copied,
+		})
+	}
+
+	slices.SortFunc(reports, func(a, b Report) int {
+		return a.ID - b.ID
+	})
+
+	return reports
+}
+
+func (state *state) write(path string) error {
+	content, err := json.Marshal(state.reports())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// ReadReports reads reports written by an instrumented program.
+func ReadReports(path string) ([]Report, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []Report
+	err = json.Unmarshal(content, &reports)
+	return reports, err
+}
+
+// Fprint writes the reports as a table to the writer.
+func Fprint(writer io.Writer, reports []Report) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "SITE\tKIND\tFUNCTION\tPOSITION\tEXPRESSION\tINJECTED\tDETECTED\tUNDETECTED\tDETECTED BY")
+	for _, report := range reports {
+		fmt.Fprintf(
+			table, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			report.ID, report.Kind, report.Function, report.Position, report.Expression,
+			report.Injected, report.Detected, report.Undetected, detectors(report.Detections),
+		)
+	}
+	return table.Flush()
+}
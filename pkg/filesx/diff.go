@@ -0,0 +1,109 @@
+package filesx
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Diff returns the unified diff of the lines of two files with three lines of
+// context or nil if they are equal.
+func Diff(oldName, newName string, old, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+
+	a, b := lines(old), lines(new)
+
+	// lengths[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	type edit struct {
+		kind byte
+		line string
+		i, j int
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lengths[i+1][j] >= lengths[i][j+1]):
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	const context = 3
+	var diff bytes.Buffer
+	fmt.Fprintf(&diff, "--- %v\n+++ %v\n", oldName, newName)
+
+	for start := 0; start < len(edits); {
+		if edits[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		// A hunk extends while changes are separated by at most twice the context.
+		end := start
+		for next := start; next < len(edits); next++ {
+			if edits[next].kind != ' ' {
+				end = next
+			} else if next-end > 2*context {
+				break
+			}
+		}
+
+		first := max(0, start-context)
+		last := min(len(edits), end+context+1)
+
+		var oldLines, newLines int
+		for _, edit := range edits[first:last] {
+			if edit.kind != '+' {
+				oldLines++
+			}
+			if edit.kind != '-' {
+				newLines++
+			}
+		}
+		fmt.Fprintf(&diff, "@@ -%v,%v +%v,%v @@\n", edits[first].i+1, oldLines, edits[first].j+1, newLines)
+		for _, edit := range edits[first:last] {
+			diff.WriteByte(edit.kind)
+			diff.WriteString(edit.line)
+			if !strings.HasSuffix(edit.line, "\n") {
+				diff.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		start = last
+	}
+
+	return diff.Bytes()
+}
+
+// lines splits the content after every newline without an empty last line.
+func lines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,84 @@
+package filesx
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+var (
+	ErrNoModule      = errors.New("directory is not in a module")
+	ErrOutsideModule = errors.New("package is outside the module")
+)
+
+// Module returns the root directory and the path of the module containing the
+// directory by searching for the nearest go.mod in it or its parents.
+func Module(directory string) (root, module string, err error) {
+	root, err = filepath.Abs(directory)
+	if err != nil {
+		return "", "", err
+	}
+
+	for {
+		content, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			module = modfile.ModulePath(content)
+			if module == "" {
+				return "", "", ErrNoModule
+			}
+			return root, module, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", "", err
+		}
+
+		parent := filepath.Dir(root)
+		if parent == root {
+			return "", "", ErrNoModule
+		}
+		root = parent
+	}
+}
+
+// ImportPath returns the import path of the package in the directory.
+func ImportPath(directory string) (string, error) {
+	root, module, err := Module(directory)
+	if err != nil {
+		return "", err
+	}
+
+	absolute, err := filepath.Abs(directory)
+	if err != nil {
+		return "", err
+	}
+
+	relative, err := filepath.Rel(root, absolute)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(module, filepath.ToSlash(relative)), nil
+}
+
+// PackageDirectory returns the directory of the package with the import path
+// in the module containing the directory.
+func PackageDirectory(directory, importPath string) (string, error) {
+	root, module, err := Module(directory)
+	if err != nil {
+		return "", err
+	}
+
+	if importPath == module {
+		return root, nil
+	}
+	relative, ok := strings.CutPrefix(importPath, module+"/")
+	if !ok {
+		return "", ErrOutsideModule
+	}
+
+	return filepath.Join(root, filepath.FromSlash(relative)), nil
+}
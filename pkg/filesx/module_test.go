@@ -0,0 +1,28 @@
+package filesx
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModule(t *testing.T) {
+	root, module, err := Module(".")
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/hyperproperties/sopher", module)
+
+	importPath, err := ImportPath("../language")
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/hyperproperties/sopher/pkg/language", importPath)
+
+	directory, err := PackageDirectory(".", "github.com/hyperproperties/sopher/pkg/lsp")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "pkg", "lsp"), directory)
+
+	_, err = PackageDirectory(".", "github.com/dave/dst")
+	assert.ErrorIs(t, err, ErrOutsideModule)
+
+	_, _, err = Module(t.TempDir())
+	assert.ErrorIs(t, err, ErrNoModule)
+}
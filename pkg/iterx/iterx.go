@@ -50,6 +50,90 @@ func Permutations(sub, slice int) iter.Seq[[]int] {
 	}
 }
 
+// Combinations returns the increasing sequences of sub indices of the slice,
+// i.e. every subset of sub distinct indices once.
+func Combinations(sub, slice int) iter.Seq[[]int] {
+	if sub < 0 {
+		panic("combinations of negative length subslices")
+	}
+
+	if sub == 0 || sub > slice {
+		return func(yield func([]int) bool) {}
+	}
+
+	return func(yield func([]int) bool) {
+		counters := make([]int, sub)
+		for idx := range counters {
+			counters[idx] = idx
+		}
+		for {
+			combination := make([]int, sub)
+			copy(combination, counters)
+			if !yield(combination) {
+				return
+			}
+
+			// Find the rightmost element that can still be incremented while
+			// leaving room for the elements to its right.
+			i := sub - 1
+			for i >= 0 && counters[i] == slice-sub+i {
+				i--
+			}
+
+			if i < 0 {
+				break
+			}
+
+			counters[i]++
+
+			// Every element to the right of i follows its left neighbour.
+			for j := i + 1; j < sub; j++ {
+				counters[j] = counters[j-1] + 1
+			}
+		}
+	}
+}
+
+// CombinationsWithRepetition returns the non-decreasing sequences of sub
+// indices of the slice, i.e. every multiset of sub indices once.
+func CombinationsWithRepetition(sub, slice int) iter.Seq[[]int] {
+	if sub < 0 {
+		panic("combinations of negative length subslices")
+	}
+
+	if sub == 0 || slice == 0 {
+		return func(yield func([]int) bool) {}
+	}
+
+	return func(yield func([]int) bool) {
+		counters := make([]int, sub)
+		for {
+			combination := make([]int, sub)
+			copy(combination, counters)
+			if !yield(combination) {
+				return
+			}
+
+			// Find the rightmost element that can still be incremented.
+			i := sub - 1
+			for i >= 0 && counters[i] == slice-1 {
+				i--
+			}
+
+			if i < 0 {
+				break
+			}
+
+			counters[i]++
+
+			// Every element to the right of i is at least the element at i.
+			for j := i + 1; j < sub; j++ {
+				counters[j] = counters[i]
+			}
+		}
+	}
+}
+
 func IncrementalPermutations(sub, slice, added int) iter.Seq[[]int] {
 	if added == 0 {
 		return func(yield func([]int) bool) {}
@@ -140,6 +140,80 @@ func TestIncrementalPermutations(t *testing.T) {
 	}
 }
 
+func TestCombinations(t *testing.T) {
+	// Number of combinations=slice choose sub and with repetition=(slice+sub-1) choose sub
+	tests := []struct {
+		description  string
+		sub, slice   int
+		combinations int
+		repetitions  int
+	}{
+		{
+			description:  "no elements",
+			sub:          2,
+			slice:        0,
+			combinations: 0,
+			repetitions:  0,
+		},
+		{
+			description:  "more variables than elements",
+			sub:          3,
+			slice:        2,
+			combinations: 0,
+			repetitions:  4,
+		},
+		{
+			description:  "single",
+			sub:          1,
+			slice:        3,
+			combinations: 3,
+			repetitions:  3,
+		},
+		{
+			description:  "pairs",
+			sub:          2,
+			slice:        4,
+			combinations: 6,
+			repetitions:  10,
+		},
+		{
+			description:  "triples",
+			sub:          3,
+			slice:        13,
+			combinations: 286,
+			repetitions:  455,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			combinations := Collect(Combinations(tt.sub, tt.slice))
+			assert.Len(t, combinations, tt.combinations)
+			for _, combination := range combinations {
+				for idx := 1; idx < len(combination); idx++ {
+					assert.Less(t, combination[idx-1], combination[idx])
+				}
+			}
+
+			repetitions := Collect(CombinationsWithRepetition(tt.sub, tt.slice))
+			assert.Len(t, repetitions, tt.repetitions)
+			for idx, repetition := range repetitions {
+				assert.NotContains(t, repetitions[idx+1:], repetition)
+				for idx := 1; idx < len(repetition); idx++ {
+					assert.LessOrEqual(t, repetition[idx-1], repetition[idx])
+				}
+			}
+			for _, combination := range combinations {
+				assert.Contains(t, repetitions, combination)
+			}
+		})
+	}
+
+	assert.PanicsWithValue(t, "combinations of negative length subslices", func() {
+		Combinations(-1, 2)
+	})
+}
+
 func TestMapPermutation(t *testing.T) {
 	tests := []struct {
 		description string
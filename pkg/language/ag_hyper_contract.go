@@ -1,6 +1,10 @@
 package language
 
-import "github.com/hyperproperties/sopher/pkg/quick"
+import (
+	"time"
+
+	"github.com/hyperproperties/sopher/pkg/quick"
+)
 
 type AGHyperContract[T any] struct {
 	assumptions []HyperAssertion[T]
@@ -32,7 +36,7 @@ func (contract *AGHyperContract[T]) Model(call func(input T) T) {
 				model[idx] = quick.New[T]()
 			}
 	
-			if contract.Assume(model...).IsTrue() {
+			if !contract.Assume(model...).IsFalse() {
 				break
 			}
 		}
@@ -43,7 +47,7 @@ func (contract *AGHyperContract[T]) Model(call func(input T) T) {
 			model[idx] = call(execution)
 		}
 
-		if contract.Guarantee(model...).IsTrue() {
+		if !contract.Guarantee(model...).IsFalse() {
 			break
 		} else {
 			panic("model does not satisfy guarantee")
@@ -55,22 +59,81 @@ func (contract *AGHyperContract[T]) Model(call func(input T) T) {
 
 func (contract *AGHyperContract[T]) Assume(executions ...T) LiftedBoolean {
 	interpreter := NewHyperAssertionInterpreter[T]()
+	result := LiftedTrue
 	for _, assumption := range contract.assumptions {
-		satisfied := interpreter.Satisfies(assumption, append(contract.model, executions...))
-		if !satisfied {
+		result = result.And(interpreter.Satisfies(assumption, append(contract.model, executions...)))
+		if result.IsFalse() {
 			return LiftedFalse
 		}
 	}
-	return LiftedTrue
+	return result
 }
 
 func (contract *AGHyperContract[T]) Guarantee(executions ...T) LiftedBoolean {
 	interpreter := NewHyperAssertionInterpreter[T]()
+	result := LiftedTrue
 	for _, guarantee := range contract.guarantees {
-		satisfied := interpreter.Satisfies(guarantee, append(contract.model, executions...))
-		if !satisfied {
+		result = result.And(interpreter.Satisfies(guarantee, append(contract.model, executions...)))
+		if result.IsFalse() {
 			return LiftedFalse
 		}
 	}
-	return LiftedTrue
+	return result
+}
+
+// AssumptionViolation evaluates every assumption on the executions and
+// returns those violated.
+func (contract *AGHyperContract[T]) AssumptionViolation(executions ...T) Violation {
+	return contract.violation("assumption", contract.assumptions, executions, nil, false, 0)
+}
+
+// GuaranteeViolation evaluates every guarantee on the executions and returns
+// those violated.
+func (contract *AGHyperContract[T]) GuaranteeViolation(executions ...T) Violation {
+	return contract.violation("guarantee", contract.guarantees, executions, nil, false, 0)
+}
+
+// violation evaluates the assertions on the executions made at the times, or
+// untimed if the times are nil, and returns those definitely violated. The executions of
+// the model are untimed and made before the executions. The executions are a
+// history if they only grow between evaluations of the assertions and the
+// seen executions were evaluated before, as by SatisfiesExtension.
+func (contract *AGHyperContract[T]) violation(
+	kind string, assertions []HyperAssertion[T], executions []T, times []time.Time, history bool, seen int,
+) Violation {
+	if times != nil && len(contract.model) > 0 {
+		times = append(make([]time.Time, len(contract.model)), times...)
+	}
+
+	violation := Violation{Kind: kind, Result: LiftedTrue}
+	interpreter := NewHyperAssertionInterpreter[T]()
+	satisfies := interpreter.SatisfiesExtension
+	if len(contract.model) > 0 {
+		executions = append(contract.model, executions...)
+		if seen > 0 {
+			seen += len(contract.model)
+		}
+	} else if history {
+		satisfies = interpreter.SatisfiesHistory
+	}
+	for idx, assertion := range assertions {
+		result := satisfies(assertion, executions, times, seen)
+		violation.Result = violation.Result.And(result)
+
+		obligation := Obligation{Index: idx}
+		if labelled, ok := assertion.(*LabelledHyperAssertion[T]); ok {
+			obligation.Label = labelled.Label()
+		}
+		for _, estimate := range interpreter.Estimates() {
+			estimate.Obligation = obligation
+			violation.Estimates = append(violation.Estimates, estimate)
+		}
+		if result.IsFalse() {
+			violation.Obligations = append(violation.Obligations, obligation)
+			if witness := interpreter.Witness(); witness != nil {
+				violation.Witnesses = append(violation.Witnesses, Witness{obligation, witness})
+			}
+		}
+	}
+	return violation
 }
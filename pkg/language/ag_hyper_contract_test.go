@@ -1,6 +1,11 @@
 package language
 
-import "testing"
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
 
 func Test(t *testing.T) {
 	type Execution struct {
@@ -13,7 +18,9 @@ func Test(t *testing.T) {
 			NewUniversalHyperAssertion(0, 1, NewPredicateHyperAssertion(
 				func(assignments []Execution) bool {
 					e := assignments[0]
-					return e.input >= 0
+					// The boundary values generated by quick would otherwise
+					// overflow the monotone function.
+					return e.input >= 0 && e.input < math.MaxInt
 				},
 			)),
 		},
@@ -36,4 +43,86 @@ func Test(t *testing.T) {
 		execution.output = output
 		return execution
 	})
-}
\ No newline at end of file
+}
+func TestViolation(t *testing.T) {
+	type Execution struct {
+		input  int
+		output int
+	}
+
+	positive := func(assignments []Execution) bool {
+		return assignments[0].output > 0
+	}
+	even := func(assignments []Execution) bool {
+		return assignments[0].output%2 == 0
+	}
+
+	contract := NewAGHyperContract(
+		[]HyperAssertion[Execution]{
+			NewLabelledHyperAssertion("Positive Input", NewUniversalHyperAssertion(0, 1, NewPredicateHyperAssertion(
+				func(assignments []Execution) bool {
+					return assignments[0].input > 0
+				},
+			))),
+		},
+		[]HyperAssertion[Execution]{
+			NewUniversalHyperAssertion(0, 1, NewPredicateHyperAssertion(positive)),
+			NewLabelledHyperAssertion("Even", NewUniversalHyperAssertion(0, 1, NewPredicateHyperAssertion(even))),
+		},
+	)
+
+	assert.False(t, contract.AssumptionViolation(Execution{input: 1}).Violated())
+	assert.Equal(t, `assumption "Positive Input" violated`, contract.AssumptionViolation(Execution{input: 0}).Error())
+
+	violation := contract.GuaranteeViolation(Execution{output: 2}, Execution{output: -1})
+	assert.Equal(t, []Obligation{{Index: 0}, {Index: 1, Label: "Even"}}, violation.Obligations)
+	assert.Equal(t, []string{"#0", "Even"}, violation.Names())
+	assert.Equal(t, `guarantees #0, "Even" violated`, violation.Error())
+	assert.Equal(t, []Witness{
+		{Obligation{Index: 0}, []int{1}},
+		{Obligation{Index: 1, Label: "Even"}, []int{1}},
+	}, violation.Witnesses)
+}
+
+func TestViolationResult(t *testing.T) {
+	type Execution struct {
+		input  int
+		output int
+	}
+
+	contract := NewAGHyperContract(
+		nil,
+		[]HyperAssertion[Execution]{
+			NewLabelledHyperAssertion("Positive", NewUniversalHyperAssertion(0, 1, NewPredicateHyperAssertion(
+				func(assignments []Execution) bool {
+					return assignments[0].output > 0
+				},
+			))),
+			NewLabelledHyperAssertion("Identity", NewExistentialHyperAssertion(0, 1, NewPredicateHyperAssertion(
+				func(assignments []Execution) bool {
+					return assignments[0].input == assignments[0].output
+				},
+			))),
+		},
+	)
+
+	// No execution violates the universal but later ones may.
+	violation := contract.GuaranteeViolation(Execution{1, 2})
+	assert.False(t, violation.Violated())
+	assert.Equal(t, LiftedUnknown, violation.Result)
+	assert.Equal(t, LiftedUnknown, contract.Guarantee(Execution{1, 2}))
+
+	// Witnessing the existential does not make the universal definite.
+	violation = contract.GuaranteeViolation(Execution{1, 2}, Execution{2, 2})
+	assert.False(t, violation.Violated())
+	assert.Equal(t, LiftedUnknown, violation.Result)
+
+	violation = contract.GuaranteeViolation(Execution{1, 2}, Execution{2, -1})
+	assert.Equal(t, []string{"Positive"}, violation.Names())
+	assert.Equal(t, LiftedFalse, violation.Result)
+	assert.Equal(t, LiftedFalse, contract.Guarantee(Execution{1, 2}, Execution{2, -1}))
+
+	// A contract without obligations holds regardless of the executions.
+	assert.Equal(t, LiftedTrue, contract.AssumptionViolation(Execution{1, 2}).Result)
+	assert.Equal(t, LiftedTrue, contract.Assume(Execution{1, 2}))
+}
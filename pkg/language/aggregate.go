@@ -0,0 +1,200 @@
+package language
+
+import (
+	"math"
+	"slices"
+)
+
+// Aggregator aggregates a stream of values in constant memory such that the
+// aggregate of a history of executions is updated by the executions added to
+// it instead of being recomputed. The value is NaN if the aggregate of the
+// values added so far is undefined, e.g. the mean of no values.
+type Aggregator interface {
+	Add(value float64)
+	Value() float64
+}
+
+// Compile time checking interface implementations:
+var (
+	_ Aggregator = (*Mean)(nil)
+	_ Aggregator = (*Sum)(nil)
+	_ Aggregator = (*Count)(nil)
+	_ Aggregator = (*Percentile)(nil)
+)
+
+// Mean is the running mean of the values.
+type Mean struct {
+	count int
+	mean  float64
+}
+
+func NewMean() *Mean {
+	return &Mean{}
+}
+
+func (mean *Mean) Add(value float64) {
+	mean.count++
+	mean.mean += (value - mean.mean) / float64(mean.count)
+}
+
+func (mean *Mean) Value() float64 {
+	if mean.count == 0 {
+		return math.NaN()
+	}
+	return mean.mean
+}
+
+// Sum is the sum of the values.
+type Sum struct {
+	sum float64
+}
+
+func NewSum() *Sum {
+	return &Sum{}
+}
+
+func (sum *Sum) Add(value float64) {
+	sum.sum += value
+}
+
+func (sum *Sum) Value() float64 {
+	return sum.sum
+}
+
+// Count is the number of values.
+type Count struct {
+	count int
+}
+
+func NewCount() *Count {
+	return &Count{}
+}
+
+func (count *Count) Add(float64) {
+	count.count++
+}
+
+func (count *Count) Value() float64 {
+	return float64(count.count)
+}
+
+// centroid is the mean of values summarised by a t-digest and their number.
+type centroid struct {
+	mean, weight float64
+}
+
+// Percentile estimates a percentile of the values by a merging t-digest. The
+// values are summarised by centroids which are small at the tails and large
+// at the median such that extreme percentiles are estimated accurately in
+// memory bounded by the compression. Added values are buffered and merged
+// into the centroids when the buffer is full or the percentile is estimated.
+type Percentile struct {
+	percentile  float64
+	compression float64
+	centroids   []centroid
+	buffer      []centroid
+	count       float64
+	min, max    float64
+}
+
+// NewPercentile returns an estimator of the percentile between 0 and 100.
+func NewPercentile(percentile float64) *Percentile {
+	if percentile < 0 || percentile > 100 {
+		panic("percentile must be between 0 and 100")
+	}
+	return &Percentile{
+		percentile:  percentile,
+		compression: 100,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+func (percentile *Percentile) Add(value float64) {
+	percentile.buffer = append(percentile.buffer, centroid{value, 1})
+	percentile.count++
+	percentile.min = math.Min(percentile.min, value)
+	percentile.max = math.Max(percentile.max, value)
+	if len(percentile.buffer) >= 5*int(percentile.compression) {
+		percentile.merge()
+	}
+}
+
+// scale maps the quantile to the scale of the t-digest on which every centroid
+// spans at most one unit.
+func (percentile *Percentile) scale(quantile float64) float64 {
+	return percentile.compression / (2 * math.Pi) * math.Asin(math.Max(-1, math.Min(1, 2*quantile-1)))
+}
+
+// merge merges the buffered values into the centroids.
+func (percentile *Percentile) merge() {
+	if len(percentile.buffer) == 0 {
+		return
+	}
+
+	centroids := append(percentile.centroids, percentile.buffer...)
+	slices.SortFunc(centroids, func(a, b centroid) int {
+		if a.mean < b.mean {
+			return -1
+		} else if a.mean > b.mean {
+			return 1
+		}
+		return 0
+	})
+
+	merged := []centroid{centroids[0]}
+	before := 0.0
+	for _, next := range centroids[1:] {
+		current := &merged[len(merged)-1]
+		left := percentile.scale(before / percentile.count)
+		right := percentile.scale((before + current.weight + next.weight) / percentile.count)
+		if right-left <= 1 {
+			current.weight += next.weight
+			current.mean += (next.mean - current.mean) * next.weight / current.weight
+			continue
+		}
+		before += current.weight
+		merged = append(merged, next)
+	}
+
+	percentile.centroids = merged
+	percentile.buffer = nil
+}
+
+// Value estimates the percentile by interpolating between the centres of the
+// centroids around it and between the extremes of the values at the tails.
+func (percentile *Percentile) Value() float64 {
+	percentile.merge()
+	if percentile.count == 0 {
+		return math.NaN()
+	}
+
+	target := percentile.percentile / 100 * percentile.count
+	centroids := percentile.centroids
+
+	first := centroids[0]
+	if target < first.weight/2 {
+		if first.weight == 1 {
+			return percentile.min
+		}
+		return percentile.min + (first.mean-percentile.min)*target/(first.weight/2)
+	}
+
+	before := 0.0
+	for idx := 0; idx+1 < len(centroids); idx++ {
+		left, right := centroids[idx], centroids[idx+1]
+		centre := before + left.weight/2
+		next := before + left.weight + right.weight/2
+		if target < next {
+			return left.mean + (right.mean-left.mean)*(target-centre)/(next-centre)
+		}
+		before += left.weight
+	}
+
+	last := centroids[len(centroids)-1]
+	centre := percentile.count - last.weight/2
+	if last.weight == 1 || target >= percentile.count {
+		return percentile.max
+	}
+	return last.mean + (percentile.max-last.mean)*(target-centre)/(last.weight/2)
+}
@@ -0,0 +1,159 @@
+package language
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregators(t *testing.T) {
+	tests := []struct {
+		description string
+		aggregator  Aggregator
+		values      []float64
+		expected    float64
+	}{
+		{"mean", NewMean(), []float64{1, 2, 3, 6}, 3},
+		{"mean of nothing", NewMean(), nil, math.NaN()},
+		{"sum", NewSum(), []float64{1, 2, 3, -6}, 0},
+		{"count", NewCount(), []float64{5, 0, 5}, 3},
+		{"median", NewPercentile(50), []float64{3, 1, 2}, 2},
+		{"minimum", NewPercentile(0), []float64{3, 1, 2}, 1},
+		{"maximum", NewPercentile(100), []float64{3, 1, 2}, 3},
+		{"percentile of nothing", NewPercentile(95), nil, math.NaN()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			for _, value := range tt.values {
+				tt.aggregator.Add(value)
+			}
+			if math.IsNaN(tt.expected) {
+				assert.True(t, math.IsNaN(tt.aggregator.Value()))
+			} else {
+				assert.InDelta(t, tt.expected, tt.aggregator.Value(), 1e-9)
+			}
+		})
+	}
+
+	assert.PanicsWithValue(t, "percentile must be between 0 and 100", func() {
+		NewPercentile(101)
+	})
+}
+
+func TestPercentile(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	var values []float64
+	for range 100000 {
+		values = append(values, random.ExpFloat64())
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	for _, percentile := range []float64{1, 50, 95, 99, 99.9} {
+		estimator := NewPercentile(percentile)
+		for _, value := range values {
+			estimator.Add(value)
+		}
+
+		// The t-digest is accurate by rank, in particular at the tails.
+		rank, _ := slices.BinarySearch(sorted, estimator.Value())
+		assert.InDelta(t, percentile/100, float64(rank)/float64(len(sorted)), 0.005, "percentile %v", percentile)
+		assert.Less(t, len(estimator.centroids), 200)
+	}
+}
+
+func TestAggregateHyperAssertion(t *testing.T) {
+	type request struct {
+		latency time.Duration
+	}
+
+	valuations := 0
+	// The mean latency is at most 100ms.
+	mean := NewAggregateHyperAssertion[request](0, func() Aggregator { return NewMean() },
+		func(assignments []request) (float64, bool) {
+			valuations++
+			return float64(assignments[0].latency), true
+		},
+		func(assignments []request, aggregate float64) bool {
+			return aggregate <= float64(100*time.Millisecond)
+		},
+	)
+
+	interpreter := NewHyperAssertionInterpreter[request]()
+	// Exact aggregates are unknown while they hold as more requests may still
+	// violate them.
+	assert.Equal(t, LiftedUnknown, interpreter.Satisfies(mean, nil))
+	assert.Equal(t, LiftedUnknown, interpreter.Satisfies(mean, []request{{50 * time.Millisecond}, {150 * time.Millisecond}}))
+	assert.Equal(t, LiftedFalse, interpreter.Satisfies(mean, []request{{50 * time.Millisecond}, {200 * time.Millisecond}}))
+
+	// Histories are aggregated incrementally.
+	contract := NewCompositeHyperContract(nil, []HyperAssertion[request]{mean})
+	valuations = 0
+	for _, latency := range []time.Duration{50, 100, 150, 50} {
+		assert.False(t, contract.Record(request{latency * time.Millisecond}).Violated())
+	}
+	assert.True(t, contract.Record(request{300 * time.Millisecond}).Violated())
+	assert.Equal(t, 5, valuations)
+
+	// A violated mean is no longer violated after faster requests.
+	assert.True(t, contract.Record(request{10 * time.Millisecond}).Violated())
+	assert.False(t, contract.Record(request{10 * time.Millisecond}).Violated())
+
+	// A reset history is aggregated anew.
+	contract.Reset()
+	assert.False(t, contract.Record(request{100 * time.Millisecond}).Violated())
+	assert.Equal(t, 8, valuations)
+}
+
+func TestParseAggregateErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		source      string
+		message     string
+	}{
+		{
+			description: "no comparison",
+			source:      "guarantee: mean t. t.latency",
+			message:     `mean expected a comparison of a value with a bound but got "t.latency"`,
+		},
+		{
+			description: "logical operator",
+			source:      "guarantee: sum t. t.x <= 1 && t.ok",
+			message:     `sum expected a comparison of a value with a bound but got "t.x <= 1 && t.ok"`,
+		},
+		{
+			description: "several variables",
+			source:      "guarantee: count a b. a.x == b.x >= 1",
+			message:     "count aggregates exactly one variable but got 2",
+		},
+		{
+			description: "confidence out of range",
+			source:      "guarantee: probability(95) t. t.ok >= 0.9",
+			message:     "confidence 95 is not between 0 and 1",
+		},
+		{
+			description: "estimate compared by equality",
+			source:      "guarantee: mean(0.9) t. t.latency == 1",
+			message:     "mean estimates are only compared by <, <=, > and >=",
+		},
+		{
+			description: "percentile out of range",
+			source:      "guarantee: percentile(120) t. t.latency <= 1",
+			message:     "percentile 120 is not between 0 and 100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.PanicsWithValue(t, tt.message, func() {
+				parser := NewParser(LexString(tt.source))
+				parser.Parse()
+			})
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package language
 
+import "slices"
+
 type Node interface{}
 
+// Contract is the obligations of a function in regions. The composition names
+// the functions whose executions the contract relates if it is composite and
+// is nil otherwise.
 type Contract struct {
-	regions []Region
+	composition []string
+	regions     []Region
 }
 
 type Region struct {
@@ -12,24 +18,57 @@ type Region struct {
 	guarantees  []Node
 }
 
+// Universal quantifies over the executions. The types name the function of
+// which every variable is an execution and are nil if no variable is typed. An
+// untyped variable is an execution of the function with the contract. A
+// distinct universal never assigns the same execution to two variables.
 type Universal struct {
 	variables []string
+	types     []string
+	distinct  bool
 	assertion Node
 }
 
+// Existential quantifies over the executions with the types of its variables
+// as for Universal.
 type Existential struct {
 	variables []string
+	types     []string
 	assertion Node
 }
 
+// Assumption is an obligation on the inputs of the executions. The label names
+// the assumption in reports and is empty if it is unlabelled.
 type Assumption struct {
+	label     string
 	assertion Node
 }
 
+// Guarantee is an obligation on the executions. The label names the guarantee
+// in reports and is empty if it is unlabelled.
 type Guarantee struct {
+	label     string
 	assertion Node
 }
 
+// Aggregate compares the aggregate of a value of the executions with a bound,
+// e.g. mean t. t.latency <= 100ms. The function is "mean", "sum", "count",
+// "probability" or "percentile" in which case the percentile is its argument.
+// The value and bound are Go expressions separated by the operator and the
+// value is the condition of the executions counted by "count" or estimated by
+// "probability". Estimates have a confidence which is zero for the exact
+// aggregates of the executions. The variable has a type as for Universal.
+type Aggregate struct {
+	function   string
+	percentile float64
+	confidence float64
+	variables  []string
+	types      []string
+	value      Node
+	operator   string
+	bound      Node
+}
+
 type GoExpresion struct {
 	code string
 }
@@ -60,6 +99,20 @@ func NewContract(regions ...Region) Contract {
 	}
 }
 
+// NewCompositeContract returns a contract relating the executions of the
+// composed functions.
+func NewCompositeContract(composition []string, regions ...Region) Contract {
+	return Contract{
+		composition: composition,
+		regions:     regions,
+	}
+}
+
+// Composition returns the functions composed by the contract.
+func (contract Contract) Composition() []string {
+	return contract.composition
+}
+
 func NewRegion(name []string, assumptions, guarantees []Node) Region {
 	return Region{
 		name:        name,
@@ -82,6 +135,65 @@ func NewExistential(variables []string, assertion Node) Existential {
 	}
 }
 
+// NewTypedUniversal returns a universal quantifier over executions of the
+// functions typing its variables.
+func NewTypedUniversal(variables, types []string, assertion Node) Universal {
+	return Universal{
+		variables: variables,
+		types:     types,
+		assertion: assertion,
+	}
+}
+
+// NewDistinctUniversal returns a typed universal quantifier over distinct
+// executions, e.g. forall distinct e0 e1. e0.id != e1.id.
+func NewDistinctUniversal(variables, types []string, assertion Node) Universal {
+	universal := NewTypedUniversal(variables, types, assertion)
+	universal.distinct = true
+	return universal
+}
+
+// NewTypedExistential returns an existential quantifier over executions of the
+// functions typing its variables.
+func NewTypedExistential(variables, types []string, assertion Node) Existential {
+	return Existential{
+		variables: variables,
+		types:     types,
+		assertion: assertion,
+	}
+}
+
+// NewAggregate returns the comparison of the mean, sum or count of the value of
+// the executions assigned to the variable with the bound.
+func NewAggregate(function string, variables, types []string, value Node, operator string, bound Node) Aggregate {
+	return Aggregate{
+		function:  function,
+		variables: variables,
+		types:     types,
+		value:     value,
+		operator:  operator,
+		bound:     bound,
+	}
+}
+
+// NewPercentileAggregate returns the comparison of the percentile of the value of the
+// executions assigned to the variable with the bound.
+func NewPercentileAggregate(percentile float64, variables, types []string, value Node, operator string, bound Node) Aggregate {
+	aggregate := NewAggregate("percentile", variables, types, value, operator, bound)
+	aggregate.percentile = percentile
+	return aggregate
+}
+
+// NewEstimateAggregate returns the comparison of the mean or probability of the
+// value of the executions, estimated with the confidence, with the bound.
+func NewEstimateAggregate(
+	function string, confidence float64, variables, types []string, value Node, operator string, bound Node,
+) Aggregate {
+	aggregate := NewAggregate(function, variables, types, value, operator, bound)
+	aggregate.confidence = confidence
+	return aggregate
+}
+
 func NewAssumption(assertion Node) Assumption {
 	return Assumption{
 		assertion: assertion,
@@ -94,6 +206,28 @@ func NewGuarantee(assertion Node) Guarantee {
 	}
 }
 
+func NewLabelledAssumption(label string, assertion Node) Assumption {
+	return Assumption{
+		label:     label,
+		assertion: assertion,
+	}
+}
+
+func NewLabelledGuarantee(label string, assertion Node) Guarantee {
+	return Guarantee{
+		label:     label,
+		assertion: assertion,
+	}
+}
+
+func (assumption Assumption) Label() string {
+	return assumption.label
+}
+
+func (guarantee Guarantee) Label() string {
+	return guarantee.label
+}
+
 func NewProbabilisticQuantifier(body Node) ProbabilisticQuantifier {
 	return ProbabilisticQuantifier{
 		event: body,
@@ -128,3 +262,134 @@ func NewGroup(node Node) Group {
 		node: node,
 	}
 }
+
+// Inspect traverses the contract AST in depth-first order. It calls f for each
+// node and only visits the children of the node if f returns true.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	switch cast := node.(type) {
+	case Contract:
+		for idx := range cast.regions {
+			Inspect(cast.regions[idx], f)
+		}
+	case Region:
+		for idx := range cast.assumptions {
+			Inspect(cast.assumptions[idx], f)
+		}
+		for idx := range cast.guarantees {
+			Inspect(cast.guarantees[idx], f)
+		}
+	case Universal:
+		Inspect(cast.assertion, f)
+	case Existential:
+		Inspect(cast.assertion, f)
+	case Assumption:
+		Inspect(cast.assertion, f)
+	case Guarantee:
+		Inspect(cast.assertion, f)
+	case Aggregate:
+		Inspect(cast.value, f)
+		Inspect(cast.bound, f)
+	case ProbabilisticQuantifier:
+		Inspect(cast.event, f)
+	case ConditionalProbabilityQuantifier:
+		Inspect(cast.event, f)
+		Inspect(cast.given, f)
+	case Group:
+		Inspect(cast.node, f)
+	}
+}
+
+// Transform rebuilds the contract AST bottom-up. It replaces every node by the
+// result of f applied to the node with its children already transformed. The
+// result of f must be a node of the same type for contracts and regions.
+func Transform(node Node, f func(Node) Node) Node {
+	if node == nil {
+		return nil
+	}
+
+	switch cast := node.(type) {
+	case Contract:
+		var regions []Region
+		for idx := range cast.regions {
+			regions = append(regions, Transform(cast.regions[idx], f).(Region))
+		}
+		node = NewCompositeContract(cast.composition, regions...)
+	case Region:
+		var assumptions, guarantees []Node
+		for idx := range cast.assumptions {
+			assumptions = append(assumptions, Transform(cast.assumptions[idx], f))
+		}
+		for idx := range cast.guarantees {
+			guarantees = append(guarantees, Transform(cast.guarantees[idx], f))
+		}
+		node = NewRegion(cast.name, assumptions, guarantees)
+	case Universal:
+		universal := NewTypedUniversal(cast.variables, cast.types, Transform(cast.assertion, f))
+		universal.distinct = cast.distinct
+		node = universal
+	case Existential:
+		node = NewTypedExistential(cast.variables, cast.types, Transform(cast.assertion, f))
+	case Assumption:
+		node = NewLabelledAssumption(cast.label, Transform(cast.assertion, f))
+	case Guarantee:
+		node = NewLabelledGuarantee(cast.label, Transform(cast.assertion, f))
+	case Aggregate:
+		aggregate := cast
+		aggregate.value = Transform(cast.value, f)
+		aggregate.bound = Transform(cast.bound, f)
+		node = aggregate
+	case ProbabilisticQuantifier:
+		node = NewProbabilisticQuantifier(Transform(cast.event, f))
+	case ConditionalProbabilityQuantifier:
+		node = NewConditionalProbabilityQuantifier(Transform(cast.event, f), Transform(cast.given, f))
+	case Group:
+		node = NewGroup(Transform(cast.node, f))
+	}
+
+	return f(node)
+}
+
+// Merge merges the contracts into one. Regions with the same name are merged
+// into one region with the obligations of the contracts in order and the
+// region without a name is first. The merged contract composes the functions
+// composed by any of the contracts.
+func Merge(contracts ...Contract) Contract {
+	var composition []string
+	var regions []Region
+	for _, contract := range contracts {
+		for _, function := range contract.composition {
+			if !slices.Contains(composition, function) {
+				composition = append(composition, function)
+			}
+		}
+		for _, region := range contract.regions {
+			idx := slices.IndexFunc(regions, func(merged Region) bool {
+				return slices.Equal(merged.name, region.name)
+			})
+			if idx < 0 {
+				regions = append(regions, NewRegion(
+					region.name, slices.Clone(region.assumptions), slices.Clone(region.guarantees),
+				))
+				continue
+			}
+			regions[idx].assumptions = append(regions[idx].assumptions, region.assumptions...)
+			regions[idx].guarantees = append(regions[idx].guarantees, region.guarantees...)
+		}
+	}
+
+	slices.SortStableFunc(regions, func(a, b Region) int {
+		if len(a.name) == 0 && len(b.name) > 0 {
+			return -1
+		}
+		if len(a.name) > 0 && len(b.name) == 0 {
+			return 1
+		}
+		return 0
+	})
+
+	return NewCompositeContract(composition, regions...)
+}
@@ -0,0 +1,316 @@
+package language
+
+import (
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/hyperproperties/sopher/pkg/quick"
+)
+
+// CheckConfiguration controls how Check generates and evaluates execution sets.
+type CheckConfiguration struct {
+	// Runs is the number of execution sets generated and checked.
+	Runs int
+	// Executions is the number of executions in each execution set.
+	Executions int
+	// Discards is the number of generated inputs rejected by the assumptions
+	// before giving up on completing an execution set.
+	Discards int
+	// Size is the size hint passed to the generator.
+	Size int
+	// Seed is the seed of the random source.
+	Seed uint64
+}
+
+type CheckOption func(configuration *CheckConfiguration)
+
+func WithRuns(runs int) CheckOption {
+	return func(configuration *CheckConfiguration) {
+		configuration.Runs = runs
+	}
+}
+
+func WithExecutions(executions int) CheckOption {
+	return func(configuration *CheckConfiguration) {
+		configuration.Executions = executions
+	}
+}
+
+func WithDiscards(discards int) CheckOption {
+	return func(configuration *CheckConfiguration) {
+		configuration.Discards = discards
+	}
+}
+
+func WithSize(size int) CheckOption {
+	return func(configuration *CheckConfiguration) {
+		configuration.Size = size
+	}
+}
+
+func WithSeed(seed uint64) CheckOption {
+	return func(configuration *CheckConfiguration) {
+		configuration.Seed = seed
+	}
+}
+
+func NewCheckConfiguration(options ...CheckOption) CheckConfiguration {
+	configuration := CheckConfiguration{
+		Runs:       100,
+		Executions: 10,
+		Discards:   1000,
+		Size:       quick.DefaultSize,
+		Seed:       rand.Uint64(),
+	}
+
+	for _, option := range options {
+		option(&configuration)
+	}
+
+	return configuration
+}
+
+// Check tests the hyper-contract as a property of the function. For every run
+// a set of inputs satisfying the assumptions is generated, the function is
+// called on each and the guarantees are evaluated on the resulting executions.
+// The first violating execution set is shrunk and reported through t.Errorf
+// together with the labels of the guarantees it violates.
+// The call receives an execution with only its inputs and must return the
+// execution with its outputs assigned.
+func Check[T any](t testing.TB, contract AGHyperContract[T], call func(input T) T, options ...CheckOption) bool {
+	t.Helper()
+
+	configuration := NewCheckConfiguration(options...)
+	random := rand.New(rand.NewPCG(configuration.Seed, configuration.Seed))
+
+	for run := 0; run < configuration.Runs; run++ {
+		inputs, ok := generateInputs(&contract, random, configuration)
+		if !ok {
+			t.Errorf(
+				"gave up after %v discarded inputs in run %v (seed %v): the assumptions are too strict for random generation",
+				configuration.Discards, run, configuration.Seed,
+			)
+			return false
+		}
+
+		if violated(t, &contract, call, inputs, run, configuration) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckSelfComposition tests the 2-safety hyper-contract of the function by
+// self-composition. For every run a pair of inputs satisfying the assumptions
+// is generated where the second is paired with the first by the pair function,
+// e.g. by sharing its low inputs, and the function is called on both. The
+// first violating pair is shrunk and reported through t.Errorf as by Check.
+func CheckSelfComposition[T any](
+	t testing.TB, contract AGHyperContract[T], pair func(e0, e1 T) T, call func(input T) T, options ...CheckOption,
+) bool {
+	t.Helper()
+
+	configuration := NewCheckConfiguration(options...)
+	random := rand.New(rand.NewPCG(configuration.Seed, configuration.Seed))
+
+	for run := 0; run < configuration.Runs; run++ {
+		var inputs []T
+		for discards := 0; inputs == nil; discards++ {
+			if discards >= configuration.Discards {
+				t.Errorf(
+					"gave up after %v discarded pairs in run %v (seed %v): the assumptions are too strict for random generation",
+					configuration.Discards, run, configuration.Seed,
+				)
+				return false
+			}
+
+			e0 := quick.NewWith[T](random, configuration.Size)
+			e1 := pair(e0, quick.NewWith[T](random, configuration.Size))
+			if !contract.Assume(e0, e1).IsFalse() {
+				inputs = []T{e0, e1}
+			}
+		}
+
+		if violated(t, &contract, call, inputs, run, configuration) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// violated calls the function on the inputs of the run and reports whether
+// the executions violate the guarantees, in which case they are shrunk and
+// reported through t.Errorf.
+func violated[T any](
+	t testing.TB, contract *AGHyperContract[T], call func(input T) T, inputs []T, run int, configuration CheckConfiguration,
+) bool {
+	t.Helper()
+
+	executions := execute(call, inputs)
+	if !contract.Guarantee(executions...).IsFalse() {
+		return false
+	}
+
+	inputs, executions = shrink(contract, call, inputs, executions)
+	t.Errorf(
+		"%v in run %v (seed %v) by %v execution(s):\n%s",
+		contract.GuaranteeViolation(executions...), run, configuration.Seed,
+		len(executions), formatExecutions(executions),
+	)
+	return true
+}
+
+// generateInputs incrementally grows a set of inputs where every addition
+// must keep the set satisfying the assumptions.
+func generateInputs[T any](
+	contract *AGHyperContract[T], random *rand.Rand, configuration CheckConfiguration,
+) ([]T, bool) {
+	inputs := make([]T, 0, configuration.Executions)
+	discards := 0
+
+	for len(inputs) < configuration.Executions {
+		input := quick.NewWith[T](random, configuration.Size)
+		if contract.Assume(append(inputs, input)...).IsFalse() {
+			discards++
+			if discards >= configuration.Discards {
+				return nil, false
+			}
+			continue
+		}
+		inputs = append(inputs, input)
+	}
+
+	return inputs, true
+}
+
+func execute[T any](call func(input T) T, inputs []T) []T {
+	executions := make([]T, len(inputs))
+	for idx := range inputs {
+		executions[idx] = call(inputs[idx])
+	}
+	return executions
+}
+
+// violates reports whether the inputs are admitted by the assumptions and the
+// executions violate the guarantees.
+func violates[T any](contract *AGHyperContract[T], inputs, executions []T) bool {
+	return !contract.Assume(inputs...).IsFalse() && contract.Guarantee(executions...).IsFalse()
+}
+
+// shrink minimises a violating execution set. First executions are removed
+// from the set and then numbers of the remaining inputs are moved towards zero
+// as long as the set is admitted by the assumptions and still violating.
+func shrink[T any](contract *AGHyperContract[T], call func(input T) T, inputs, executions []T) ([]T, []T) {
+	for idx := 0; idx < len(inputs); {
+		candidateInputs := append(append([]T{}, inputs[:idx]...), inputs[idx+1:]...)
+		candidateExecutions := append(append([]T{}, executions[:idx]...), executions[idx+1:]...)
+		if len(candidateInputs) > 0 && violates(contract, candidateInputs, candidateExecutions) {
+			inputs, executions = candidateInputs, candidateExecutions
+		} else {
+			idx++
+		}
+	}
+
+	// Shrinking one input can enable further shrinking of another so
+	// all inputs are revisited until no input can be shrunk.
+	for shrunk := true; shrunk; {
+		shrunk = false
+		for idx := range inputs {
+			for candidate := range shrinkValue(inputs[idx]) {
+				candidateInputs := append([]T{}, inputs...)
+				candidateInputs[idx] = candidate
+				candidateExecutions := append([]T{}, executions...)
+				candidateExecutions[idx] = call(candidate)
+				if violates(contract, candidateInputs, candidateExecutions) {
+					inputs, executions = candidateInputs, candidateExecutions
+					shrunk = true
+					break
+				}
+			}
+		}
+	}
+
+	return inputs, executions
+}
+
+// shrinkValue yields copies of the value where a single number has been
+// replaced by zero or halved towards zero.
+func shrinkValue[T any](value T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		var numbers []reflect.Value
+		var collect func(value reflect.Value)
+		collect = func(value reflect.Value) {
+			switch value.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64:
+				numbers = append(numbers, value)
+			case reflect.Struct:
+				for idx := 0; idx < value.NumField(); idx++ {
+					collect(value.Field(idx))
+				}
+			case reflect.Array:
+				for idx := 0; idx < value.Len(); idx++ {
+					collect(value.Index(idx))
+				}
+			}
+		}
+
+		for idx := 0; ; idx++ {
+			copied := value
+			numbers = numbers[:0]
+			collect(reflect.ValueOf(&copied).Elem())
+			if idx >= len(numbers) {
+				return
+			}
+
+			// Unexported fields are made settable in the same way as quick does.
+			pointer := unsafe.Pointer(numbers[idx].UnsafeAddr())
+			number := reflect.NewAt(numbers[idx].Type(), pointer).Elem()
+			if number.IsZero() {
+				continue
+			}
+
+			original := reflect.ValueOf(number.Interface())
+			for _, shrunk := range []func(){
+				func() { number.SetZero() },
+				func() { halve(number, original) },
+			} {
+				number.Set(original)
+				shrunk()
+				if number.Equal(original) {
+					continue
+				}
+				if !yield(copied) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func halve(number, original reflect.Value) {
+	switch number.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number.SetInt(original.Int() / 2)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number.SetUint(original.Uint() / 2)
+	case reflect.Float32, reflect.Float64:
+		number.SetFloat(original.Float() / 2)
+	}
+}
+
+func formatExecutions[T any](executions []T) string {
+	var builder strings.Builder
+	for idx, execution := range executions {
+		builder.WriteString(fmt.Sprintf("\te%v: %+v\n", idx, execution))
+	}
+	return builder.String()
+}
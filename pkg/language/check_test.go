@@ -0,0 +1,127 @@
+package language
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder captures the errors reported by Check.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (recorder *recorder) Helper() {}
+
+func (recorder *recorder) Errorf(format string, args ...any) {
+	recorder.errors = append(recorder.errors, fmt.Sprintf(format, args...))
+}
+
+type monotoneExecution struct {
+	input  int16
+	output int32
+}
+
+func monotoneContract() AGHyperContract[monotoneExecution] {
+	return NewAGHyperContract(
+		[]HyperAssertion[monotoneExecution]{
+			NewUniversalHyperAssertion(0, 1, NewPredicateHyperAssertion(
+				func(assignments []monotoneExecution) bool {
+					e := assignments[0]
+					return e.input >= 0
+				},
+			)),
+		},
+		[]HyperAssertion[monotoneExecution]{
+			NewUniversalHyperAssertion(0, 2, NewPredicateHyperAssertion(
+				func(assignments []monotoneExecution) bool {
+					e0, e1 := assignments[0], assignments[1]
+					return (e0.input >= e1.input) == (e0.output >= e1.output)
+				},
+			)),
+		},
+	)
+}
+
+func TestCheckSatisfied(t *testing.T) {
+	satisfied := Check(t, monotoneContract(), func(execution monotoneExecution) monotoneExecution {
+		execution.output = int32(execution.input) + 1
+		return execution
+	}, WithRuns(20), WithExecutions(5))
+	assert.True(t, satisfied)
+}
+
+func TestCheckViolatedIsShrunk(t *testing.T) {
+	recorder := &recorder{}
+	satisfied := Check(recorder, monotoneContract(), func(execution monotoneExecution) monotoneExecution {
+		execution.output = int32(execution.input % 100)
+		return execution
+	}, WithRuns(100), WithExecutions(5), WithSeed(1))
+
+	assert.False(t, satisfied)
+	assert.Len(t, recorder.errors, 1)
+	assert.Contains(t, recorder.errors[0], "by 2 execution(s)")
+}
+
+func TestCheckGivesUp(t *testing.T) {
+	recorder := &recorder{}
+	contract := NewAGHyperContract(
+		[]HyperAssertion[monotoneExecution]{
+			NewUniversalHyperAssertion(0, 1, NewPredicateHyperAssertion(
+				func(assignments []monotoneExecution) bool {
+					return false
+				},
+			)),
+		},
+		[]HyperAssertion[monotoneExecution]{},
+	)
+
+	satisfied := Check(recorder, contract, func(execution monotoneExecution) monotoneExecution {
+		return execution
+	}, WithDiscards(10))
+
+	assert.False(t, satisfied)
+	assert.Len(t, recorder.errors, 1)
+	assert.Contains(t, recorder.errors[0], "gave up")
+}
+
+func TestCheckSelfComposition(t *testing.T) {
+	type execution struct {
+		low, high int8
+		ret       int16
+	}
+
+	// Executions with the same low input return the same.
+	contract := NewAGHyperContract(nil, []HyperAssertion[execution]{
+		NewUniversalHyperAssertion(0, 2, NewPredicateHyperAssertion(
+			func(assignments []execution) bool {
+				e0, e1 := assignments[0], assignments[1]
+				return e0.low != e1.low || e0.ret == e1.ret
+			},
+		)),
+	})
+	pair := func(e0, e1 execution) execution {
+		e1.low = e0.low
+		return e1
+	}
+
+	secure := func(e execution) execution {
+		e.ret = int16(e.low) * 2
+		return e
+	}
+	assert.True(t, CheckSelfComposition(t, contract, pair, secure, WithRuns(50)))
+
+	// The pairs of a seed are reproduced.
+	insecure := func(e execution) execution {
+		e.ret = int16(e.low) + int16(e.high)
+		return e
+	}
+	first, second := &recorder{}, &recorder{}
+	assert.False(t, CheckSelfComposition(first, contract, pair, insecure, WithSeed(3)))
+	assert.False(t, CheckSelfComposition(second, contract, pair, insecure, WithSeed(3)))
+	assert.Len(t, first.errors, 1)
+	assert.Contains(t, first.errors[0], "(seed 3) by 2 execution(s)")
+	assert.Equal(t, first.errors, second.errors)
+}
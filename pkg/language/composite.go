@@ -0,0 +1,252 @@
+package language
+
+import (
+	"fmt"
+	"go/token"
+	"slices"
+	"strings"
+
+	"github.com/dave/dst"
+)
+
+// composite is a composite contract with the function declaring it and the
+// functions whose executions it relates with the declaring function first.
+type composite struct {
+	function  string
+	functions []string
+	contract  Contract
+}
+
+// docText returns the text of the doc comment without comment markers.
+func docText(comments []string) string {
+	var text strings.Builder
+	for _, comment := range comments {
+		comment = strings.TrimPrefix(comment, "//")
+		comment = strings.TrimSuffix(strings.TrimPrefix(comment, "/*"), "*/")
+		text.WriteString(comment)
+		text.WriteRune('\n')
+	}
+	return text.String()
+}
+
+// docContract parses the contract in the doc comment of the function. False is
+// returned if the doc comment is not a contract.
+func docContract(function *dst.FuncDecl) (Contract, bool) {
+	comments := function.Decs.NodeDecs.Start
+	if !IsContract(docText(comments)) {
+		return Contract{}, false
+	}
+	parser := NewParser(LexDocStrings(comments))
+	return parser.Parse(), true
+}
+
+// compose finds the composite contracts declared in the files of a package by
+// the names of the functions they compose.
+func compose(files ...*dst.File) map[string][]composite {
+	declared := make(map[string]struct{})
+	var composites []composite
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			function, ok := decl.(*dst.FuncDecl)
+			if !ok {
+				continue
+			}
+			if function.Recv == nil {
+				declared[function.Name.Name] = struct{}{}
+			}
+
+			contract, ok := docContract(function)
+			if !ok || len(contract.composition) == 0 {
+				continue
+			}
+			if function.Recv != nil {
+				panic(fmt.Sprintf("%v is a method but only functions can compose", function.Name.Name))
+			}
+
+			functions := []string{function.Name.Name}
+			for _, composed := range contract.composition {
+				if !slices.Contains(functions, composed) {
+					functions = append(functions, composed)
+				}
+			}
+			composites = append(composites, composite{function.Name.Name, functions, contract})
+		}
+	}
+
+	composed := make(map[string][]composite)
+	for _, composite := range composites {
+		for _, function := range composite.functions {
+			if _, exists := declared[function]; !exists {
+				panic(fmt.Sprintf("%v composes %v which is not a function of its package", composite.function, function))
+			}
+			composed[function] = append(composed[function], composite)
+		}
+
+		Inspect(composite.contract, func(node Node) bool {
+			var types []string
+			switch cast := node.(type) {
+			case Universal:
+				types = cast.types
+			case Existential:
+				types = cast.types
+			case Aggregate:
+				types = cast.types
+			}
+			for _, function := range types {
+				if function != "" && !slices.Contains(composite.functions, function) {
+					panic(fmt.Sprintf("%v types a variable with %v which it does not compose", composite.function, function))
+				}
+			}
+			return true
+		})
+	}
+
+	return composed
+}
+
+// Compose finds the composite contracts in the files of a package such that
+// Inject wires the executions of every composed function into them. Without
+// it Inject only finds the composite contracts of the file it instruments.
+func (injector *Injector) Compose(files ...*dst.File) {
+	injector.composites = compose(files...)
+}
+
+// Composite returns the declarations of the model of the composite contract
+// with a field per composed function and of the contract shared by them.
+func (injector Injector) Composite(composite composite) (string, []dst.Decl) {
+	modelName := composite.function + "_CompositionModel"
+	contractName := composite.function + "_Composition"
+
+	var fields []*dst.Field
+	for _, function := range composite.functions {
+		fields = append(fields, &dst.Field{
+			Names: []*dst.Ident{dst.NewIdent(function)},
+			Type:  &dst.StarExpr{X: dst.NewIdent(function + "_ExecutionModel")},
+		})
+	}
+
+	var assumptions, guarantees []Node
+	for _, region := range composite.contract.regions {
+		assumptions = append(assumptions, region.assumptions...)
+		guarantees = append(guarantees, region.guarantees...)
+	}
+
+	constructor := injector.constructor(
+		NewGoCompositeMonitorFactory("sopher", modelName, composite.function),
+		&dst.IndexExpr{
+			X: &dst.SelectorExpr{
+				X:   dst.NewIdent("sopher"),
+				Sel: dst.NewIdent("NewCompositeHyperContract"),
+			},
+			Index: dst.NewIdent(modelName),
+		},
+		modelName, assumptions, guarantees,
+	)
+
+	return contractName, []dst.Decl{
+		&dst.GenDecl{
+			Tok: token.TYPE,
+			Specs: []dst.Spec{
+				&dst.TypeSpec{
+					Name: dst.NewIdent(modelName),
+					Type: &dst.StructType{Fields: &dst.FieldList{List: fields}},
+				},
+			},
+		},
+		&dst.GenDecl{
+			Tok: token.VAR,
+			Specs: []dst.Spec{
+				&dst.ValueSpec{
+					Names:  []*dst.Ident{dst.NewIdent(contractName)},
+					Values: []dst.Expr{constructor},
+				},
+			},
+		},
+	}
+}
+
+// composed returns the model of the composite contract declared by the
+// function holding the execution of the composed function.
+func (injector Injector) composed(declaring, function string) *dst.CompositeLit {
+	return &dst.CompositeLit{
+		Type: dst.NewIdent(declaring + "_CompositionModel"),
+		Elts: []dst.Expr{
+			&dst.KeyValueExpr{
+				Key:   dst.NewIdent(function),
+				Value: &dst.UnaryExpr{Op: token.AND, X: dst.NewIdent("execution")},
+			},
+		},
+	}
+}
+
+// CompositeCheck panics with the violation of the composite contract declared
+// by the function if the execution of the composed function violates it. The
+// name is either "Assume", checking the assumptions before the call, or
+// "Guarantee", recording the execution and checking the guarantees after it.
+func (injector Injector) CompositeCheck(name, declaring, function string) *dst.IfStmt {
+	method := "Record"
+	if name == "Assume" {
+		method = "AssumptionViolation"
+	}
+
+	return &dst.IfStmt{
+		Init: &dst.AssignStmt{
+			Lhs: []dst.Expr{dst.NewIdent("violation")},
+			Tok: token.DEFINE,
+			Rhs: []dst.Expr{
+				&dst.CallExpr{
+					Fun: &dst.SelectorExpr{
+						X:   dst.NewIdent(declaring + "_Composition"),
+						Sel: dst.NewIdent(method),
+					},
+					Args: []dst.Expr{injector.composed(declaring, function)},
+				},
+			},
+		},
+		Cond: &dst.CallExpr{
+			Fun: &dst.SelectorExpr{
+				X:   dst.NewIdent("violation"),
+				Sel: dst.NewIdent("Violated"),
+			},
+		},
+		Body: &dst.BlockStmt{
+			List: []dst.Stmt{
+				&dst.ExprStmt{
+					X: &dst.CallExpr{
+						Fun:  dst.NewIdent("panic"),
+						Args: []dst.Expr{dst.NewIdent("violation")},
+					},
+				},
+			},
+		},
+	}
+}
+
+// CompositeObserve records the execution of the composed function in the
+// composite contract declared by the function and reports the violated
+// guarantees to the fault runtime.
+func (injector Injector) CompositeObserve(declaring, function string) *dst.ExprStmt {
+	return &dst.ExprStmt{
+		X: &dst.CallExpr{
+			Fun: &dst.SelectorExpr{
+				X:   dst.NewIdent("faults"),
+				Sel: dst.NewIdent("Observe"),
+			},
+			Args: []dst.Expr{
+				&dst.CallExpr{
+					Fun: &dst.SelectorExpr{
+						X: &dst.CallExpr{
+							Fun: &dst.SelectorExpr{
+								X:   dst.NewIdent(declaring + "_Composition"),
+								Sel: dst.NewIdent("Record"),
+							},
+							Args: []dst.Expr{injector.composed(declaring, function)},
+						},
+						Sel: dst.NewIdent("Names"),
+					},
+				},
+			},
+			Ellipsis: true,
+		},
+	}
+}
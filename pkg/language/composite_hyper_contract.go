@@ -0,0 +1,80 @@
+package language
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// CompositeHyperContract is a contract relating the executions of several
+// functions. The executions of every composed function are recorded in a
+// history shared by the functions such that its obligations quantify over
+// executions of different functions, e.g. reads after erasures. T has a pointer
+// field to the execution model of every composed function of which exactly one
+// is set for an execution. The history is ordered by the time the executions
+// were recorded and is kept until it is reset, such that its memory grows with
+// every recorded execution. Obligations only evaluate the assignments of the
+// execution checked or recorded with those before it, as the others were
+// evaluated when it was recorded, so a violation is only reported by the
+// execution completing it.
+type CompositeHyperContract[T any] struct {
+	mutex      sync.Mutex
+	contract   AGHyperContract[T]
+	executions []T
+	times      []time.Time
+}
+
+func NewCompositeHyperContract[T any](
+	assumptions, guarantees []HyperAssertion[T],
+) *CompositeHyperContract[T] {
+	return &CompositeHyperContract[T]{
+		contract: NewAGHyperContract(assumptions, guarantees),
+	}
+}
+
+// AssumptionViolation evaluates every assumption on the recorded executions
+// and the execution about to be made now without recording it.
+func (contract *CompositeHyperContract[T]) AssumptionViolation(execution T) Violation {
+	contract.mutex.Lock()
+	defer contract.mutex.Unlock()
+
+	executions := append(slices.Clone(contract.executions), execution)
+	times := append(slices.Clone(contract.times), time.Now())
+	return contract.contract.violation(
+		"assumption", contract.contract.assumptions, executions, times, false, len(contract.executions),
+	)
+}
+
+// Record records the execution made now and evaluates every guarantee on the
+// recorded executions returning those violated.
+func (contract *CompositeHyperContract[T]) Record(execution T) Violation {
+	contract.mutex.Lock()
+	defer contract.mutex.Unlock()
+
+	seen := len(contract.executions)
+	contract.executions = append(contract.executions, execution)
+	contract.times = append(contract.times, time.Now())
+	return contract.contract.violation(
+		"guarantee", contract.contract.guarantees, contract.executions, contract.times, true, seen,
+	)
+}
+
+// Executions returns the number of recorded executions.
+func (contract *CompositeHyperContract[T]) Executions() int {
+	contract.mutex.Lock()
+	defer contract.mutex.Unlock()
+
+	return len(contract.executions)
+}
+
+// Reset forgets the recorded executions and the aggregates of them.
+func (contract *CompositeHyperContract[T]) Reset() {
+	contract.mutex.Lock()
+	defer contract.mutex.Unlock()
+
+	contract.executions = nil
+	contract.times = nil
+	for _, guarantee := range contract.contract.guarantees {
+		forget(guarantee)
+	}
+}
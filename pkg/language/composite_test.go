@@ -0,0 +1,176 @@
+package language
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/dave/dst/decorator"
+	"github.com/stretchr/testify/assert"
+)
+
+type readExecution struct {
+	path string
+	ret0 bool
+}
+
+type eraseExecution struct {
+	path string
+}
+
+type erasureExecution struct {
+	Read  *readExecution
+	Erase *eraseExecution
+}
+
+func TestCompositeHyperContract(t *testing.T) {
+	// No read of an erased path succeeds.
+	contract := NewCompositeHyperContract(
+		[]HyperAssertion[erasureExecution]{
+			NewUniversalHyperAssertion[erasureExecution](0, 1, NewPredicateHyperAssertion(func(assignments []erasureExecution) bool {
+				e := assignments[0].Erase
+				return e == nil || e.path != ""
+			})),
+		},
+		[]HyperAssertion[erasureExecution]{
+			NewUniversalHyperAssertion[erasureExecution](0, 2, NewPredicateHyperAssertion(func(assignments []erasureExecution) bool {
+				e, r := assignments[0].Erase, assignments[1].Read
+				return e == nil || r == nil || e.path != r.path || !r.ret0
+			})),
+		},
+	)
+
+	assert.True(t, contract.AssumptionViolation(erasureExecution{Erase: &eraseExecution{""}}).Violated())
+	assert.Equal(t, 0, contract.Executions())
+
+	assert.False(t, contract.Record(erasureExecution{Read: &readExecution{"a", true}}).Violated())
+	assert.False(t, contract.Record(erasureExecution{Erase: &eraseExecution{"b"}}).Violated())
+	assert.False(t, contract.Record(erasureExecution{Read: &readExecution{"b", false}}).Violated())
+
+	violation := contract.Record(erasureExecution{Read: &readExecution{"b", true}})
+	assert.Equal(t, "guarantee", violation.Kind)
+	assert.Equal(t, []string{"#0"}, violation.Names())
+	assert.Equal(t, 4, contract.Executions())
+
+	contract.Reset()
+	assert.Equal(t, 0, contract.Executions())
+}
+
+func TestCompositeHistory(t *testing.T) {
+	type execution struct {
+		x int
+	}
+
+	evaluations := 0
+	contract := NewCompositeHyperContract(nil, []HyperAssertion[execution]{
+		NewUniversalHyperAssertion[execution](0, 2, NewPredicateHyperAssertion(func(assignments []execution) bool {
+			evaluations++
+			return assignments[0].x != 4 || assignments[1].x != 2
+		})),
+		NewUniversalHyperAssertion[execution](0, 1, NewUniversalHyperAssertion[execution](1, 1,
+			NewPredicateHyperAssertion(func(assignments []execution) bool {
+				return assignments[0].x != 0 || assignments[1].x != 9
+			}),
+		)),
+	})
+
+	// Only the assignments of the recorded execution are evaluated.
+	for recorded := 1; recorded <= 3; recorded++ {
+		evaluations = 0
+		assert.False(t, contract.Record(execution{recorded}).Violated())
+		assert.Equal(t, 2*recorded-1, evaluations)
+	}
+
+	// A violation is only reported by the execution completing it.
+	assert.Equal(t, []string{"#0"}, contract.Record(execution{4}).Names())
+	assert.False(t, contract.Record(execution{5}).Violated())
+
+	// Nested universals may be violated by the recorded execution for every
+	// assignment of the outer universal.
+	assert.False(t, contract.Record(execution{0}).Violated())
+	assert.Equal(t, []string{"#1"}, contract.Record(execution{9}).Names())
+}
+
+func TestInjectComposite(t *testing.T) {
+	source := `package storage
+
+func Read(path string) bool {
+	return path != ""
+}
+
+// compose: Read
+// guarantee "Erased": forall e, r: Read. r.path != e.path || !r.ret0
+func Erase(path string) {}
+`
+
+	file, err := decorator.NewDecorator(token.NewFileSet()).ParseFile("storage.go", source, parser.ParseComments)
+	assert.NoError(t, err)
+
+	injector := NewGoInjector()
+	injector.Inject(file)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, decorator.Fprint(&buffer, file))
+	instrumented := buffer.String()
+
+	// Composed functions are instrumented without contracts of their own.
+	assert.Contains(t, instrumented, "type Read_ExecutionModel struct")
+	assert.NotContains(t, instrumented, "Read_Contract")
+	assert.NotContains(t, instrumented, "Erase_Contract")
+
+	assert.Contains(t, instrumented, `type Erase_CompositionModel struct {
+	Erase *Erase_ExecutionModel
+	Read  *Read_ExecutionModel
+}`)
+	assert.Contains(t, instrumented, "var Erase_Composition = sopher.NewCompositeHyperContract[Erase_CompositionModel](")
+	assert.Contains(t, instrumented, `			r := elements[i1].Read
+			if r == nil {
+				continue
+			}
+			_, _ = e, r
+			if !(r.path != e.path || !r.ret0) {
+				return sopher.LiftedFalse, []int{i0, i1}
+			}`)
+
+	for _, function := range []string{"Read", "Erase"} {
+		assert.Contains(t, instrumented, "if violation := Erase_Composition.AssumptionViolation(Erase_CompositionModel{"+function+": &execution}); violation.Violated() {")
+		assert.Contains(t, instrumented, "if violation := Erase_Composition.Record(Erase_CompositionModel{"+function+": &execution}); violation.Violated() {")
+	}
+}
+
+func TestComposeErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		source      string
+		panic       string
+	}{
+		{
+			description: "undeclared function",
+			source:      "package storage\n\n// compose: Read\n// guarantee: forall e. true\nfunc Erase(path string) {}\n",
+			panic:       "Erase composes Read which is not a function of its package",
+		},
+		{
+			description: "uncomposed type",
+			source:      "package storage\n\nfunc Read() {}\n\n// compose: Read\n// guarantee: forall e: Write. true\nfunc Erase(path string) {}\n",
+			panic:       "Erase types a variable with Write which it does not compose",
+		},
+		{
+			description: "method",
+			source:      "package storage\n\ntype Disk struct{}\n\n// compose: Erase\n// guarantee: forall e. true\nfunc (Disk) Read() {}\n\nfunc Erase() {}\n",
+			panic:       "Read is a method but only functions can compose",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			file, err := decorator.NewDecorator(token.NewFileSet()).ParseFile("storage.go", tt.source, parser.ParseComments)
+			assert.NoError(t, err)
+
+			injector := NewGoInjector()
+			assert.PanicsWithValue(t, tt.panic, func() {
+				injector.Compose(file)
+			})
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package language
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// Estimator is an aggregator estimating a value of all executions from the
+// executions aggregated so far with an interval containing the value with the
+// confidence, e.g. the probability of a condition holding for executions.
+type Estimator interface {
+	Aggregator
+	Interval() (lower, upper float64)
+	Confidence() float64
+}
+
+// Compile time checking interface implementations:
+var (
+	_ Estimator = (*Proportion)(nil)
+	_ Estimator = (*BootstrapMean)(nil)
+)
+
+// Resolve resolves the comparison of the value estimated by the interval. The
+// comparison is accepted if the whole interval satisfies it and rejected if
+// the whole interval violates it, otherwise it is unknown until the interval
+// is narrowed by more executions. The comparison must be monotone, e.g.
+// value >= 0.95, such that it holds for the interval if it holds for its ends.
+func Resolve(lower, upper float64, comparison func(value float64) bool) LiftedBoolean {
+	atLower, atUpper := comparison(lower), comparison(upper)
+	if atLower && atUpper {
+		return LiftedTrue
+	} else if !atLower && !atUpper {
+		return LiftedFalse
+	}
+	return LiftedUnknown
+}
+
+// normalQuantile returns the quantile of the standard normal distribution of a
+// two-sided interval with the confidence.
+func normalQuantile(confidence float64) float64 {
+	return math.Sqrt2 * math.Erfinv(confidence)
+}
+
+// Wilson returns the Wilson score interval of the probability of success from
+// the number of successes of the trials. The interval is [0, 1] if there are
+// no trials.
+func Wilson(successes, trials int, confidence float64) (lower, upper float64) {
+	if trials == 0 {
+		return 0, 1
+	}
+
+	n := float64(trials)
+	p := float64(successes) / n
+	z := normalQuantile(confidence)
+	centre := (p + z*z/(2*n)) / (1 + z*z/n)
+	margin := z / (1 + z*z/n) * math.Sqrt(p*(1-p)/n+z*z/(4*n*n))
+	return math.Max(0, centre-margin), math.Min(1, centre+margin)
+}
+
+// ClopperPearson returns the exact Clopper-Pearson interval of the probability
+// of success from the number of successes of the trials. It is conservative,
+// containing the probability with at least the confidence, where the Wilson
+// interval only does so on average. The interval is [0, 1] if there are no
+// trials.
+func ClopperPearson(successes, trials int, confidence float64) (lower, upper float64) {
+	alpha := 1 - confidence
+	lower, upper = 0, 1
+	if successes > 0 {
+		lower = betaQuantile(alpha/2, float64(successes), float64(trials-successes+1))
+	}
+	if successes < trials {
+		upper = betaQuantile(1-alpha/2, float64(successes+1), float64(trials-successes))
+	}
+	return lower, upper
+}
+
+// betaQuantile returns the quantile of the beta distribution with the shapes
+// by bisecting its cumulative distribution function.
+func betaQuantile(quantile, a, b float64) float64 {
+	low, high := 0.0, 1.0
+	for range 100 {
+		middle := (low + high) / 2
+		if incompleteBeta(middle, a, b) < quantile {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2
+}
+
+// incompleteBeta returns the regularized incomplete beta function of x with the
+// shapes evaluated by its continued fraction.
+func incompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	} else if x >= 1 {
+		return 1
+	}
+
+	// The continued fraction converges fast for x below the mean.
+	if x > (a+1)/(a+b+2) {
+		return 1 - incompleteBeta(1-x, b, a)
+	}
+
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+
+	const tiny = 1e-300
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	fraction := d
+	for m := 1.0; m <= 300; m++ {
+		for _, numerator := range []float64{
+			m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m)),
+			-(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1)),
+		} {
+			d = 1 + numerator*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + numerator/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			fraction *= d * c
+		}
+		if math.Abs(d*c-1) < 1e-15 {
+			break
+		}
+	}
+	return front * fraction / a
+}
+
+// Proportion estimates the probability of the values being non-zero, e.g. of
+// a condition holding for an execution, with a confidence interval.
+type Proportion struct {
+	confidence float64
+	interval   func(successes, trials int, confidence float64) (lower, upper float64)
+	successes  int
+	trials     int
+}
+
+// NewProportion returns an estimator of a probability by the Wilson interval.
+func NewProportion(confidence float64) *Proportion {
+	return &Proportion{
+		confidence: confidence,
+		interval:   Wilson,
+	}
+}
+
+// NewExactProportion returns an estimator of a probability by the
+// Clopper-Pearson interval.
+func NewExactProportion(confidence float64) *Proportion {
+	return &Proportion{
+		confidence: confidence,
+		interval:   ClopperPearson,
+	}
+}
+
+func (proportion *Proportion) Add(value float64) {
+	proportion.trials++
+	if value != 0 {
+		proportion.successes++
+	}
+}
+
+func (proportion *Proportion) Value() float64 {
+	if proportion.trials == 0 {
+		return math.NaN()
+	}
+	return float64(proportion.successes) / float64(proportion.trials)
+}
+
+func (proportion *Proportion) Interval() (lower, upper float64) {
+	return proportion.interval(proportion.successes, proportion.trials, proportion.confidence)
+}
+
+func (proportion *Proportion) Confidence() float64 {
+	return proportion.confidence
+}
+
+// BootstrapMean estimates the mean of the values with a percentile bootstrap
+// interval. The interval is bootstrapped from a uniform sample of at most a
+// thousand of the values such that it is computed in bounded time and memory
+// and is centred on the mean of all values. The interval is unbounded until two
+// values are added. It is only bootstrapped anew once the number of values has
+// doubled since it was last bootstrapped, such that monitoring costs amortised
+// constant time per value, and in between the bootstrapped deviations from the
+// mean are rescaled to the number of values.
+type BootstrapMean struct {
+	confidence float64
+	mean       Mean
+	sample     []float64
+	random     *rand.Rand
+	// bootstrapped is the number of values when the interval was last
+	// bootstrapped, and lower and upper are the deviations of its bounds from
+	// the mean for a single value.
+	bootstrapped int
+	lower, upper float64
+}
+
+// bootstrapSample is the number of values bootstrapped and bootstrapResamples
+// is the number of means of resamples the interval is bootstrapped from.
+const (
+	bootstrapSample    = 1000
+	bootstrapResamples = 1000
+)
+
+func NewBootstrapMean(confidence float64) *BootstrapMean {
+	return &BootstrapMean{
+		confidence: confidence,
+		random:     rand.New(rand.NewSource(0)),
+	}
+}
+
+func (mean *BootstrapMean) Add(value float64) {
+	mean.mean.Add(value)
+	if len(mean.sample) < bootstrapSample {
+		mean.sample = append(mean.sample, value)
+	} else if idx := mean.random.Intn(mean.mean.count); idx < bootstrapSample {
+		mean.sample[idx] = value
+	}
+}
+
+func (mean *BootstrapMean) Value() float64 {
+	return mean.mean.Value()
+}
+
+func (mean *BootstrapMean) Interval() (lower, upper float64) {
+	if len(mean.sample) < 2 {
+		return math.Inf(-1), math.Inf(1)
+	}
+	if mean.bootstrapped == 0 || mean.mean.count >= 2*mean.bootstrapped {
+		mean.bootstrap()
+	}
+
+	// The standard error of a mean shrinks by the square root of the number
+	// of values.
+	scale := 1 / math.Sqrt(float64(mean.mean.count))
+	return mean.mean.Value() + mean.lower*scale, mean.mean.Value() + mean.upper*scale
+}
+
+// bootstrap bootstraps the deviations of the bounds of the interval from the
+// mean of the sample and scales them to a single value.
+func (mean *BootstrapMean) bootstrap() {
+	// The resamples are drawn by a generator of their own such that the same
+	// values always have the same interval.
+	random := rand.New(rand.NewSource(int64(mean.mean.count)))
+	means := make([]float64, bootstrapResamples)
+	for resample := range means {
+		sum := 0.0
+		for range mean.sample {
+			sum += mean.sample[random.Intn(len(mean.sample))]
+		}
+		means[resample] = sum / float64(len(mean.sample))
+	}
+	slices.Sort(means)
+
+	alpha := 1 - mean.confidence
+	at := func(quantile float64) float64 {
+		return means[min(len(means)-1, int(quantile*float64(len(means))))]
+	}
+	centre := mean.sampleMean()
+	scale := math.Sqrt(float64(len(mean.sample)))
+	mean.lower, mean.upper = (at(alpha/2)-centre)*scale, (at(1-alpha/2)-centre)*scale
+	mean.bootstrapped = mean.mean.count
+}
+
+func (mean *BootstrapMean) sampleMean() float64 {
+	sum := 0.0
+	for _, value := range mean.sample {
+		sum += value
+	}
+	return sum / float64(len(mean.sample))
+}
+
+func (mean *BootstrapMean) Confidence() float64 {
+	return mean.confidence
+}
@@ -0,0 +1,136 @@
+package language
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntervals(t *testing.T) {
+	tests := []struct {
+		description          string
+		interval             func(successes, trials int, confidence float64) (float64, float64)
+		successes, trials    int
+		confidence           float64
+		expectedLower, upper float64
+	}{
+		{"wilson", Wilson, 8, 10, 0.95, 0.4902, 0.9433},
+		{"wilson without successes", Wilson, 0, 20, 0.95, 0, 0.1611},
+		{"wilson without trials", Wilson, 0, 0, 0.95, 0, 1},
+		{"clopper-pearson", ClopperPearson, 8, 10, 0.95, 0.4439, 0.9748},
+		{"clopper-pearson without successes", ClopperPearson, 0, 10, 0.95, 0, 0.3085},
+		{"clopper-pearson without failures", ClopperPearson, 10, 10, 0.99, 0.5887, 1},
+		{"clopper-pearson without trials", ClopperPearson, 0, 0, 0.95, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			lower, upper := tt.interval(tt.successes, tt.trials, tt.confidence)
+			assert.InDelta(t, tt.expectedLower, lower, 1e-4)
+			assert.InDelta(t, tt.upper, upper, 1e-4)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	atLeast := func(value float64) bool { return value >= 0.9 }
+	assert.Equal(t, LiftedTrue, Resolve(0.92, 0.99, atLeast))
+	assert.Equal(t, LiftedFalse, Resolve(0.5, 0.85, atLeast))
+	assert.Equal(t, LiftedUnknown, Resolve(0.85, 0.95, atLeast))
+}
+
+func TestBootstrapMean(t *testing.T) {
+	mean := NewBootstrapMean(0.95)
+	mean.Add(10)
+	lower, upper := mean.Interval()
+	assert.True(t, math.IsInf(lower, -1) && math.IsInf(upper, 1))
+
+	var widths []float64
+	for idx := range 5000 {
+		mean.Add(float64(idx % 21))
+		if idx == 99 || idx == 4999 {
+			lower, upper = mean.Interval()
+			assert.Less(t, lower, mean.Value())
+			assert.Greater(t, upper, mean.Value())
+			widths = append(widths, upper-lower)
+		}
+	}
+
+	// The standard error of the mean is about 0.085 after 5000 values.
+	assert.InDelta(t, 10, mean.Value(), 0.01)
+	assert.InDelta(t, 4*0.085, widths[1], 0.1)
+	assert.Less(t, widths[1], widths[0])
+	assert.Len(t, mean.sample, bootstrapSample)
+
+	// The interval is only bootstrapped anew once the values have doubled.
+	bootstrapped := mean.bootstrapped
+	for range bootstrapped - 1 {
+		mean.Add(10)
+		mean.Interval()
+	}
+	assert.Equal(t, bootstrapped, mean.bootstrapped)
+	lower, upper = mean.Interval()
+	mean.Add(10)
+	rescaled, _ := mean.Interval()
+	assert.Equal(t, 2*bootstrapped, mean.bootstrapped)
+	assert.Less(t, lower, rescaled)
+	assert.Greater(t, upper, rescaled)
+}
+
+func BenchmarkBootstrapMean(b *testing.B) {
+	mean := NewBootstrapMean(0.95)
+	for idx := range b.N {
+		mean.Add(float64(idx % 21))
+		mean.Interval()
+	}
+}
+
+func TestEstimates(t *testing.T) {
+	type request struct {
+		fast bool
+	}
+
+	// At least 90% of the requests are fast.
+	fast := NewLabelledHyperAssertion[request]("Fast", NewAggregateHyperAssertion[request](
+		0, func() Aggregator { return NewProportion(0.95) },
+		func(assignments []request) (float64, bool) {
+			if assignments[0].fast {
+				return 1, true
+			}
+			return 0, true
+		},
+		func(assignments []request, aggregate float64) bool { return aggregate >= 0.9 },
+	))
+	contract := NewAGHyperContract(nil, []HyperAssertion[request]{fast})
+
+	requests := func(fast, slow int) (requests []request) {
+		for range fast {
+			requests = append(requests, request{true})
+		}
+		for range slow {
+			requests = append(requests, request{false})
+		}
+		return requests
+	}
+
+	// Too few requests to tell is not a violation but is estimated.
+	violation := contract.GuaranteeViolation(requests(9, 1)...)
+	assert.False(t, violation.Violated())
+	if assert.Len(t, violation.Estimates, 1) {
+		estimate := violation.Estimates[0]
+		assert.Equal(t, Obligation{Index: 0, Label: "Fast"}, estimate.Obligation)
+		assert.Equal(t, LiftedUnknown, estimate.Result)
+		assert.InDelta(t, 0.9, estimate.Value, 1e-9)
+		assert.Less(t, estimate.Lower, 0.9)
+	}
+
+	violation = contract.GuaranteeViolation(requests(990, 10)...)
+	assert.False(t, violation.Violated())
+	assert.Equal(t, LiftedTrue, violation.Estimates[0].Result)
+
+	violation = contract.GuaranteeViolation(requests(80, 20)...)
+	assert.True(t, violation.Violated())
+	assert.Equal(t, LiftedFalse, violation.Estimates[0].Result)
+	assert.Equal(t, `guarantee "Fast" (0.8 in [0.711, 0.867] at 95% confidence) violated`, violation.Error())
+}
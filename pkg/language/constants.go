@@ -0,0 +1,156 @@
+package language
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"math"
+	"path"
+	"strconv"
+
+	"github.com/dave/dst"
+)
+
+const (
+	// faultsPath is the import path of the fault runtime.
+	faultsPath = "github.com/hyperproperties/sopher/pkg/faults"
+	// quickPath is the import path of the generator registering literals.
+	quickPath = "github.com/hyperproperties/sopher/pkg/quick"
+)
+
+// Literals harvests the values of the numeric, string and character literals
+// in the body of the function and the Go expressions of its contract. Negated
+// literals are kept negated. The literals are used to bias generation towards
+// values the function and contract compare against. Calls of the fault runtime,
+// by whatever name the file imports it, are instrumentation and not harvested.
+// The file may be nil if the function is not instrumented.
+func Literals(file *dst.File, function *dst.FuncDecl, contract Contract) (literals []constant.Value) {
+	seen := make(map[string]struct{})
+	add := func(kind token.Token, value string, negated bool) {
+		switch kind {
+		case token.INT, token.FLOAT, token.STRING, token.CHAR:
+		default:
+			return
+		}
+		literal := constant.MakeFromLiteral(value, kind, 0)
+		if literal.Kind() == constant.Unknown {
+			return
+		}
+		if negated {
+			literal = constant.UnaryOp(token.SUB, literal, 0)
+		}
+		key := literal.Kind().String() + literal.ExactString()
+		if _, exists := seen[key]; exists {
+			return
+		}
+		seen[key] = struct{}{}
+		literals = append(literals, literal)
+	}
+
+	instrumentation := make(map[string]struct{})
+	if file != nil {
+		for _, spec := range file.Imports {
+			imported, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || imported != faultsPath {
+				continue
+			}
+			name := path.Base(imported)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			instrumentation[name] = struct{}{}
+		}
+	}
+
+	if function != nil && function.Body != nil {
+		dst.Inspect(function.Body, func(node dst.Node) bool {
+			switch cast := node.(type) {
+			case *dst.CallExpr:
+				if selector, ok := cast.Fun.(*dst.SelectorExpr); ok {
+					if identifier, ok := selector.X.(*dst.Ident); ok {
+						if _, exists := instrumentation[identifier.Name]; exists {
+							return false
+						}
+					}
+				}
+			case *dst.UnaryExpr:
+				if literal, ok := cast.X.(*dst.BasicLit); ok && cast.Op == token.SUB {
+					add(literal.Kind, literal.Value, true)
+					return false
+				}
+			case *dst.BasicLit:
+				add(cast.Kind, cast.Value, false)
+			}
+			return true
+		})
+	}
+
+	Inspect(contract, func(node Node) bool {
+		expression, ok := node.(GoExpresion)
+		if !ok {
+			return true
+		}
+
+		// Expressions which are not valid Go cannot contribute any literals.
+		expr, err := parser.ParseExpr(expression.code)
+		if err != nil {
+			return true
+		}
+
+		ast.Inspect(expr, func(node ast.Node) bool {
+			switch cast := node.(type) {
+			case *ast.UnaryExpr:
+				if literal, ok := cast.X.(*ast.BasicLit); ok && cast.Op == token.SUB {
+					add(literal.Kind, literal.Value, true)
+					return false
+				}
+			case *ast.BasicLit:
+				add(cast.Kind, cast.Value, false)
+			}
+			return true
+		})
+
+		return true
+	})
+
+	return literals
+}
+
+// typedLiteral returns the literal converted to the type it fits, e.g.
+// uint64(18446744073709551615), such that it keeps its value when passed as
+// any instead of defaulting to int. Integers are int64 or uint64 if they fit
+// and otherwise float64, and literals which fit neither are not returned.
+func typedLiteral(literal constant.Value) (dst.Expr, bool) {
+	number := func(typ string, kind token.Token, value string, negative bool) dst.Expr {
+		var expression dst.Expr = &dst.BasicLit{Kind: kind, Value: value}
+		if negative {
+			expression = &dst.UnaryExpr{Op: token.SUB, X: expression}
+		}
+		return &dst.CallExpr{Fun: dst.NewIdent(typ), Args: []dst.Expr{expression}}
+	}
+
+	switch literal.Kind() {
+	case constant.String:
+		return &dst.BasicLit{Kind: token.STRING, Value: strconv.Quote(constant.StringVal(literal))}, true
+	case constant.Int:
+		negative := constant.Sign(literal) < 0
+		magnitude := constant.UnaryOp(token.SUB, literal, 0)
+		if !negative {
+			magnitude = literal
+		}
+		if _, exact := constant.Int64Val(literal); exact {
+			return number("int64", token.INT, magnitude.ExactString(), negative), true
+		}
+		if _, exact := constant.Uint64Val(literal); exact {
+			return number("uint64", token.INT, magnitude.ExactString(), false), true
+		}
+	}
+
+	// Floats and integers beyond 64 bits are rounded to the nearest float64.
+	value, _ := constant.Float64Val(literal)
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return nil, false
+	}
+	return number("float64", token.FLOAT, strconv.FormatFloat(math.Abs(value), 'g', -1, 64), value < 0), true
+}
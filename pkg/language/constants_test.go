@@ -0,0 +1,130 @@
+package language
+
+import (
+	"bytes"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiterals(t *testing.T) {
+	source := `package examples
+
+import sites "github.com/hyperproperties/sopher/pkg/faults"
+
+func Abs(input int) int {
+	sites.Flip(7, &input)
+	if input < -1 {
+		return -input
+	}
+	return input + 0x0
+}`
+
+	file, err := decorator.NewDecorator(token.NewFileSet()).ParseFile("abs.go", source, parser.ParseComments)
+	assert.NoError(t, err)
+	function := file.Decls[1].(*dst.FuncDecl)
+
+	parser := NewParser(LexString("guarantee: forall e. e.ret0 >= 0 && e.name != \"root\""))
+	var literals []string
+	for _, literal := range Literals(file, function, parser.Parse()) {
+		literals = append(literals, literal.ExactString())
+	}
+
+	// The site of the aliased fault runtime is not harvested and 0x0 and 0 are
+	// the same value.
+	assert.ElementsMatch(t, []string{"-1", "0", "\"root\""}, literals)
+}
+
+func TestTypedLiteral(t *testing.T) {
+	tests := []struct {
+		description string
+		kind        token.Token
+		literal     string
+		negated     bool
+		expected    string
+	}{
+		{"int", token.INT, "42", false, "int64(42)"},
+		{"negative int", token.INT, "1", true, "int64(-1)"},
+		{"minimum int64", token.INT, "9223372036854775808", true, "int64(-9223372036854775808)"},
+		{"mask", token.INT, "0xFFFFFFFFFFFFFFFF", false, "uint64(18446744073709551615)"},
+		{"beyond 64 bits", token.INT, "1" + "00000000000000000000", false, "float64(1e+20)"},
+		{"float", token.FLOAT, "0.1", false, "float64(0.1)"},
+		{"negative float", token.FLOAT, "2.5", true, "float64(-2.5)"},
+		{"char", token.CHAR, "'a'", false, "int64(97)"},
+		{"string", token.STRING, "`root`", false, "\"root\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			literal := constant.MakeFromLiteral(tt.literal, tt.kind, 0)
+			if tt.negated {
+				literal = constant.UnaryOp(token.SUB, literal, 0)
+			}
+			expression, ok := typedLiteral(literal)
+			assert.True(t, ok)
+
+			file := &dst.File{Name: dst.NewIdent("p"), Decls: []dst.Decl{&dst.GenDecl{
+				Tok:   token.VAR,
+				Specs: []dst.Spec{&dst.ValueSpec{Names: []*dst.Ident{dst.NewIdent("_")}, Values: []dst.Expr{expression}}},
+			}}}
+			var buffer bytes.Buffer
+			assert.NoError(t, decorator.Fprint(&buffer, file))
+			assert.Contains(t, buffer.String(), "var _ = "+tt.expected)
+		})
+	}
+
+	_, ok := typedLiteral(constant.MakeFromLiteral("1e400", token.FLOAT, 0))
+	assert.False(t, ok, "floats beyond float64 fit no type")
+}
+
+func TestInterestingTest(t *testing.T) {
+	source := `package examples
+
+// guarantee: forall e. e.ret0 == 18446744073709551615
+func Mask(x uint64) bool {
+	return x&0xFFFFFFFFFFFFFFFF == 18446744073709551615
+}
+
+func Plain() int {
+	return 3
+}`
+
+	file, err := decorator.Parse(source)
+	assert.NoError(t, err)
+	test, ok := NewGoInjector().InterestingTest(file)
+	assert.True(t, ok)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, decorator.Fprint(&buffer, test))
+	assert.Contains(t, buffer.String(), "quick.Interesting(uint64(18446744073709551615))")
+	assert.NotContains(t, buffer.String(), "int64(3)")
+
+	// The registration type checks although the literal overflows int.
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, "mask_interesting_sopher_test.go", buffer.Bytes(), 0)
+	assert.NoError(t, err)
+	quick := types.NewPackage(quickPath, "quick")
+	values := types.NewVar(token.NoPos, quick, "values", types.NewSlice(types.Universe.Lookup("any").Type()))
+	signature := types.NewSignatureType(nil, nil, nil, types.NewTuple(values), nil, true)
+	quick.Scope().Insert(types.NewFunc(token.NoPos, quick, "Interesting", signature))
+	quick.MarkComplete()
+	config := types.Config{Importer: importerFunc(func(string) (*types.Package, error) { return quick, nil })}
+	_, err = config.Check("examples", fset, []*ast.File{parsed}, nil)
+	assert.NoError(t, err)
+
+	_, ok = NewGoInjector().InterestingTest(&dst.File{Name: dst.NewIdent("examples")})
+	assert.False(t, ok, "files without contracted functions register no literals")
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (importer importerFunc) Import(path string) (*types.Package, error) {
+	return importer(path)
+}
@@ -0,0 +1,337 @@
+package language
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/dave/dst/dstutil"
+	"github.com/hyperproperties/sopher/pkg/faults"
+	"github.com/hyperproperties/sopher/pkg/filesx"
+)
+
+// FaultInjector instruments contracted functions with both their contracts and
+// fault sites. At runtime the fault sites randomly flip bits in variables,
+// branch conditions or return values as configured through the faults package,
+// and every guarantee check is reported as an observation such that faults can
+// be classified as detected or undetected by the contract monitors.
+type FaultInjector struct {
+	injector Injector
+	kinds    []faults.Kind
+	sites    []faults.Site
+}
+
+// NewGoFaultInjector creates a fault injector for the kinds of sites. All kinds
+// of sites are instrumented if none are given.
+func NewGoFaultInjector(kinds ...faults.Kind) *FaultInjector {
+	if len(kinds) == 0 {
+		kinds = []faults.Kind{faults.VariableSite, faults.ConditionSite, faults.ReturnSite, faults.StatementSite}
+	}
+
+	return &FaultInjector{
+		injector: Injector{observe: true},
+		kinds:    kinds,
+	}
+}
+
+// Sidecars reads the contracts of the sidecar files and checks their syntax.
+func (injector *FaultInjector) Sidecars(paths iter.Seq[string]) error {
+	return injector.injector.Sidecars(paths)
+}
+
+// Interfaces inherits the contracts of interface methods onto the methods
+// implementing them in the same way as Injector.Interfaces.
+func (injector *FaultInjector) Interfaces(directory string, patterns ...string) error {
+	return injector.injector.Interfaces(directory, patterns...)
+}
+
+// Sites returns all fault sites instrumented so far.
+func (injector *FaultInjector) Sites() []faults.Site {
+	return injector.sites
+}
+
+func (injector *FaultInjector) site(kind faults.Kind, function string, position string, expression string) int {
+	id := len(injector.sites)
+	injector.sites = append(injector.sites, faults.Site{
+		ID:         id,
+		Kind:       kind,
+		Function:   function,
+		Position:   position,
+		Expression: expression,
+	})
+	return id
+}
+
+func (injector *FaultInjector) selector(name string) *dst.SelectorExpr {
+	return &dst.SelectorExpr{
+		X:   dst.NewIdent("faults"),
+		Sel: dst.NewIdent(name),
+	}
+}
+
+func (injector *FaultInjector) identifier(id int) *dst.BasicLit {
+	return &dst.BasicLit{Kind: token.INT, Value: fmt.Sprintf("%v", id)}
+}
+
+// results returns the type of every result of the function in order.
+func (injector *FaultInjector) results(function *dst.FuncDecl) (types []dst.Expr) {
+	if function.Type.Results == nil {
+		return nil
+	}
+
+	for _, field := range function.Type.Results.List {
+		count := max(len(field.Names), 1)
+		for range count {
+			types = append(types, field.Type)
+		}
+	}
+
+	return types
+}
+
+// Faults rewrites the body of the function such that faults can be injected
+// at its variables, branch conditions, return values and statements. The describe function
+// returns the position and source of a node for reporting.
+func (injector *FaultInjector) Faults(function *dst.FuncDecl, describe func(dst.Node) (string, string)) {
+	if function.Body == nil {
+		return
+	}
+
+	name := function.Name.Name
+	results := injector.results(function)
+
+	dstutil.Apply(function.Body, func(cursor *dstutil.Cursor) bool {
+		// Returns of closures have different result types than the function.
+		_, closure := cursor.Node().(*dst.FuncLit)
+		return !closure
+	}, func(cursor *dstutil.Cursor) bool {
+		switch cast := cursor.Node().(type) {
+		case *dst.ExprStmt, *dst.IncDecStmt, *dst.SendStmt:
+			if slices.Contains(injector.kinds, faults.StatementSite) && cursor.Index() >= 0 {
+				cursor.Replace(injector.skip(name, cast.(dst.Stmt), describe))
+			}
+		case *dst.AssignStmt:
+			if cursor.Index() < 0 {
+				return true
+			}
+
+			// Declarations cannot be skipped as they scope the variables.
+			if cast.Tok != token.DEFINE && slices.Contains(injector.kinds, faults.StatementSite) {
+				cursor.Replace(injector.skip(name, cast, describe))
+			}
+
+			if !slices.Contains(injector.kinds, faults.VariableSite) {
+				return true
+			}
+
+			// Faults are injected after the assignment through the address of
+			// the variable such that its type is known without type checking.
+			for _, lhs := range cast.Lhs {
+				identifier, ok := lhs.(*dst.Ident)
+				if !ok || identifier.Name == "_" {
+					continue
+				}
+
+				position, _ := describe(cast)
+				id := injector.site(faults.VariableSite, name, position, identifier.Name)
+				cursor.InsertAfter(&dst.ExprStmt{
+					X: &dst.CallExpr{
+						Fun: injector.selector("Variable"),
+						Args: []dst.Expr{
+							injector.identifier(id),
+							&dst.UnaryExpr{Op: token.AND, X: dst.NewIdent(identifier.Name)},
+						},
+					},
+				})
+			}
+		case *dst.IfStmt:
+			if slices.Contains(injector.kinds, faults.ConditionSite) {
+				cast.Cond = injector.condition(name, cast.Cond, describe)
+			}
+		case *dst.ForStmt:
+			if cast.Cond != nil && slices.Contains(injector.kinds, faults.ConditionSite) {
+				cast.Cond = injector.condition(name, cast.Cond, describe)
+			}
+		case *dst.ReturnStmt:
+			// Naked returns and returns of multi-valued calls are skipped.
+			if !slices.Contains(injector.kinds, faults.ReturnSite) || len(cast.Results) != len(results) {
+				return true
+			}
+
+			for idx, result := range cast.Results {
+				position, expression := describe(result)
+				id := injector.site(faults.ReturnSite, name, position, expression)
+				cast.Results[idx] = &dst.CallExpr{
+					Fun: &dst.IndexExpr{
+						X:     injector.selector("Value"),
+						Index: dst.Clone(results[idx]).(dst.Expr),
+					},
+					Args: []dst.Expr{injector.identifier(id), result},
+				}
+			}
+		}
+		return true
+	})
+}
+
+func (injector *FaultInjector) condition(
+	function string, condition dst.Expr, describe func(dst.Node) (string, string),
+) dst.Expr {
+	position, expression := describe(condition)
+	id := injector.site(faults.ConditionSite, function, position, expression)
+	return &dst.CallExpr{
+		Fun:  injector.selector("Condition"),
+		Args: []dst.Expr{injector.identifier(id), condition},
+	}
+}
+
+// skip guards the statement such that it is skipped if a fault is injected.
+func (injector *FaultInjector) skip(
+	function string, statement dst.Stmt, describe func(dst.Node) (string, string),
+) dst.Stmt {
+	position, expression := describe(statement)
+	id := injector.site(faults.StatementSite, function, position, expression)
+	return &dst.IfStmt{
+		Cond: &dst.UnaryExpr{
+			Op: token.NOT,
+			X: &dst.CallExpr{
+				Fun:  injector.selector("Skipped"),
+				Args: []dst.Expr{injector.identifier(id)},
+			},
+		},
+		Body: &dst.BlockStmt{List: []dst.Stmt{statement}},
+	}
+}
+
+// Register returns an init function registering the sites with the runtime.
+func (injector *FaultInjector) Register(sites []faults.Site) *dst.FuncDecl {
+	kinds := map[faults.Kind]string{
+		faults.VariableSite:  "VariableSite",
+		faults.ConditionSite: "ConditionSite",
+		faults.ReturnSite:    "ReturnSite",
+		faults.StatementSite: "StatementSite",
+	}
+
+	var statements []dst.Stmt
+	for _, site := range sites {
+		literal := &dst.CompositeLit{
+			Type: injector.selector("Site"),
+			Elts: []dst.Expr{
+				&dst.KeyValueExpr{Key: dst.NewIdent("ID"), Value: injector.identifier(site.ID)},
+				&dst.KeyValueExpr{Key: dst.NewIdent("Kind"), Value: injector.selector(kinds[site.Kind])},
+				&dst.KeyValueExpr{Key: dst.NewIdent("Function"), Value: quote(site.Function)},
+				&dst.KeyValueExpr{Key: dst.NewIdent("Position"), Value: quote(site.Position)},
+				&dst.KeyValueExpr{Key: dst.NewIdent("Expression"), Value: quote(site.Expression)},
+			},
+		}
+		statement := &dst.ExprStmt{
+			X: &dst.CallExpr{
+				Fun:  injector.selector("Register"),
+				Args: []dst.Expr{literal},
+			},
+		}
+		statement.Decs.Before = dst.NewLine
+		statements = append(statements, statement)
+	}
+
+	return &dst.FuncDecl{
+		Name: dst.NewIdent("init"),
+		Type: &dst.FuncType{},
+		Body: &dst.BlockStmt{List: statements},
+	}
+}
+
+func quote(str string) *dst.BasicLit {
+	return &dst.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("%q", str)}
+}
+
+// Inject instruments the contracted functions of the file with fault sites
+// followed by their contracts.
+func (injector *FaultInjector) Inject(file *dst.File, describe func(dst.Node) (string, string)) {
+	first := len(injector.sites)
+
+	for _, decl := range file.Decls {
+		function, ok := decl.(*dst.FuncDecl)
+		if !ok || len(function.Decs.NodeDecs.Start) == 0 {
+			continue
+		}
+		injector.Faults(function, describe)
+	}
+
+	injector.injector.Inject(file)
+
+	if sites := injector.sites[first:]; len(sites) > 0 {
+		file.Decls = append(file.Decls, injector.Register(sites))
+	}
+}
+
+// Files instruments the files in place while keeping the originals in the same
+// way as Injector.Files.
+func (injector *FaultInjector) Files(files iter.Seq[string]) {
+	fset := token.NewFileSet()
+	decor := decorator.NewDecorator(fset)
+
+	var paths []string
+	parsed := make(map[string]*dst.File)
+	for path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		file, err := decor.ParseFile(filepath.Base(path), content, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+
+		injector.injector.attach(path, file)
+		paths = append(paths, path)
+		parsed[path] = file
+	}
+	defer func() {
+		injector.injector.composites = nil
+	}()
+
+	for _, path := range paths {
+		file := parsed[path]
+
+		describe := func(node dst.Node) (string, string) {
+			original, exists := decor.Ast.Nodes[node]
+			if !exists {
+				return filepath.Base(path), ""
+			}
+
+			var buffer bytes.Buffer
+			printer.Fprint(&buffer, fset, original)
+			return fset.Position(original.Pos()).String(), buffer.String()
+		}
+
+		// The literals are harvested before the file is instrumented.
+		if test, ok := injector.injector.InterestingTest(file); ok {
+			if err := writeGenerated(interestingPath(path), test); err != nil {
+				panic(err)
+			}
+		}
+
+		injector.injector.Compose(siblings(path, paths, parsed)...)
+		injector.Inject(file, describe)
+
+		filesx.Move(path, path+"-sopher")
+
+		instrumented, err := filesx.Create(path)
+		if err != nil {
+			panic(err)
+		}
+
+		decorator.Fprint(instrumented, file)
+		instrumented.Close()
+	}
+}
@@ -0,0 +1,81 @@
+package language
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/hyperproperties/sopher/pkg/faults"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultInjector(t *testing.T) {
+	source := `package examples
+
+// guarantee: forall e. e.ret0 == (e.attempt <= 3)
+func Check(attempt int) bool {
+	valid := attempt <= 3
+	if !valid {
+		return false
+	}
+	return true
+}`
+
+	file, err := decorator.NewDecorator(token.NewFileSet()).ParseFile("check.go", source, parser.ParseComments)
+	assert.NoError(t, err)
+
+	injector := NewGoFaultInjector()
+	injector.Inject(file, func(dst.Node) (string, string) {
+		return "check.go", ""
+	})
+
+	kinds := map[faults.Kind]int{}
+	for _, site := range injector.Sites() {
+		assert.Equal(t, "Check", site.Function)
+		kinds[site.Kind]++
+	}
+	assert.Equal(t, map[faults.Kind]int{
+		faults.VariableSite:  1,
+		faults.ConditionSite: 1,
+		faults.ReturnSite:    2,
+	}, kinds)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, decorator.Fprint(&buffer, file))
+	instrumented := buffer.String()
+
+	assert.Contains(t, instrumented, "faults.Variable(0, &valid)")
+	assert.Contains(t, instrumented, "if faults.Condition(2, !valid) {")
+	assert.Contains(t, instrumented, "return faults.Value[bool](1, false)")
+	assert.Contains(t, instrumented, "return faults.Value[bool](3, true)")
+	assert.Contains(t, instrumented, "faults.Observe(Check_Contract.GuaranteeViolation(execution).Names()...)")
+	assert.Contains(t, instrumented, "faults.Register(faults.Site{ID: 3, Kind: faults.ReturnSite")
+}
+
+func TestFaultInjectorStatements(t *testing.T) {
+	source := `package examples
+
+func Count(values []int) (count int) {
+	total := 0
+	total = len(values)
+	count++
+	return
+}`
+
+	file, err := decorator.Parse(source)
+	assert.NoError(t, err)
+
+	injector := NewGoFaultInjector(faults.StatementSite)
+	injector.Faults(file.Decls[0].(*dst.FuncDecl), func(dst.Node) (string, string) {
+		return "", ""
+	})
+	assert.Len(t, injector.Sites(), 2, "declarations are not skipped")
+
+	var buffer bytes.Buffer
+	assert.NoError(t, decorator.Fprint(&buffer, file))
+	assert.Contains(t, buffer.String(), "if !faults.Skipped(0) {\n\t\ttotal = len(values)\n\t}")
+	assert.Contains(t, buffer.String(), "if !faults.Skipped(1) {\n\t\tcount++\n\t}")
+}
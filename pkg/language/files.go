@@ -14,7 +14,8 @@ import (
 var ErrFileNotFound = errors.New("file could not be found")
 
 type Files struct {
-	paths []string
+	paths    []string
+	sidecars []string
 }
 
 func NewFiles() Files {
@@ -42,7 +43,11 @@ func (files *Files) AddFile(path string) error {
 		return ErrFileNotFound
 	}
 
-	files.paths = append(files.paths, path)
+	if filepath.Ext(path) == SidecarExtension {
+		files.sidecars = append(files.sidecars, path)
+	} else {
+		files.paths = append(files.paths, path)
+	}
 
 	return nil
 }
@@ -59,11 +64,13 @@ func (files *Files) AddDirectory(path string) (err error) {
 			if entry.IsDir() {
 				return nil
 			}
-			if filepath.Ext(path) != ".go" {
-				return nil
-			}
 
-			files.paths = append(files.paths, path)
+			switch filepath.Ext(path) {
+			case ".go":
+				files.paths = append(files.paths, path)
+			case SidecarExtension:
+				files.sidecars = append(files.sidecars, path)
+			}
 
 			return nil
 		})
@@ -74,7 +81,7 @@ func (files *Files) AddDirectory(path string) (err error) {
 		}
 
 		for _, file := range in {
-			if filepath.Ext(file.Name()) == ".go" {
+			if extension := filepath.Ext(file.Name()); extension == ".go" || extension == SidecarExtension {
 				path := filepath.Join(path, file.Name())
 				if err := files.AddFile(path); err != nil {
 					return err
@@ -95,3 +102,14 @@ func (files *Files) Iterator() iter.Seq[string] {
 		}
 	}
 }
+
+// Sidecars iterates the sidecar files of contracts.
+func (files *Files) Sidecars() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, path := range files.sidecars {
+			if !yield(path) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package language
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultWidth is the width of contract lines beyond which they are wrapped.
+const DefaultWidth = 100
+
+// IsContract reports whether the text of a doc comment is a contract rather
+// than prose, i.e. whether any of its lines starts with an obligation, a region
+// or the compose clause.
+func IsContract(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if isObligation(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// isObligation reports whether the comment line starts an obligation, a region
+// or the compose clause.
+func isObligation(line string) bool {
+	if strings.HasPrefix(line, "\t") {
+		return false
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	keyword, _, _ := strings.Cut(fields[0], ":")
+	return keyword == "assume" || keyword == "guarantee" || keyword == "region" || keyword == "compose"
+}
+
+var (
+	headerPattern     = regexp.MustCompile(`^(assume|guarantee)( "(?:[^"\\]|\\.)*")? ?: ?`)
+	regionPattern     = regexp.MustCompile(`^region((?: [A-Za-z_]\w*)*) ?: ?`)
+	quantifierPattern = regexp.MustCompile(`(^|[ (;])(forall|exists)((?: [A-Za-z_]\w*)+) ?\. ?`)
+)
+
+// literals calls the function for every byte of the code outside of string,
+// rune and raw string literals until it returns false. Literals are skipped
+// such that their content is never reformatted.
+func literals(code string, outside func(idx int) bool) {
+	var quote byte
+	for idx := 0; idx < len(code); idx++ {
+		character := code[idx]
+		if quote != 0 {
+			if character == '\\' && quote != '`' {
+				idx++
+			} else if character == quote {
+				quote = 0
+			}
+			continue
+		}
+		if character == '"' || character == '\'' || character == '`' {
+			quote = character
+			continue
+		}
+		if !outside(idx) {
+			return
+		}
+	}
+}
+
+// label splits a trailing "// Label" from the line.
+func label(line string) (string, string) {
+	at := -1
+	literals(line, func(idx int) bool {
+		if strings.HasPrefix(line[idx:], "//") {
+			at = idx
+			return false
+		}
+		return true
+	})
+	if at < 0 {
+		return line, ""
+	}
+	return line[:at], strings.TrimSpace(strings.TrimPrefix(line[at:], "//"))
+}
+
+// normalise collapses whitespace outside of literals and puts exactly one
+// space after semicolons.
+func normalise(code string) string {
+	var builder strings.Builder
+	last := 0
+	space := false
+	literals(code, func(idx int) bool {
+		if idx > last {
+			// The literal before the character is kept as written.
+			if space && builder.Len() > 0 {
+				builder.WriteByte(' ')
+			}
+			space = false
+			builder.WriteString(code[last:idx])
+		}
+		last = idx + 1
+
+		character := code[idx]
+		switch {
+		case character == ' ' || character == '\t':
+			space = true
+		case character == ';':
+			builder.WriteByte(';')
+			space = true
+		default:
+			if space && builder.Len() > 0 && !strings.HasSuffix(builder.String(), " ") {
+				builder.WriteByte(' ')
+			}
+			space = false
+			builder.WriteByte(character)
+		}
+		return true
+	})
+	if last < len(code) {
+		if space && builder.Len() > 0 {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString(code[last:])
+	}
+
+	normalised := strings.ReplaceAll(strings.TrimSpace(builder.String()), " ;", ";")
+	normalised = headerPattern.ReplaceAllString(normalised, "$1$2: ")
+	normalised = regionPattern.ReplaceAllString(normalised, "region$1: ")
+	normalised = quantifierPattern.ReplaceAllString(normalised, "$1$2$3. ")
+	return strings.TrimSpace(normalised)
+}
+
+// segments splits the code after every semicolon outside of literals.
+func segments(code string) []string {
+	var segments []string
+	start := 0
+	literals(code, func(idx int) bool {
+		if code[idx] == ';' {
+			segments = append(segments, strings.TrimSpace(code[start:idx+1]))
+			start = idx + 1
+		}
+		return true
+	})
+	if rest := strings.TrimSpace(code[start:]); rest != "" {
+		segments = append(segments, rest)
+	}
+	return segments
+}
+
+// columns returns the width of the line with tabs expanded to eight columns.
+func columns(line string) (width int) {
+	for _, character := range line {
+		if character == '\t' {
+			width += 8 - width%8
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+type contractLine struct {
+	code  string
+	label string
+}
+
+// element is either a verbatim prose line or an obligation with the lines of
+// its continuation.
+type element struct {
+	prose      string
+	obligation []contractLine
+}
+
+// FormatContract rewrites the lines of a doc comment holding a contract into
+// the canonical layout. Every obligation starts on its own line and lines
+// longer than the width are wrapped at semicolons. Wrapped and continued lines
+// are indented as a code block such that gofmt keeps them as is. Trailing
+// labels are aligned and prose is kept verbatim. Formatting is idempotent.
+func FormatContract(comments []string, width int) []string {
+	var texts []string
+	for _, comment := range comments {
+		text, ok := strings.CutPrefix(comment, "//")
+		if !ok {
+			// Block comments are left as written.
+			return comments
+		}
+		texts = append(texts, text)
+	}
+
+	var elements []element
+	current := -1
+	for idx, text := range texts {
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case isObligation(strings.TrimPrefix(text, " ")):
+			code, label := label(text)
+			elements = append(elements, element{obligation: []contractLine{{code, label}}})
+			current = len(elements) - 1
+			continue
+		case current >= 0 && trimmed != "" && (strings.HasPrefix(text, "\t") || strings.HasPrefix(text, "  ")):
+			code, label := label(text)
+			elements[current].obligation = append(elements[current].obligation, contractLine{code, label})
+			continue
+		case current >= 0 && trimmed == "":
+			// Blank lines separating continuations are implied by the layout.
+			next := slices.IndexFunc(texts[idx+1:], func(text string) bool {
+				return strings.TrimSpace(text) != ""
+			})
+			if next >= 0 {
+				following := texts[idx+1+next]
+				if strings.HasPrefix(following, "\t") || strings.HasPrefix(following, "  ") {
+					continue
+				}
+			}
+			if next >= 0 && isObligation(strings.TrimPrefix(texts[idx+1+next], " ")) {
+				continue
+			}
+		}
+		current = -1
+		elements = append(elements, element{prose: text})
+	}
+
+	type rendered struct {
+		text  string
+		label string
+	}
+	var lines []rendered
+
+	for idx, element := range elements {
+		if element.obligation == nil {
+			lines = append(lines, rendered{text: "//" + element.prose})
+			continue
+		}
+
+		var physical []contractLine
+		for _, line := range element.obligation {
+			// Segments are packed greedily and the label is kept on the
+			// last line produced from the original line.
+			var packed []string
+			for _, segment := range segments(normalise(line.code)) {
+				prefix := "//\t"
+				if len(physical)+len(packed) <= 1 {
+					prefix = "// "
+				}
+				if n := len(packed); n > 0 && columns(prefix+packed[n-1]+" "+segment) <= width {
+					packed[n-1] += " " + segment
+				} else {
+					packed = append(packed, segment)
+				}
+			}
+			for _, code := range packed {
+				physical = append(physical, contractLine{code: code})
+			}
+			if line.label != "" && len(physical) > 0 {
+				physical[len(physical)-1].label = line.label
+			}
+		}
+
+		for number, line := range physical {
+			if number == 0 {
+				lines = append(lines, rendered{text: "// " + line.code, label: line.label})
+				continue
+			}
+			if number == 1 {
+				lines = append(lines, rendered{text: "//"})
+			}
+			lines = append(lines, rendered{text: "//\t" + line.code, label: line.label})
+		}
+
+		// Wrapped obligations are separated from what follows unless a blank
+		// line already does so.
+		if len(physical) > 1 && idx < len(elements)-1 &&
+			!(elements[idx+1].obligation == nil && strings.TrimSpace(elements[idx+1].prose) == "") {
+			lines = append(lines, rendered{text: "//"})
+		}
+	}
+
+	column := 0
+	for _, line := range lines {
+		if line.label != "" {
+			column = max(column, columns(line.text)+1)
+		}
+	}
+
+	formatted := make([]string, len(lines))
+	for idx, line := range lines {
+		formatted[idx] = line.text
+		if line.label != "" {
+			formatted[idx] += strings.Repeat(" ", column-columns(line.text)) + "// " + line.label
+		}
+	}
+	return formatted
+}
+
+// Format rewrites the contracts in the doc comments of the functions in the
+// Go source into their canonical layout. The rest of the source is unchanged.
+func Format(source []byte, width int) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	type replacement struct {
+		start, end int
+		text       string
+	}
+	var replacements []replacement
+
+	for _, decl := range file.Decls {
+		function, ok := decl.(*ast.FuncDecl)
+		if !ok || function.Doc == nil || !IsContract(function.Doc.Text()) {
+			continue
+		}
+
+		comments := make([]string, len(function.Doc.List))
+		for idx, comment := range function.Doc.List {
+			comments[idx] = comment.Text
+		}
+
+		replacements = append(replacements, replacement{
+			start: fset.Position(function.Doc.Pos()).Offset,
+			end:   fset.Position(function.Doc.End()).Offset,
+			text:  strings.Join(FormatContract(comments, width), "\n"),
+		})
+	}
+
+	var formatted bytes.Buffer
+	last := 0
+	for _, replacement := range replacements {
+		formatted.Write(source[last:replacement.start])
+		formatted.WriteString(replacement.text)
+		last = replacement.end
+	}
+	formatted.Write(source[last:])
+
+	return formatted.Bytes(), nil
+}
@@ -0,0 +1,124 @@
+package language
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatContract(t *testing.T) {
+	tests := []struct {
+		description string
+		width       int
+		comments    []string
+		formatted   []string
+	}{
+		{
+			description: "spacing",
+			width:       DefaultWidth,
+			comments: []string{
+				"//   assume :forall   e .  e.attempt>=0 ;  e.pin != \"a  b\"",
+				"// guarantee:   forall e0  e1.e0.ret0 == e1.ret0",
+				"// guarantee  \"Successful   Check\"  :forall e. e.ret0",
+			},
+			formatted: []string{
+				"// assume: forall e. e.attempt>=0; e.pin != \"a  b\"",
+				"// guarantee: forall e0 e1. e0.ret0 == e1.ret0",
+				"// guarantee \"Successful   Check\": forall e. e.ret0",
+			},
+		},
+		{
+			description: "aligned labels",
+			width:       DefaultWidth,
+			comments: []string{
+				"// assume: forall e. e.attempt > 0\t\t\t// Valid Attempt",
+				"// guarantee: forall e0 e1. e0.ret0 == e1.ret0 // Deterministic",
+				"// guarantee: forall e. e.ret0 // \"quoted // label\"",
+				"// guarantee: forall e. e.pin != \"//\"",
+			},
+			formatted: []string{
+				"// assume: forall e. e.attempt > 0             // Valid Attempt",
+				"// guarantee: forall e0 e1. e0.ret0 == e1.ret0 // Deterministic",
+				"// guarantee: forall e. e.ret0                 // \"quoted // label\"",
+				"// guarantee: forall e. e.pin != \"//\"",
+			},
+		},
+		{
+			description: "wrapped at semicolons",
+			width:       40,
+			comments: []string{
+				"// guarantee: forall e0 e1. e0.low == e1.low; -> e0.ret0 == e1.ret0; -> e0.ok // Label",
+				"// assume: forall e. e.attempt > 0",
+			},
+			formatted: []string{
+				"// guarantee: forall e0 e1. e0.low == e1.low;",
+				"//",
+				"//\t-> e0.ret0 == e1.ret0; -> e0.ok // Label",
+				"//",
+				"// assume: forall e. e.attempt > 0",
+			},
+		},
+		{
+			description: "continuations",
+			width:       DefaultWidth,
+			comments: []string{
+				"// Prose is kept  as written.",
+				"//",
+				"// guarantee: forall e.",
+				"//\t\te.ret == (e.attempt <= 3 &&",
+				"//",
+				"//  digits[0] == 0)",
+				"// More prose.",
+			},
+			formatted: []string{
+				"// Prose is kept  as written.",
+				"//",
+				"// guarantee: forall e.",
+				"//",
+				"//\te.ret == (e.attempt <= 3 &&",
+				"//\tdigits[0] == 0)",
+				"//",
+				"// More prose.",
+			},
+		},
+		{
+			description: "block comments",
+			width:       DefaultWidth,
+			comments:    []string{"/* guarantee:   forall e. e.ret0 */"},
+			formatted:   []string{"/* guarantee:   forall e. e.ret0 */"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			formatted := FormatContract(tt.comments, tt.width)
+			assert.Equal(t, tt.formatted, formatted)
+			assert.Equal(t, formatted, FormatContract(formatted, tt.width), "formatting is idempotent")
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	source := `package examples
+
+// Add adds  two numbers.
+func Add(a, b int) int {
+	return a + b
+}
+
+// guarantee:forall e. e.ret0 == e.a  // Identity
+func Identity(a int) int {
+	// guarantee:   forall e. e.ret0
+	return a
+}
+`
+	formatted, err := Format([]byte(source), DefaultWidth)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Replace(source,
+		"// guarantee:forall e. e.ret0 == e.a  // Identity",
+		"// guarantee: forall e. e.ret0 == e.a // Identity", 1), string(formatted))
+
+	_, err = Format([]byte("package"), DefaultWidth)
+	assert.Error(t, err)
+}
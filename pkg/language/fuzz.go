@@ -0,0 +1,53 @@
+package language
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/hyperproperties/sopher/pkg/quick"
+)
+
+// Fuzz turns the hyper-contract into a fuzz target. The fuzzer's byte stream
+// is decoded into a set of executions whose inputs are first checked against
+// the assumptions and then, after calling the function, the executions are
+// checked against the guarantees. This lets the coverage guidance of the Go
+// fuzzer hunt for hyperproperty violations which are stored in testdata/fuzz.
+//
+// The number of executions in a set is configured by WithExecutions and the
+// seed corpus consists of WithRuns random byte streams generated from WithSeed.
+func Fuzz[T any](f *testing.F, contract AGHyperContract[T], call func(input T) T, options ...CheckOption) {
+	f.Helper()
+
+	configuration := NewCheckConfiguration(options...)
+	random := rand.New(rand.NewPCG(configuration.Seed, configuration.Seed))
+
+	f.Add([]byte{})
+	for run := 0; run < configuration.Runs; run++ {
+		seed := make([]byte, random.IntN(64*configuration.Executions+1))
+		for idx := range seed {
+			seed[idx] = byte(random.Uint32())
+		}
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decoder := quick.NewDecoder(data, configuration.Size)
+
+		inputs := make([]T, configuration.Executions)
+		for idx := range inputs {
+			inputs[idx] = quick.Decode[T](decoder)
+		}
+
+		if contract.Assume(inputs...).IsFalse() {
+			t.Skip("the decoded inputs are not admitted by the assumptions")
+		}
+
+		executions := execute(call, inputs)
+		if violation := contract.GuaranteeViolation(executions...); violation.Violated() {
+			t.Errorf(
+				"%v by %v execution(s):\n%s",
+				violation, len(executions), formatExecutions(executions),
+			)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func FuzzMonotone(f *testing.F) {
+	Fuzz(f, monotoneContract(), func(execution monotoneExecution) monotoneExecution {
+		execution.output = int32(execution.input) + 1
+		return execution
+	}, WithExecutions(3), WithRuns(10), WithSeed(0))
+}
+
+// contractGenerator decodes the fuzzer's bytes into a contract AST. Only
+// contracts the parser accepts are generated, so identifiers are never
+// keywords and expressions are never empty and contain no delimiters.
+type contractGenerator struct {
+	data []byte
+}
+
+var (
+	generatedIdentifiers = []string{"e", "e0", "e1", "Low", "_high", "ε2"}
+	generatedTypes       = []string{"", "Read", "Erase"}
+	generatedLabels      = []string{"", "", "Valid PIN", "No \"Timing\" Side Channel", "a // b", "\\"}
+	generatedExpressions = []string{
+		"true",
+		"e.x >= 0",
+		"e0.ret0 == e1.ret0",
+		"e0.low == e1.low && !e0.ok",
+		"f(e.a, e.b) != nil  ",
+		"len(e.xs[1:]) == 0",
+		"-e.x*(e.y+1) < 3",
+		"P(e.x) > 0.5",
+		"e.a | e.b != 0",
+	}
+	generatedNumbers    = []float32{0, 1, 0.5, -2, 1e-7, 3.4e38}
+	generatedAggregates = []string{"mean", "sum", "count", "percentile", "probability", "mean"}
+	generatedValues     = []string{"e.x", "len(e.xs[1:])", "-e.x*(e.y+1)", "e0.ret0 == e1.ret0"}
+	generatedOperators  = []string{"<=", "<", ">", ">=", "==", "!="}
+	generatedBounds     = []string{"0", "100ms", "f(e.a, e.b)"}
+)
+
+func (generator *contractGenerator) choose(n int) int {
+	if len(generator.data) == 0 {
+		return 0
+	}
+	choice := int(generator.data[0]) % n
+	generator.data = generator.data[1:]
+	return choice
+}
+
+func (generator *contractGenerator) identifiers(minimum int) (identifiers []string) {
+	for count := minimum + generator.choose(3); len(identifiers) < count; {
+		identifiers = append(identifiers, generatedIdentifiers[generator.choose(len(generatedIdentifiers))])
+	}
+	return identifiers
+}
+
+// types returns the types of the variables which are nil if none is typed.
+func (generator *contractGenerator) types(variables []string) (types []string) {
+	typed := false
+	for range variables {
+		types = append(types, generatedTypes[generator.choose(len(generatedTypes))])
+		typed = typed || types[len(types)-1] != ""
+	}
+	if !typed {
+		return nil
+	}
+	return types
+}
+
+func (generator *contractGenerator) assertion(depth int) Node {
+	choice := 6
+	if depth < 4 {
+		choice = generator.choose(7)
+	}
+
+	switch choice {
+	case 0:
+		variables := generator.identifiers(1)
+		return NewTypedUniversal(variables, generator.types(variables), generator.assertion(depth+1))
+	case 1:
+		variables := generator.identifiers(1)
+		return NewTypedExistential(variables, generator.types(variables), generator.assertion(depth+1))
+	case 2:
+		return NewGroup(generator.assertion(depth + 1))
+	case 3:
+		return generator.aggregate()
+	case 4:
+		event := generator.assertion(depth + 1)
+		if generator.choose(2) == 0 {
+			return NewProbabilisticQuantifier(event)
+		}
+		return NewConditionalProbabilityQuantifier(event, generator.assertion(depth+1))
+	case 5:
+		return Number(generatedNumbers[generator.choose(len(generatedNumbers))])
+	}
+	return NewGoExpression(generatedExpressions[generator.choose(len(generatedExpressions))])
+}
+
+func (generator *contractGenerator) aggregate() Aggregate {
+	variables := generator.identifiers(1)[:1]
+	types := generator.types(variables)
+	value := NewGoExpression(generatedValues[generator.choose(len(generatedValues))])
+	operator := generatedOperators[generator.choose(len(generatedOperators))]
+	bound := NewGoExpression(generatedBounds[generator.choose(len(generatedBounds))])
+
+	choice := generator.choose(len(generatedAggregates))
+	function := generatedAggregates[choice]
+	switch {
+	case function == "percentile":
+		return NewPercentileAggregate([]float64{50, 99.9}[generator.choose(2)], variables, types, value, operator, bound)
+	case function == "probability" || choice == len(generatedAggregates)-1:
+		// Estimates are only compared by inequalities.
+		operator = generatedOperators[generator.choose(4)]
+		return NewEstimateAggregate(function, []float64{0.95, 0.999}[generator.choose(2)], variables, types, value, operator, bound)
+	}
+	return NewAggregate(function, variables, types, value, operator, bound)
+}
+
+func (generator *contractGenerator) contract() Contract {
+	var composition []string
+	for count := generator.choose(3); len(composition) < count; {
+		composition = append(composition, generatedTypes[1+generator.choose(len(generatedTypes)-1)])
+	}
+
+	var regions []Region
+	for count := generator.choose(4); len(regions) < count; {
+		var assumptions, guarantees []Node
+		for count := generator.choose(3); len(assumptions) < count; {
+			label := generatedLabels[generator.choose(len(generatedLabels))]
+			assumptions = append(assumptions, NewLabelledAssumption(label, generator.assertion(0)))
+		}
+		for count := generator.choose(3); len(guarantees) < count; {
+			label := generatedLabels[generator.choose(len(generatedLabels))]
+			guarantees = append(guarantees, NewLabelledGuarantee(label, generator.assertion(0)))
+		}
+		regions = append(regions, NewRegion(generator.identifiers(0), assumptions, guarantees))
+	}
+	return NewCompositeContract(composition, regions...)
+}
+
+func FuzzParsePrint(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{1, 0, 1, 1, 3, 3})
+	f.Add([]byte{2, 2, 1, 2, 0, 2, 1, 5, 1, 1, 2, 3, 4, 2, 0, 3, 1, 3, 6, 1, 2})
+	f.Add([]byte{3, 1, 2, 2, 1, 0, 0, 1, 3, 4, 0, 2, 2, 2, 2, 1, 9, 7, 5, 3, 1})
+	f.Add([]byte{1, 0, 1, 1, 3, 1, 2, 3, 1, 3, 2, 3, 3, 0, 2, 3, 4, 3, 0, 1})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		generator := contractGenerator{data: data}
+		contract := generator.contract()
+
+		source := Print(contract)
+		parser := NewParser(LexString(source))
+		assert.Equal(t, contract, parser.Parse(), source)
+	})
+}
@@ -0,0 +1,57 @@
+package language
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+)
+
+// generatedHeader marks the files generated by sopher as generated Go code.
+const generatedHeader = "// Code generated by sopher. DO NOT EDIT.\n\n"
+
+var ErrNotGenerated = errors.New("refusing to overwrite a file sopher did not generate")
+
+// generated reports whether the file at the path was generated by sopher.
+func generated(path string) bool {
+	content, err := os.ReadFile(path)
+	return err == nil && bytes.HasPrefix(content, []byte(generatedHeader))
+}
+
+// writeGenerated writes the file marked as generated to the path unless it
+// would overwrite a file sopher did not generate. The file is printed before
+// writing it such that a failure leaves no truncated file behind.
+func writeGenerated(path string, file *dst.File) error {
+	if _, err := os.Stat(path); err == nil && !generated(path) {
+		return fmt.Errorf("%w: %v", ErrNotGenerated, path)
+	}
+
+	buffer := bytes.NewBufferString(generatedHeader)
+	if err := decorator.Fprint(buffer, file); err != nil {
+		return fmt.Errorf("printing %v: %w", path, err)
+	}
+	return os.WriteFile(path, buffer.Bytes(), 0o644)
+}
+
+// removeGenerated removes the file at the path if sopher generated it.
+func removeGenerated(path string) error {
+	if !generated(path) {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// testName returns the name of the generated test of the name, e.g. TestSort
+// for Sort and Test_sort for sort as go test requires the name after Test not
+// to start with a lowercase letter.
+func testName(name string) string {
+	if first, _ := utf8.DecodeRuneInString(name); unicode.IsLower(first) {
+		return "Test_" + name
+	}
+	return "Test" + name
+}
@@ -0,0 +1,32 @@
+package language
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dave/dst"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteGenerated(t *testing.T) {
+	directory := t.TempDir()
+	file := &dst.File{Name: dst.NewIdent("examples")}
+
+	// Generated files are overwritten and removed.
+	path := filepath.Join(directory, "generated_sopher_test.go")
+	assert.NoError(t, writeGenerated(path, file))
+	assert.NoError(t, writeGenerated(path, file))
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, generatedHeader+"package examples\n", string(content))
+	assert.NoError(t, removeGenerated(path))
+	assert.NoFileExists(t, path)
+
+	// Files sopher did not generate are neither overwritten nor removed.
+	path = filepath.Join(directory, "handwritten_sopher_test.go")
+	assert.NoError(t, os.WriteFile(path, []byte("package examples\n"), 0o644))
+	assert.ErrorIs(t, writeGenerated(path, file), ErrNotGenerated)
+	assert.NoError(t, removeGenerated(path))
+	assert.FileExists(t, path)
+}
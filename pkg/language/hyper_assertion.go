@@ -1,5 +1,13 @@
 package language
 
+import (
+	"iter"
+	"slices"
+	"sync"
+
+	"github.com/hyperproperties/sopher/pkg/iterx"
+)
+
 // TODO: Remove "offset" from assertions.
 
 type HyperAssertionVisitor[T any] interface {
@@ -7,6 +15,8 @@ type HyperAssertionVisitor[T any] interface {
 	ExistentialHyperAssertion(assertion ExistentialHyperAssertion[T])
 	PredicateHyperAssertion(assertion PredicateHyperAssertion[T])
 	TrueHyperAssertion(assertion TrueHyperAssertion[T])
+	AggregateHyperAssertion(assertion AggregateHyperAssertion[T])
+	CompiledHyperAssertion(assertion CompiledHyperAssertion[T])
 }
 
 // HyperAssertion represents an interface for tracking and evaluating the state of
@@ -23,6 +33,9 @@ var (
 	_ HyperAssertion[any] = (*ExistentialHyperAssertion[any])(nil)
 	_ HyperAssertion[any] = (*PredicateHyperAssertion[any])(nil)
 	_ HyperAssertion[any] = (*TrueHyperAssertion[any])(nil)
+	_ HyperAssertion[any] = (*LabelledHyperAssertion[any])(nil)
+	_ HyperAssertion[any] = (*AggregateHyperAssertion[any])(nil)
+	_ HyperAssertion[any] = (*CompiledHyperAssertion[any])(nil)
 )
 
 func HyperAssertionFromAST[T any](node Node) HyperAssertion[T] {
@@ -31,6 +44,9 @@ func HyperAssertionFromAST[T any](node Node) HyperAssertion[T] {
 		switch cast := node.(type) {
 		case Universal:
 			body := recurse(cast.assertion, variables+len(cast.variables))
+			if cast.distinct {
+				return NewSymmetricUniversalHyperAssertion[T](variables, len(cast.variables), Reflexive, body)
+			}
 			monitor := NewUniversalHyperAssertion[T](variables, len(cast.variables), body)
 			return monitor
 		case Existential:
@@ -46,6 +62,24 @@ func HyperAssertionFromAST[T any](node Node) HyperAssertion[T] {
 	return recurse(node, 0)
 }
 
+// LabelledHyperAssertion is the assertion of a labelled obligation. It is
+// evaluated as the assertion and its label names it when it is violated.
+type LabelledHyperAssertion[T any] struct {
+	HyperAssertion[T]
+	label string
+}
+
+func NewLabelledHyperAssertion[T any](label string, assertion HyperAssertion[T]) *LabelledHyperAssertion[T] {
+	return &LabelledHyperAssertion[T]{
+		HyperAssertion: assertion,
+		label:          label,
+	}
+}
+
+func (assertion LabelledHyperAssertion[T]) Label() string {
+	return assertion.label
+}
+
 type TrueHyperAssertion[T any] struct{}
 
 func NewTrueHyperAssertion[T any]() *TrueHyperAssertion[T] {
@@ -60,8 +94,11 @@ func (assertion TrueHyperAssertion[T]) Accept(visitor HyperAssertionVisitor[T])
 	visitor.TrueHyperAssertion(assertion)
 }
 
+// PredicateHyperAssertion is a predicate on the executions assigned to the
+// variables. Temporal predicates also get the timeline of the executions.
 type PredicateHyperAssertion[T any] struct {
 	predicate func(assignments []T) bool
+	temporal  func(assignments []T, timeline Timeline) bool
 }
 
 func NewPredicateHyperAssertion[T any](predicate func(assignments []T) bool) *PredicateHyperAssertion[T] {
@@ -70,6 +107,16 @@ func NewPredicateHyperAssertion[T any](predicate func(assignments []T) bool) *Pr
 	}
 }
 
+// NewTemporalPredicateHyperAssertion returns a predicate ordering the executions
+// assigned to the variables by the timeline.
+func NewTemporalPredicateHyperAssertion[T any](
+	predicate func(assignments []T, timeline Timeline) bool,
+) *PredicateHyperAssertion[T] {
+	return &PredicateHyperAssertion[T]{
+		temporal: predicate,
+	}
+}
+
 func (assertion PredicateHyperAssertion[T]) Size() int {
 	return 0
 }
@@ -78,19 +125,145 @@ func (assertion PredicateHyperAssertion[T]) Accept(visitor HyperAssertionVisitor
 	visitor.PredicateHyperAssertion(assertion)
 }
 
+// CompiledHyperAssertion is an assertion compiled to Go which evaluates it on
+// the elements, e.g. by nested loops over them, without assigning them to the
+// variables of an interpreter. The timeline orders the elements by their
+// positions. Only the elements from and to the positions are assigned to its
+// outermost variable, and it returns the positions of the elements assigned
+// to its variables by the first assignment deciding it as its witness, or nil
+// if it is undecided, such that it can be partitioned across workers.
+type CompiledHyperAssertion[T any] struct {
+	evaluate func(elements []T, timeline Timeline, from, to int) (LiftedBoolean, []int)
+}
+
+func NewCompiledHyperAssertion[T any](
+	evaluate func(elements []T, timeline Timeline, from, to int) (LiftedBoolean, []int),
+) *CompiledHyperAssertion[T] {
+	return &CompiledHyperAssertion[T]{
+		evaluate: evaluate,
+	}
+}
+
+func (assertion CompiledHyperAssertion[T]) Size() int {
+	return 0
+}
+
+func (assertion CompiledHyperAssertion[T]) Accept(visitor HyperAssertionVisitor[T]) {
+	visitor.CompiledHyperAssertion(assertion)
+}
+
+// UniversalHyperAssertion quantifies universally over the elements. The
+// symmetry of its body and the join or order of its variables reduce the
+// assignments of its variables evaluated. The join and order are nil if its
+// variables are not joined or ordered.
 type UniversalHyperAssertion[T any] struct {
 	offset, size int
+	symmetry     Symmetry
+	join         *Join[T]
+	order        *Order
 	body         HyperAssertion[T]
 	result       LiftedBoolean
 }
 
 func NewUniversalHyperAssertion[T any](offset, size int, body HyperAssertion[T]) *UniversalHyperAssertion[T] {
+	return NewJoinedUniversalHyperAssertion(offset, size, Asymmetric, nil, body)
+}
+
+// NewSymmetricUniversalHyperAssertion returns a universal whose body has the
+// symmetry in its variables. The symmetry is not checked and an asymmetric
+// body with a symmetry is only evaluated for some of its assignments.
+func NewSymmetricUniversalHyperAssertion[T any](
+	offset, size int, symmetry Symmetry, body HyperAssertion[T],
+) *UniversalHyperAssertion[T] {
+	return NewJoinedUniversalHyperAssertion(offset, size, symmetry, nil, body)
+}
+
+// NewJoinedUniversalHyperAssertion returns a universal whose body has the
+// symmetry and is satisfied by every assignment of elements whose keys of the
+// join differ. Neither is checked.
+func NewJoinedUniversalHyperAssertion[T any](
+	offset, size int, symmetry Symmetry, join *Join[T], body HyperAssertion[T],
+) *UniversalHyperAssertion[T] {
 	return &UniversalHyperAssertion[T]{
-		offset: offset,
-		size:   size,
-		body:   body,
-		result: LiftedTrue,
+		offset:   offset,
+		size:     size,
+		symmetry: symmetry,
+		join:     join,
+		body:     body,
+		result:   LiftedTrue,
+	}
+}
+
+// NewOrderedUniversalHyperAssertion returns a universal whose body has the
+// symmetry and is satisfied by every assignment of elements the order does
+// not order. Neither is checked.
+func NewOrderedUniversalHyperAssertion[T any](
+	offset, size int, symmetry Symmetry, order *Order, body HyperAssertion[T],
+) *UniversalHyperAssertion[T] {
+	return &UniversalHyperAssertion[T]{
+		offset:   offset,
+		size:     size,
+		symmetry: symmetry,
+		order:    order,
+		body:     body,
+		result:   LiftedTrue,
+	}
+}
+
+// assignments returns the indices of the elements assigned to the variables
+// for every assignment of them evaluated if they are not joined. Symmetric
+// bodies are only evaluated for one ordering of the elements and reflexive
+// bodies only for distinct elements. Only the assignments of at least one
+// element after the seen ones are evaluated.
+func (assertion UniversalHyperAssertion[T]) assignments(seen, elements int) iter.Seq[[]int] {
+	permutations := iterx.Permutations(assertion.size, elements)
+	if seen > 0 {
+		permutations = iterx.IncrementalPermutations(assertion.size, seen, elements-seen)
+	} else {
+		switch assertion.symmetry {
+		case Asymmetric:
+			return permutations
+		case Symmetric:
+			return iterx.CombinationsWithRepetition(assertion.size, elements)
+		case Symmetric | Reflexive:
+			return iterx.Combinations(assertion.size, elements)
+		}
+	}
+	return func(yield func([]int) bool) {
+		for permutation := range permutations {
+			if assertion.symmetry.admits(permutation) && !yield(permutation) {
+				return
+			}
+		}
+	}
+}
+
+// admits reports whether the assignment of the indices to the variables of a
+// body with the symmetry is evaluated, i.e. whether the indices are ordered if
+// it is symmetric and distinct if it is reflexive.
+func (symmetry Symmetry) admits(indices []int) bool {
+	for idx := range indices {
+		if symmetry&Symmetric != 0 && idx > 0 && indices[idx-1] > indices[idx] {
+			return false
+		}
+		if symmetry&Reflexive != 0 && slices.Contains(indices[idx+1:], indices[idx]) {
+			return false
+		}
 	}
+	return true
+}
+
+// settled reports whether an assignment of elements to the variables of a
+// universal with the body, once evaluated, is never violated by elements made
+// later such that the universal only evaluates the assignments of new
+// elements. Aggregates and universals in the body may be violated by the new
+// elements while existentials never are.
+func settled[T any](body HyperAssertion[T]) bool {
+	switch body.(type) {
+	case *PredicateHyperAssertion[T], *TrueHyperAssertion[T], *ExistentialHyperAssertion[T]:
+		return true
+	}
+	return false
 }
 
 func (assertion UniversalHyperAssertion[T]) Size() int {
@@ -101,15 +274,44 @@ func (assertion UniversalHyperAssertion[T]) Accept(visitor HyperAssertionVisitor
 	visitor.UniversalHyperAssertion(assertion)
 }
 
+// ExistentialHyperAssertion quantifies existentially over the elements. The
+// join or order of its variables reduces the assignments of its variables
+// evaluated and is nil if they are not joined or ordered.
 type ExistentialHyperAssertion[T any] struct {
 	offset, size int
+	join         *Join[T]
+	order        *Order
 	body         HyperAssertion[T]
 }
 
 func NewExistentialHyperAssertion[T any](offset, size int, body HyperAssertion[T]) *ExistentialHyperAssertion[T] {
+	return NewJoinedExistentialHyperAssertion(offset, size, nil, body)
+}
+
+// NewJoinedExistentialHyperAssertion returns an existential whose body is
+// violated by every assignment of elements whose keys of the join differ. It
+// is not checked.
+func NewJoinedExistentialHyperAssertion[T any](
+	offset, size int, join *Join[T], body HyperAssertion[T],
+) *ExistentialHyperAssertion[T] {
 	return &ExistentialHyperAssertion[T]{
 		offset: offset,
 		size:   size,
+		join:   join,
+		body:   body,
+	}
+}
+
+// NewOrderedExistentialHyperAssertion returns an existential whose body is
+// violated by every assignment of elements the order does not order. It is
+// not checked.
+func NewOrderedExistentialHyperAssertion[T any](
+	offset, size int, order *Order, body HyperAssertion[T],
+) *ExistentialHyperAssertion[T] {
+	return &ExistentialHyperAssertion[T]{
+		offset: offset,
+		size:   size,
+		order:  order,
 		body:   body,
 	}
 }
@@ -121,3 +323,70 @@ func (assertion ExistentialHyperAssertion[T]) Size() int {
 func (assertion ExistentialHyperAssertion[T]) Accept(visitor HyperAssertionVisitor[T]) {
 	visitor.ExistentialHyperAssertion(assertion)
 }
+
+// AggregateHyperAssertion compares the aggregate of the values of the
+// executions assigned to its variable with a bound. Executions without a
+// value, e.g. executions of other functions in a composite contract, are not
+// aggregated. Aggregates not nested in quantifiers and evaluated on a history
+// only aggregate the executions added to it since they were last evaluated.
+type AggregateHyperAssertion[T any] struct {
+	offset     int
+	aggregator func() Aggregator
+	value      func(assignments []T) (float64, bool)
+	compare    func(assignments []T, aggregate float64) bool
+	history    *aggregateHistory[T]
+}
+
+// aggregateHistory is the aggregate of the executions of a history seen so far.
+type aggregateHistory[T any] struct {
+	mutex      sync.Mutex
+	seen       int
+	aggregator Aggregator
+}
+
+// forget forgets the aggregates and indexes of histories of the assertion such
+// that they aggregate and index the next history anew.
+func forget[T any](assertion HyperAssertion[T]) {
+	switch cast := assertion.(type) {
+	case *LabelledHyperAssertion[T]:
+		forget(cast.HyperAssertion)
+	case *UniversalHyperAssertion[T]:
+		if cast.join != nil {
+			cast.join.forget()
+		}
+		forget(cast.body)
+	case *ExistentialHyperAssertion[T]:
+		if cast.join != nil {
+			cast.join.forget()
+		}
+		forget(cast.body)
+	case *AggregateHyperAssertion[T]:
+		cast.history.mutex.Lock()
+		defer cast.history.mutex.Unlock()
+		cast.history.seen = 0
+		cast.history.aggregator = nil
+	}
+}
+
+func NewAggregateHyperAssertion[T any](
+	offset int,
+	aggregator func() Aggregator,
+	value func(assignments []T) (float64, bool),
+	compare func(assignments []T, aggregate float64) bool,
+) *AggregateHyperAssertion[T] {
+	return &AggregateHyperAssertion[T]{
+		offset:     offset,
+		aggregator: aggregator,
+		value:      value,
+		compare:    compare,
+		history:    &aggregateHistory[T]{},
+	}
+}
+
+func (assertion AggregateHyperAssertion[T]) Size() int {
+	return 1
+}
+
+func (assertion AggregateHyperAssertion[T]) Accept(visitor HyperAssertionVisitor[T]) {
+	visitor.AggregateHyperAssertion(assertion)
+}
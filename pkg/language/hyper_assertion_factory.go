@@ -2,18 +2,32 @@ package language
 
 import (
 	"fmt"
+	"go/ast"
 	"go/parser"
 	"go/token"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dave/dst"
 	"github.com/dave/dst/decorator"
 )
 
+// MonitorFactory creates the monitors of obligations as Go expressions. The
+// monitors of a composite contract assign executions of the composed functions
+// to their variables from the fields of the model named by the functions. The
+// function is then the function declaring the contract typing untyped variables
+// and the universal reports, for every variable, how it is quantified.
 type MonitorFactory struct {
 	packageName string
 	modelName   string
+	function    string
 	offset      int
 	variables   []string
+	types       []string
+	universal   []bool
 }
 
 func NewGoMonitorFactory(packageName, modelName string) MonitorFactory {
@@ -25,6 +39,14 @@ func NewGoMonitorFactory(packageName, modelName string) MonitorFactory {
 	}
 }
 
+// NewGoCompositeMonitorFactory returns a factory of the monitors of a composite
+// contract declared by the function.
+func NewGoCompositeMonitorFactory(packageName, modelName, function string) MonitorFactory {
+	factory := NewGoMonitorFactory(packageName, modelName)
+	factory.function = function
+	return factory
+}
+
 func (factory *MonitorFactory) Create(node Node) *dst.CallExpr {
 	switch cdst := node.(type) {
 	case GoExpresion:
@@ -33,34 +55,178 @@ func (factory *MonitorFactory) Create(node Node) *dst.CallExpr {
 		return factory.NewUniversalMonitorCall(cdst)
 	case Existential:
 		return factory.NewExistentialMonitorCall(cdst)
+	case Aggregate:
+		return factory.NewAggregateMonitorCall(cdst)
 	case Guarantee:
-		factory.variables = nil
-		factory.offset = 0
-		return factory.Create(cdst.assertion)
+		factory.reset()
+		return factory.label(cdst.label, factory.obligation(cdst.assertion))
 	case Assumption:
-		factory.variables = nil
-		factory.offset = 0
-		return factory.Create(cdst.assertion)
+		factory.reset()
+		return factory.label(cdst.label, factory.obligation(cdst.assertion))
 	}
 	factory.offset = 0
 	panic(fmt.Sprintf("unknown node type %t", node))
 }
 
-func (factory *MonitorFactory) NewPredicateMonitorCall(expression GoExpresion) *dst.CallExpr {
-	// FIXME: Can accidentally define variables not in use. First we have to see what variables are in use and only define those.
+// obligation returns the monitor of the assertion of an obligation which is
+// compiled if it can be.
+func (factory *MonitorFactory) obligation(assertion Node) *dst.CallExpr {
+	if compiled := factory.NewCompiledMonitorCall(assertion); compiled != nil {
+		return compiled
+	}
+	factory.reset()
+	return factory.Create(assertion)
+}
 
-	var body []dst.Stmt
+// reset forgets the variables of the previous obligation.
+func (factory *MonitorFactory) reset() {
+	factory.variables = nil
+	factory.types = nil
+	factory.universal = nil
+	factory.offset = 0
+}
+
+// bind binds the variables of a quantifier.
+func (factory *MonitorFactory) bind(variables, types []string, universal bool) {
+	if types != nil && factory.function == "" {
+		panic("typed variables are only allowed in composite contracts")
+	}
 
+	factory.offset += len(variables)
+	factory.variables = append(factory.variables, variables...)
+	for idx := range variables {
+		function := factory.function
+		if types != nil && types[idx] != "" {
+			function = types[idx]
+		}
+		factory.types = append(factory.types, function)
+		factory.universal = append(factory.universal, universal)
+	}
+}
+
+// relativise restricts the variables of a composite contract to executions of
+// the functions typing them. As every other field of the model is nil for an
+// execution a universally quantified variable holds for executions of other
+// functions and an existentially quantified variable does not.
+func (factory *MonitorFactory) relativise(code string) string {
+	for idx := len(factory.variables) - 1; idx >= 0; idx-- {
+		if factory.universal[idx] {
+			code = fmt.Sprintf("%v == nil || (%v)", factory.variables[idx], code)
+		} else {
+			code = fmt.Sprintf("%v != nil && (%v)", factory.variables[idx], code)
+		}
+	}
+	return code
+}
+
+// temporal rewrites calls of the temporal predicates on quantified variables,
+// e.g. before(e0, e1), into calls of the timeline with the positions of the
+// variables, e.g. timeline.Before(0, 1). False is returned if the code calls
+// no temporal predicate.
+func (factory *MonitorFactory) temporal(code string) (string, bool) {
+	fset := token.NewFileSet()
+	expression, err := parser.ParseExprFrom(fset, "", code, 0)
+	if err != nil {
+		return code, false
+	}
+
+	position := func(expression ast.Expr) (int, bool) {
+		identifier, ok := expression.(*ast.Ident)
+		if !ok {
+			return 0, false
+		}
+		idx := slices.Index(factory.variables, identifier.Name)
+		return idx, idx >= 0
+	}
+
+	type replacement struct {
+		start, end int
+		code       string
+	}
+	var replacements []replacement
+	ast.Inspect(expression, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		function, ok := call.Fun.(*ast.Ident)
+		if !ok || (function.Name != "before" && function.Name != "next" && function.Name != "within") {
+			return true
+		}
+
+		arity := 2
+		if function.Name == "within" {
+			arity = 3
+		}
+		if len(call.Args) != arity {
+			panic(fmt.Sprintf("%v expects %v arguments but got %v", function.Name, arity, len(call.Args)))
+		}
+		a, aok := position(call.Args[0])
+		b, bok := position(call.Args[1])
+		if !aok || !bok {
+			panic(fmt.Sprintf("%v expects quantified variables as its first two arguments", function.Name))
+		}
+
+		method := strings.ToUpper(function.Name[:1]) + function.Name[1:]
+		rewritten := fmt.Sprintf("timeline.%v(%v, %v", method, a, b)
+		if arity == 3 {
+			duration := call.Args[2]
+			rewritten += ", " + code[fset.Position(duration.Pos()).Offset:fset.Position(duration.End()).Offset]
+		}
+		replacements = append(replacements, replacement{
+			fset.Position(call.Pos()).Offset, fset.Position(call.End()).Offset, rewritten + ")",
+		})
+		return false
+	})
+
+	for idx := len(replacements) - 1; idx >= 0; idx-- {
+		replacement := replacements[idx]
+		code = code[:replacement.start] + replacement.code + code[replacement.end:]
+	}
+	return code, len(replacements) > 0
+}
+
+// label wraps the monitor of a labelled obligation such that violations of it
+// are reported by its label.
+func (factory *MonitorFactory) label(label string, monitor *dst.CallExpr) *dst.CallExpr {
+	if label == "" {
+		return monitor
+	}
+
+	return &dst.CallExpr{
+		Fun: &dst.IndexExpr{
+			X: &dst.SelectorExpr{
+				X:   dst.NewIdent(factory.packageName),
+				Sel: dst.NewIdent("NewLabelledHyperAssertion"),
+			},
+			Index: dst.NewIdent(factory.modelName),
+		},
+		Args: []dst.Expr{
+			&dst.BasicLit{Kind: token.STRING, Value: strconv.Quote(label)},
+			monitor,
+		},
+	}
+}
+
+// assignments returns the statements assigning the executions of the bound
+// variables to them. The variables are also assigned to the blank identifier
+// such that they are used.
+func (factory *MonitorFactory) assignments() (body []dst.Stmt) {
 	if len(factory.variables) > 0 {
 		// e0, e1, e2 := assignments[0], assignments[1], assignments[2]
 		var lhs, anon, rhs []dst.Expr
 		for idx, identifier := range factory.variables {
 			anon = append(anon, dst.NewIdent(identifier))
 			lhs = append(lhs, dst.NewIdent(identifier))
-			rhs = append(rhs, &dst.IndexExpr{
+			var assignment dst.Expr = &dst.IndexExpr{
 				X:     dst.NewIdent("assignments"),
 				Index: &dst.BasicLit{Kind: token.INT, Value: fmt.Sprintf("%v", idx)},
-			})
+			}
+			if factory.function != "" {
+				// r := assignments[0].Read
+				assignment = &dst.SelectorExpr{X: assignment, Sel: dst.NewIdent(factory.types[idx])}
+			}
+			rhs = append(rhs, assignment)
 		}
 
 		executionAssignment := &dst.AssignStmt{
@@ -78,28 +244,58 @@ func (factory *MonitorFactory) NewPredicateMonitorCall(expression GoExpresion) *
 		body = append(body, anonymousAssignment)
 	}
 
+	return body
+}
+
+func (factory *MonitorFactory) NewPredicateMonitorCall(expression GoExpresion) *dst.CallExpr {
+	// FIXME: Can accidentally define variables not in use. First we have to see what variables are in use and only define those.
+
+	body := factory.assignments()
+
 	// Inject the expression.
-	expr, err := parser.ParseExpr(expression.code)
+	code, temporal := factory.temporal(expression.code)
+	if factory.function != "" {
+		code = factory.relativise(code)
+	}
+	expr, err := parser.ParseExpr(code)
 	if err != nil {
 		panic(err)
 	}
 	decor := decorator.NewDecorator(token.NewFileSet())
-	code, _ := decor.DecorateNode(expr)
+	decorated, _ := decor.DecorateNode(expr)
 	body = append(body, &dst.ReturnStmt{
 		Results: []dst.Expr{
-			code.(dst.Expr),
+			decorated.(dst.Expr),
 		},
 	})
 
+	// Keep every statement of the predicate on its own line.
+	for _, statement := range body {
+		statement.Decorations().Before = dst.NewLine
+	}
+
+	parameters := []*dst.Field{
+		{
+			Names: []*dst.Ident{dst.NewIdent("assignments")},
+			Type:  &dst.ArrayType{Elt: dst.NewIdent(factory.modelName)},
+		},
+	}
+	constructor := "NewPredicateHyperAssertion"
+	if temporal {
+		parameters = append(parameters, &dst.Field{
+			Names: []*dst.Ident{dst.NewIdent("timeline")},
+			Type: &dst.SelectorExpr{
+				X:   dst.NewIdent(factory.packageName),
+				Sel: dst.NewIdent("Timeline"),
+			},
+		})
+		constructor = "NewTemporalPredicateHyperAssertion"
+	}
+
 	predicate := &dst.FuncLit{
 		Type: &dst.FuncType{
 			Params: &dst.FieldList{
-				List: []*dst.Field{
-					{
-						Names: []*dst.Ident{dst.NewIdent("assignments")},
-						Type:  &dst.ArrayType{Elt: dst.NewIdent(factory.modelName)},
-					},
-				},
+				List: parameters,
 			},
 			Results: &dst.FieldList{
 				List: []*dst.Field{
@@ -115,7 +311,7 @@ func (factory *MonitorFactory) NewPredicateMonitorCall(expression GoExpresion) *
 	return &dst.CallExpr{
 		Fun: &dst.SelectorExpr{
 			X:   dst.NewIdent(factory.packageName),
-			Sel: dst.NewIdent("NewPredicateMonitor"),
+			Sel: dst.NewIdent(constructor),
 		},
 		Args: []dst.Expr{predicate},
 	}
@@ -123,12 +319,15 @@ func (factory *MonitorFactory) NewPredicateMonitorCall(expression GoExpresion) *
 
 func (factory *MonitorFactory) NewUniversalMonitorCall(universal Universal) *dst.CallExpr {
 	offset := factory.offset
-	factory.offset += len(universal.variables)
-	factory.variables = append(factory.variables, universal.variables...)
+	factory.bind(universal.variables, universal.types, true)
+	symmetry := factory.symmetry(universal, offset)
 	call := &dst.CallExpr{
-		Fun: &dst.SelectorExpr{
-			X:   dst.NewIdent(factory.packageName),
-			Sel: dst.NewIdent("NewUniversalMonitor"),
+		Fun: &dst.IndexExpr{
+			X: &dst.SelectorExpr{
+				X:   dst.NewIdent(factory.packageName),
+				Sel: dst.NewIdent("NewUniversalHyperAssertion"),
+			},
+			Index: dst.NewIdent(factory.modelName),
 		},
 		Args: []dst.Expr{
 			&dst.BasicLit{Kind: token.INT, Value: fmt.Sprintf("%v", offset)},
@@ -136,17 +335,76 @@ func (factory *MonitorFactory) NewUniversalMonitorCall(universal Universal) *dst
 			factory.Create(universal.assertion),
 		},
 	}
+	join := factory.join(universal.assertion, universal.variables, offset, true)
+	var order dst.Expr
+	if join == nil {
+		order = factory.order(universal.assertion, universal.variables, true)
+	}
+	if symmetry == Asymmetric && join == nil && order == nil {
+		return call
+	}
+
+	// sopher.NewSymmetricUniversalHyperAssertion[M](0, 2, sopher.Symmetric|sopher.Reflexive, ...)
+	var names []dst.Expr
+	if symmetry&Symmetric != 0 {
+		names = append(names, &dst.SelectorExpr{X: dst.NewIdent(factory.packageName), Sel: dst.NewIdent("Symmetric")})
+	}
+	if symmetry&Reflexive != 0 {
+		names = append(names, &dst.SelectorExpr{X: dst.NewIdent(factory.packageName), Sel: dst.NewIdent("Reflexive")})
+	}
+	if symmetry == Asymmetric {
+		names = append(names, &dst.SelectorExpr{X: dst.NewIdent(factory.packageName), Sel: dst.NewIdent("Asymmetric")})
+	}
+	argument := names[0]
+	for _, name := range names[1:] {
+		argument = &dst.BinaryExpr{X: argument, Op: token.OR, Y: name}
+	}
+	call.Fun.(*dst.IndexExpr).X.(*dst.SelectorExpr).Sel = dst.NewIdent("NewSymmetricUniversalHyperAssertion")
+	call.Args = slices.Insert(call.Args, 2, argument)
+	if join != nil {
+		// sopher.NewJoinedUniversalHyperAssertion[M](0, 2, sopher.Symmetric, sopher.NewJoin[M](...), ...)
+		call.Fun.(*dst.IndexExpr).X.(*dst.SelectorExpr).Sel = dst.NewIdent("NewJoinedUniversalHyperAssertion")
+		call.Args = slices.Insert(call.Args, 3, join)
+	} else if order != nil {
+		// sopher.NewOrderedUniversalHyperAssertion[M](0, 2, sopher.Asymmetric, sopher.NewOrder(...), ...)
+		call.Fun.(*dst.IndexExpr).X.(*dst.SelectorExpr).Sel = dst.NewIdent("NewOrderedUniversalHyperAssertion")
+		call.Args = slices.Insert(call.Args, 3, order)
+	}
 	return call
 }
 
+// symmetry returns the symmetry of the universal bound at the offset, which is
+// reflexive if it is distinct and symmetric if its body is a symmetric
+// expression. Variables of a composite contract are only reordered if they are
+// executions of the same function.
+func (factory *MonitorFactory) symmetry(universal Universal, offset int) Symmetry {
+	symmetry := Asymmetric
+	if universal.distinct && len(universal.variables) > 1 {
+		symmetry = Reflexive
+	}
+	expression, ok := universal.assertion.(GoExpresion)
+	if !ok {
+		return symmetry
+	}
+	types := factory.types[offset : offset+len(universal.variables)]
+	for _, function := range types {
+		if function != types[0] {
+			return symmetry
+		}
+	}
+	return symmetry | SymmetryOf(expression.code, universal.variables)
+}
+
 func (factory *MonitorFactory) NewExistentialMonitorCall(existential Existential) *dst.CallExpr {
 	offset := factory.offset
-	factory.offset += len(existential.variables)
-	factory.variables = append(factory.variables, existential.variables...)
+	factory.bind(existential.variables, existential.types, false)
 	call := &dst.CallExpr{
-		Fun: &dst.SelectorExpr{
-			X:   dst.NewIdent(factory.packageName),
-			Sel: dst.NewIdent("NewExistentialMonitor"),
+		Fun: &dst.IndexExpr{
+			X: &dst.SelectorExpr{
+				X:   dst.NewIdent(factory.packageName),
+				Sel: dst.NewIdent("NewExistentialHyperAssertion"),
+			},
+			Index: dst.NewIdent(factory.modelName),
 		},
 		Args: []dst.Expr{
 			&dst.BasicLit{Kind: token.INT, Value: fmt.Sprintf("%v", offset)},
@@ -154,5 +412,390 @@ func (factory *MonitorFactory) NewExistentialMonitorCall(existential Existential
 			factory.Create(existential.assertion),
 		},
 	}
+	if join := factory.join(existential.assertion, existential.variables, offset, false); join != nil {
+		// sopher.NewJoinedExistentialHyperAssertion[M](0, 2, sopher.NewJoin[M](...), ...)
+		call.Fun.(*dst.IndexExpr).X.(*dst.SelectorExpr).Sel = dst.NewIdent("NewJoinedExistentialHyperAssertion")
+		call.Args = slices.Insert(call.Args, 2, join)
+	} else if order := factory.order(existential.assertion, existential.variables, false); order != nil {
+		// sopher.NewOrderedExistentialHyperAssertion[M](0, 2, sopher.NewOrder(...), ...)
+		call.Fun.(*dst.IndexExpr).X.(*dst.SelectorExpr).Sel = dst.NewIdent("NewOrderedExistentialHyperAssertion")
+		call.Args = slices.Insert(call.Args, 2, order)
+	}
 	return call
 }
+
+// join returns the join of the variables of the quantifier bound at the offset
+// by the equalities its body requires if its body is an expression, and nil
+// otherwise. The key of a variable of a composite contract is only computed
+// for executions of the function typing it, and only variables typed by the
+// same function are joined.
+func (factory *MonitorFactory) join(body Node, variables []string, offset int, universal bool) dst.Expr {
+	expression, ok := body.(GoExpresion)
+	if !ok {
+		return nil
+	}
+	equality, ok := equalityOf(expression.code, variables, factory.variables, universal)
+	if !ok || factory.types[offset+equality.left] != factory.types[offset+equality.right] {
+		return nil
+	}
+
+	// key returns the function computing the key of the variable at the position.
+	key := func(position int, keys []string) *dst.FuncLit {
+		variable := variables[position]
+		statements := fmt.Sprintf("%v := assignment", variable)
+		if factory.function != "" {
+			statements = fmt.Sprintf(
+				"%v := assignment.%v; if %v == nil { return nil, false }",
+				variable, factory.types[offset+position], variable,
+			)
+		}
+		value := keys[0]
+		if len(keys) > 1 {
+			value = fmt.Sprintf("[%v]any{%v}", len(keys), strings.Join(keys, ", "))
+		}
+		return function(fmt.Sprintf(
+			"func(assignment %v) (any, bool) { %v; return %v, true }", factory.modelName, statements, value,
+		))
+	}
+
+	return &dst.CallExpr{
+		Fun: &dst.IndexExpr{
+			X: &dst.SelectorExpr{
+				X:   dst.NewIdent(factory.packageName),
+				Sel: dst.NewIdent("NewJoin"),
+			},
+			Index: dst.NewIdent(factory.modelName),
+		},
+		Args: []dst.Expr{
+			&dst.BasicLit{Kind: token.INT, Value: fmt.Sprintf("%v", equality.left)},
+			key(equality.left, equality.leftKeys),
+			&dst.BasicLit{Kind: token.INT, Value: fmt.Sprintf("%v", equality.right)},
+			key(equality.right, equality.rightKeys),
+		},
+	}
+}
+
+// order returns the order of the variables of a quantifier by the temporal
+// predicate its body requires if its body is an expression, and nil
+// otherwise.
+func (factory *MonitorFactory) order(body Node, variables []string, universal bool) dst.Expr {
+	expression, ok := body.(GoExpresion)
+	if !ok {
+		return nil
+	}
+	ordering, ok := orderingOf(expression.code, variables, factory.variables, universal)
+	if !ok {
+		return nil
+	}
+
+	duration := ordering.duration
+	if duration == "" {
+		duration = "0"
+	}
+	expr, err := parser.ParseExpr(fmt.Sprintf(
+		"%v.NewOrder(%v, %v, %q, %v)", factory.packageName, ordering.left, ordering.right, ordering.predicate, duration,
+	))
+	if err != nil {
+		panic(err)
+	}
+	decorated, _ := decorator.NewDecorator(token.NewFileSet()).DecorateNode(expr)
+	return decorated.(dst.Expr)
+}
+
+// NewCompiledMonitorCall returns the monitor of an assertion quantifying only
+// universally, or only existentially, over an expression compiled to nested
+// loops over the executions, or nil if it cannot be compiled. The loops of a
+// symmetric or reflexive universal skip the assignments its monitor would not
+// evaluate and the loops of the variables of a composite contract skip the
+// executions of the functions not typing them. Quantifiers joined by
+// equalities or ordered by temporal predicates are not compiled as their index
+// or order enumerates fewer assignments.
+func (factory *MonitorFactory) NewCompiledMonitorCall(assertion Node) *dst.CallExpr {
+	var loops []loop
+	universal := true
+	for quantifiers := 0; ; quantifiers++ {
+		var variables, types []string
+		var body Node
+		symmetry := Asymmetric
+		offset := factory.offset
+		switch cast := assertion.(type) {
+		case Universal:
+			if quantifiers > 0 && !universal {
+				return nil
+			}
+			variables, types, body = cast.variables, cast.types, cast.assertion
+			factory.bind(variables, types, true)
+			symmetry = factory.symmetry(cast, offset)
+		case Existential:
+			if quantifiers > 0 && universal {
+				return nil
+			}
+			universal = false
+			variables, types, body = cast.variables, cast.types, cast.assertion
+			factory.bind(variables, types, false)
+		case GoExpresion:
+			if quantifiers == 0 {
+				return nil
+			}
+			return factory.compile(cast, loops, universal)
+		default:
+			return nil
+		}
+		if factory.join(body, variables, offset, universal) != nil || factory.order(body, variables, universal) != nil {
+			return nil
+		}
+
+		for idx := range variables {
+			position := offset + idx
+			loop := loop{position: position}
+			if position == 0 {
+				// The outermost loop is partitioned across workers.
+				loop.start = "from"
+			}
+			if idx > 0 && symmetry&Symmetric != 0 {
+				// Only one ordering of the executions is evaluated.
+				loop.start = fmt.Sprintf("i%v", position-1)
+				if symmetry&Reflexive != 0 {
+					loop.start += " + 1"
+				}
+			} else if idx > 0 && symmetry&Reflexive != 0 {
+				// The same execution is not evaluated twice.
+				var equalities []string
+				for previous := offset; previous < position; previous++ {
+					equalities = append(equalities, fmt.Sprintf("i%v == i%v", position, previous))
+				}
+				loop.skip = strings.Join(equalities, " || ")
+			}
+			loops = append(loops, loop)
+		}
+		assertion = body
+	}
+}
+
+// loop is the loop over the executions assigned to the variable at the
+// position of a compiled quantifier. It starts at the start, or the first
+// execution if it is empty, and skips the executions for which the skip holds
+// unless it is empty.
+type loop struct {
+	position    int
+	start, skip string
+}
+
+// header returns the statement opening the loop.
+func (loop loop) header() string {
+	header := fmt.Sprintf("for i%v := range elements {", loop.position)
+	if loop.position == 0 {
+		header = fmt.Sprintf("for i0 := %v; i0 < to; i0++ {", loop.start)
+	} else if loop.start != "" {
+		header = fmt.Sprintf("for i%v := %v; i%v < len(elements); i%v++ {",
+			loop.position, loop.start, loop.position, loop.position)
+	}
+	if loop.skip != "" {
+		header += fmt.Sprintf("\nif %v {\ncontinue\n}", loop.skip)
+	}
+	return header
+}
+
+// compile returns the monitor compiled from the loops over the executions of
+// the bound variables around the expression. A universal is false once an
+// assignment violates the expression and an existential true once an
+// assignment satisfies it, with the assignment as its witness, and both are
+// otherwise unknown. The innermost loop of a universal starts at the first
+// execution unseen by the history if the outer loops only assign seen ones, as
+// their assignments were evaluated when the history was.
+func (factory *MonitorFactory) compile(expression GoExpresion, loops []loop, universal bool) *dst.CallExpr {
+	code, temporal := factory.temporal(expression.code)
+
+	indices := make([]string, len(loops))
+	for position := range loops {
+		indices[position] = fmt.Sprintf("i%v", position)
+	}
+
+	var source strings.Builder
+	parameter := "_"
+	if temporal || universal {
+		parameter = "history"
+	}
+	if universal {
+		innermost := &loops[len(loops)-1]
+		unseen := fmt.Sprintf("history.Unseen(%v)", strings.Join(indices[:innermost.position], ", "))
+		if innermost.start != "" {
+			unseen = fmt.Sprintf("max(%v, %v)", innermost.start, unseen)
+		}
+		innermost.start = unseen
+	}
+	fmt.Fprintf(&source, "func(elements []%v, %v %v.Timeline, from, to int) (%v.LiftedBoolean, []int) {\n",
+		factory.modelName, parameter, factory.packageName, factory.packageName)
+	if temporal {
+		fmt.Fprintf(&source, "var indices [%v]int\ntimeline := history.Assigned(indices[:])\n", len(factory.variables))
+	}
+	for position, loop := range loops {
+		source.WriteString(loop.header() + "\n")
+		if temporal {
+			fmt.Fprintf(&source, "indices[%v] = i%v\n", position, position)
+		}
+		variable := factory.variables[position]
+		if factory.function == "" {
+			fmt.Fprintf(&source, "%v := elements[i%v]\n", variable, position)
+		} else {
+			// Executions of other functions are not assigned to the variable.
+			fmt.Fprintf(&source, "%v := elements[i%v].%v\nif %v == nil {\ncontinue\n}\n",
+				variable, position, factory.types[position], variable)
+		}
+	}
+	blanks := strings.TrimSuffix(strings.Repeat("_, ", len(factory.variables)), ", ")
+	fmt.Fprintf(&source, "%v = %v\n", blanks, strings.Join(factory.variables, ", "))
+	witness := fmt.Sprintf("[]int{%v}", strings.Join(indices, ", "))
+	if universal {
+		if expression, err := parser.ParseExpr(code); err == nil {
+			if _, binary := expression.(*ast.BinaryExpr); binary {
+				code = "(" + code + ")"
+			}
+		}
+		fmt.Fprintf(&source, "if !%v {\nreturn %v.LiftedFalse, %v\n}\n", code, factory.packageName, witness)
+	} else {
+		fmt.Fprintf(&source, "if %v {\nreturn %v.LiftedTrue, %v\n}\n", code, factory.packageName, witness)
+	}
+	source.WriteString(strings.Repeat("}\n", len(loops)))
+	fmt.Fprintf(&source, "return %v.LiftedUnknown, nil\n}", factory.packageName)
+
+	return &dst.CallExpr{
+		Fun: &dst.IndexExpr{
+			X: &dst.SelectorExpr{
+				X:   dst.NewIdent(factory.packageName),
+				Sel: dst.NewIdent("NewCompiledHyperAssertion"),
+			},
+			Index: dst.NewIdent(factory.modelName),
+		},
+		Args: []dst.Expr{function(source.String())},
+	}
+}
+
+// durationLiteral matches duration literals, e.g. 100ms or 1h30m, which are
+// not Go but can be used by the expressions of aggregates.
+var durationLiteral = regexp.MustCompile(`\b([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+\b`)
+
+// durations rewrites the duration literals of the code into their number of
+// nanoseconds which is also the value of a time.Duration.
+func durations(code string) string {
+	return durationLiteral.ReplaceAllStringFunc(code, func(literal string) string {
+		duration, err := time.ParseDuration(literal)
+		if err != nil {
+			return literal
+		}
+		return strconv.FormatInt(duration.Nanoseconds(), 10)
+	})
+}
+
+// function returns the function literal of the source with every statement
+// on its own line.
+func function(source string) *dst.FuncLit {
+	expr, err := parser.ParseExpr(source)
+	if err != nil {
+		panic(err)
+	}
+	decor := decorator.NewDecorator(token.NewFileSet())
+	decorated, _ := decor.DecorateNode(expr)
+	literal := decorated.(*dst.FuncLit)
+	for _, statement := range literal.Body.List {
+		statement.Decorations().Before = dst.NewLine
+	}
+	return literal
+}
+
+// MaskDurations replaces the duration literals of the code by zeros such that
+// it parses as Go with the same positions.
+func MaskDurations(code string) string {
+	return durationLiteral.ReplaceAllStringFunc(code, func(literal string) string {
+		return strings.Repeat("0", len(literal))
+	})
+}
+
+// closure returns the function literal of the source with the assignments of
+// the bound variables before its statements.
+func (factory *MonitorFactory) closure(source string) *dst.FuncLit {
+	closure := function(source)
+	assignments := factory.assignments()
+	for _, statement := range assignments {
+		statement.Decorations().Before = dst.NewLine
+	}
+	closure.Body.List = append(assignments, closure.Body.List...)
+	return closure
+}
+
+// NewAggregateMonitorCall returns the monitor of an aggregate. The value of an
+// execution is converted to a float64 and compared with the bound, such that
+// durations are compared by their nanoseconds, and is the condition of the
+// counted executions for count and of the successes for probability. In
+// composite contracts only executions of the function typing the variable are
+// aggregated.
+func (factory *MonitorFactory) NewAggregateMonitorCall(aggregate Aggregate) *dst.CallExpr {
+	offset := factory.offset
+
+	comparison := fmt.Sprintf("aggregate %v float64(%v)", aggregate.operator, durations(aggregate.bound.(GoExpresion).code))
+	if factory.function != "" {
+		comparison = factory.relativise(comparison)
+	}
+	compare := factory.closure(fmt.Sprintf(
+		"func(assignments []%v, aggregate float64) bool { return %v }", factory.modelName, comparison,
+	))
+
+	factory.bind(aggregate.variables, aggregate.types, true)
+
+	var statements string
+	if factory.function != "" {
+		// Executions with a variable assigned an execution of another function
+		// have no value.
+		statements = fmt.Sprintf("if %v == nil { return 0, false }; ", strings.Join(factory.variables, " == nil || "))
+	}
+	value := durations(aggregate.value.(GoExpresion).code)
+	switch aggregate.function {
+	case "count":
+		statements += fmt.Sprintf("return 1, %v", value)
+	case "probability":
+		statements += fmt.Sprintf("if %v { return 1, true }; return 0, true", value)
+	default:
+		statements += fmt.Sprintf("return float64(%v), true", value)
+	}
+	valuation := factory.closure(fmt.Sprintf(
+		"func(assignments []%v) (float64, bool) { %v }", factory.modelName, statements,
+	))
+
+	confidence := strconv.FormatFloat(aggregate.confidence, 'g', -1, 64)
+	var aggregator string
+	switch {
+	case aggregate.function == "mean" && aggregate.confidence > 0:
+		aggregator = fmt.Sprintf("NewBootstrapMean(%v)", confidence)
+	case aggregate.function == "probability":
+		aggregator = fmt.Sprintf("NewProportion(%v)", confidence)
+	case aggregate.function == "mean":
+		aggregator = "NewMean()"
+	case aggregate.function == "sum":
+		aggregator = "NewSum()"
+	case aggregate.function == "count":
+		aggregator = "NewCount()"
+	case aggregate.function == "percentile":
+		aggregator = fmt.Sprintf("NewPercentile(%v)", strconv.FormatFloat(aggregate.percentile, 'g', -1, 64))
+	default:
+		panic(fmt.Sprintf("unknown aggregate %v", aggregate.function))
+	}
+	constructor := function(fmt.Sprintf(
+		"func() %v.Aggregator { return %v.%v }", factory.packageName, factory.packageName, aggregator,
+	))
+
+	return &dst.CallExpr{
+		Fun: &dst.IndexExpr{
+			X: &dst.SelectorExpr{
+				X:   dst.NewIdent(factory.packageName),
+				Sel: dst.NewIdent("NewAggregateHyperAssertion"),
+			},
+			Index: dst.NewIdent(factory.modelName),
+		},
+		Args: []dst.Expr{
+			&dst.BasicLit{Kind: token.INT, Value: fmt.Sprintf("%v", offset)},
+			constructor,
+			valuation,
+			compare,
+		},
+	}
+}
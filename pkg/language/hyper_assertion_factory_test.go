@@ -2,22 +2,49 @@ package language
 
 import (
 	"bytes"
-	"go/printer"
 	"go/token"
+	"strings"
 	"testing"
 
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
 	"github.com/stretchr/testify/assert"
 )
 
+// printExpression prints the expression by restoring it as the value of a
+// blank variable declaration in an otherwise empty file.
+func printExpression(t *testing.T, expression dst.Expr) string {
+	file := &dst.File{
+		Name: dst.NewIdent("examples"),
+		Decls: []dst.Decl{
+			&dst.GenDecl{
+				Tok: token.VAR,
+				Specs: []dst.Spec{
+					&dst.ValueSpec{
+						Names:  []*dst.Ident{dst.NewIdent("_")},
+						Values: []dst.Expr{expression},
+					},
+				},
+			},
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := decorator.Fprint(&buffer, file); err != nil {
+		t.Fatal(err)
+	}
+
+	source := strings.TrimPrefix(buffer.String(), "package examples\n\nvar _ = ")
+	return strings.TrimSuffix(source, "\n")
+}
+
 func TestNewPredicateMonitorCall(t *testing.T) {
 	factory := NewGoMonitorFactory("sopher", "ExecutionModel")
 	expression := NewGoExpression("!(e0.high == e1.high) || (e0.ret0 == e2.ret0)")
 	call := factory.NewPredicateMonitorCall(expression)
-	var buffer bytes.Buffer
-	printer.Fprint(&buffer, token.NewFileSet(), call)
-	assert.Equal(t, `sopher.NewPredicateMonitor(func(assignments []ExecutionModel) bool {
+	assert.Equal(t, `sopher.NewPredicateHyperAssertion(func(assignments []ExecutionModel) bool {
 	return !(e0.high == e1.high) || (e0.ret0 == e2.ret0)
-})`, buffer.String())
+})`, printExpression(t, call))
 }
 
 func TestNewUniversalMonitorCall(t *testing.T) {
@@ -25,12 +52,156 @@ func TestNewUniversalMonitorCall(t *testing.T) {
 	expression := NewGoExpression("!(e0.high == e1.high) || (e0.ret0 == e2.ret0)")
 	forall := NewUniversal([]string{"e0", "e1"}, expression)
 	call := factory.Create(forall)
-	var buffer bytes.Buffer
-	printer.Fprint(&buffer, token.NewFileSet(), call)
-	assert.Equal(t, `sopher.NewUniversalMonitor(0, 2, sopher.NewPredicateMonitor(func(assignments []ExecutionModel) bool {
+	assert.Equal(t, `sopher.NewJoinedUniversalHyperAssertion[ExecutionModel](0, 2, sopher.Asymmetric, sopher.NewJoin[ExecutionModel](0, func(assignment ExecutionModel) (any, bool) {
+	e0 := assignment
+	return e0.high, true
+}, 1, func(assignment ExecutionModel) (any, bool) {
+	e1 := assignment
+	return e1.high, true
+}), sopher.NewPredicateHyperAssertion(func(assignments []ExecutionModel) bool {
 	e0, e1 := assignments[0], assignments[1]
+	_, _ = e0, e1
 	return !(e0.high == e1.high) || (e0.ret0 == e2.ret0)
-}))`, buffer.String())
+}))`, printExpression(t, call))
+}
+
+func TestSymmetricMonitorCall(t *testing.T) {
+	factory := NewGoMonitorFactory("sopher", "ExecutionModel")
+	expression := NewGoExpression("!(e0.high == e1.high) || (e0.ret0 == e1.ret0)")
+	call := factory.Create(NewGuarantee(NewUniversal([]string{"e0", "e1"}, expression)))
+	assert.Equal(t, `sopher.NewJoinedUniversalHyperAssertion[ExecutionModel](0, 2, sopher.Symmetric, sopher.NewJoin[ExecutionModel](0, func(assignment ExecutionModel) (any, bool) {
+	e0 := assignment
+	return e0.high, true
+}, 1, func(assignment ExecutionModel) (any, bool) {
+	e1 := assignment
+	return e1.high, true
+}), sopher.NewPredicateHyperAssertion(func(assignments []ExecutionModel) bool {
+	e0, e1 := assignments[0], assignments[1]
+	_, _ = e0, e1
+	return !(e0.high == e1.high) || (e0.ret0 == e1.ret0)
+}))`, printExpression(t, call))
+
+	// Executions of different functions are not reordered.
+	composite := NewGoCompositeMonitorFactory("sopher", "Erase_CompositionModel", "Erase")
+	expression = NewGoExpression("e.path != r.path")
+	call = composite.Create(NewTypedUniversal([]string{"e", "r"}, []string{"", "Read"}, expression))
+	assert.Equal(t, `sopher.NewUniversalHyperAssertion[Erase_CompositionModel](0, 2, sopher.NewPredicateHyperAssertion(func(assignments []Erase_CompositionModel) bool {
+	e, r := assignments[0].Erase, assignments[1].Read
+	_, _ = e, r
+	return e == nil || (r == nil || (e.path != r.path))
+}))`, printExpression(t, call))
+}
+
+func TestJoinedMonitorCall(t *testing.T) {
+	factory := NewGoMonitorFactory("sopher", "ExecutionModel")
+	expression := NewGoExpression("e1.user == e0.user && e1.ip == e0.ip && e0.ret0 != e1.ret0")
+	call := factory.Create(NewGuarantee(NewExistential([]string{"e0", "e1"}, expression)))
+	assert.Equal(t, `sopher.NewJoinedExistentialHyperAssertion[ExecutionModel](0, 2, sopher.NewJoin[ExecutionModel](0, func(assignment ExecutionModel) (any, bool) {
+	e0 := assignment
+	return [2]any{e0.user, e0.ip}, true
+}, 1, func(assignment ExecutionModel) (any, bool) {
+	e1 := assignment
+	return [2]any{e1.user, e1.ip}, true
+}), sopher.NewPredicateHyperAssertion(func(assignments []ExecutionModel) bool {
+	e0, e1 := assignments[0], assignments[1]
+	_, _ = e0, e1
+	return e1.user == e0.user && e1.ip == e0.ip && e0.ret0 != e1.ret0
+}))`, printExpression(t, call))
+
+	// Only executions of the function typing the variables have keys.
+	composite := NewGoCompositeMonitorFactory("sopher", "Erase_CompositionModel", "Erase")
+	expression = NewGoExpression("r0.path != r1.path || r0.ret1 == r1.ret1")
+	call = composite.Create(NewGuarantee(NewDistinctUniversal([]string{"r0", "r1"}, []string{"Read", "Read"}, expression)))
+	assert.Equal(t, `sopher.NewJoinedUniversalHyperAssertion[Erase_CompositionModel](0, 2, sopher.Symmetric|sopher.Reflexive, sopher.NewJoin[Erase_CompositionModel](0, func(assignment Erase_CompositionModel) (any, bool) {
+	r0 := assignment.Read
+	if r0 == nil {
+		return nil, false
+	}
+	return r0.path, true
+}, 1, func(assignment Erase_CompositionModel) (any, bool) {
+	r1 := assignment.Read
+	if r1 == nil {
+		return nil, false
+	}
+	return r1.path, true
+}), sopher.NewPredicateHyperAssertion(func(assignments []Erase_CompositionModel) bool {
+	r0, r1 := assignments[0].Read, assignments[1].Read
+	_, _ = r0, r1
+	return r0 == nil || (r1 == nil || (r0.path != r1.path || r0.ret1 == r1.ret1))
+}))`, printExpression(t, call))
+}
+
+func TestCompiledMonitorCall(t *testing.T) {
+	factory := NewGoMonitorFactory("sopher", "ExecutionModel")
+	call := factory.Create(NewGuarantee(NewUniversal([]string{"e0", "e1"}, NewGoExpression("e0.ret0 == e1.ret0"))))
+	assert.Equal(t, `sopher.NewCompiledHyperAssertion[ExecutionModel](func(elements []ExecutionModel, history sopher.Timeline, from, to int) (sopher.LiftedBoolean, []int) {
+	for i0 := from; i0 < to; i0++ {
+		e0 := elements[i0]
+		for i1 := max(i0, history.Unseen(i0)); i1 < len(elements); i1++ {
+			e1 := elements[i1]
+			_, _ = e0, e1
+			if !(e0.ret0 == e1.ret0) {
+				return sopher.LiftedFalse, []int{i0, i1}
+			}
+		}
+	}
+	return sopher.LiftedUnknown, nil
+})`, printExpression(t, call))
+
+	// Distinct universals skip assignments of the same execution. The
+	// innermost loop of a universal skips the executions seen by the history
+	// unless an outer loop assigns an unseen one.
+	call = factory.Create(NewAssumption(NewUniversal([]string{"e0"}, NewDistinctUniversal([]string{"e1", "e2"}, nil, NewGoExpression("e1.x >= e2.x || e0.x > 0")))))
+	assert.Equal(t, `sopher.NewCompiledHyperAssertion[ExecutionModel](func(elements []ExecutionModel, history sopher.Timeline, from, to int) (sopher.LiftedBoolean, []int) {
+	for i0 := from; i0 < to; i0++ {
+		e0 := elements[i0]
+		for i1 := range elements {
+			e1 := elements[i1]
+			for i2 := history.Unseen(i0, i1); i2 < len(elements); i2++ {
+				if i2 == i1 {
+					continue
+				}
+				e2 := elements[i2]
+				_, _, _ = e0, e1, e2
+				if !(e1.x >= e2.x || e0.x > 0) {
+					return sopher.LiftedFalse, []int{i0, i1, i2}
+				}
+			}
+		}
+	}
+	return sopher.LiftedUnknown, nil
+})`, printExpression(t, call))
+
+	// Temporal predicates are evaluated on the timeline of the loops.
+	call = factory.Create(NewGuarantee(NewExistential([]string{"e0", "e1"}, NewGoExpression("next(e0, e1) || e0.ret0 != e1.ret0"))))
+	assert.Equal(t, `sopher.NewCompiledHyperAssertion[ExecutionModel](func(elements []ExecutionModel, history sopher.Timeline, from, to int) (sopher.LiftedBoolean, []int) {
+	var indices [2]int
+	timeline := history.Assigned(indices[:])
+	for i0 := from; i0 < to; i0++ {
+		indices[0] = i0
+		e0 := elements[i0]
+		for i1 := range elements {
+			indices[1] = i1
+			e1 := elements[i1]
+			_, _ = e0, e1
+			if timeline.Next(0, 1) || e0.ret0 != e1.ret0 {
+				return sopher.LiftedTrue, []int{i0, i1}
+			}
+		}
+	}
+	return sopher.LiftedUnknown, nil
+})`, printExpression(t, call))
+
+	// Quantifier alternations and aggregates are interpreted.
+	alternation := NewUniversal([]string{"e0"}, NewExistential([]string{"e1"}, NewGoExpression("e1.x == e0.ret0")))
+	assert.Nil(t, factory.NewCompiledMonitorCall(alternation))
+	factory.reset()
+	aggregate := NewAggregate("count", []string{"t"}, nil, NewGoExpression("t.ret0"), ">", NewGoExpression("3"))
+	assert.Nil(t, factory.NewCompiledMonitorCall(NewUniversal([]string{"e"}, aggregate)))
+
+	// Ordered quantifiers are interpreted.
+	factory.reset()
+	assert.Nil(t, factory.NewCompiledMonitorCall(NewUniversal([]string{"e0", "e1"}, NewGoExpression("!before(e0, e1) || e0.x < e1.x"))))
 }
 
 func TestNewExistentialMonitorCall(t *testing.T) {
@@ -38,12 +209,11 @@ func TestNewExistentialMonitorCall(t *testing.T) {
 	expression := NewGoExpression("e0.ret > 0")
 	exists := NewExistential([]string{"e0"}, expression)
 	call := factory.Create(exists)
-	var buffer bytes.Buffer
-	printer.Fprint(&buffer, token.NewFileSet(), call)
-	assert.Equal(t, `sopher.NewExistentialMonitor(0, 1, sopher.NewPredicateMonitor(func(assignments []ExecutionModel) bool {
+	assert.Equal(t, `sopher.NewExistentialHyperAssertion[ExecutionModel](0, 1, sopher.NewPredicateHyperAssertion(func(assignments []ExecutionModel) bool {
 	e0 := assignments[0]
+	_ = e0
 	return e0.ret > 0
-}))`, buffer.String())
+}))`, printExpression(t, call))
 }
 
 func TestNewUniversalaNDExistentialMonitorCall(t *testing.T) {
@@ -52,11 +222,122 @@ func TestNewUniversalaNDExistentialMonitorCall(t *testing.T) {
 	exists := NewExistential([]string{"e2"}, expression)
 	forall := NewUniversal([]string{"e0", "e1"}, exists)
 	call := factory.Create(forall)
-	var buffer bytes.Buffer
-	printer.Fprint(&buffer, token.NewFileSet(), call)
-	t.Log(buffer.String())
-	assert.Equal(t, `sopher.NewUniversalMonitor(0, 2, sopher.NewExistentialMonitor(2, 1, sopher.NewPredicateMonitor(func(assignments []ExecutionModel) bool {
+	assert.Equal(t, `sopher.NewUniversalHyperAssertion[ExecutionModel](0, 2, sopher.NewExistentialHyperAssertion[ExecutionModel](2, 1, sopher.NewPredicateHyperAssertion(func(assignments []ExecutionModel) bool {
 	e0, e1, e2 := assignments[0], assignments[1], assignments[2]
+	_, _, _ = e0, e1, e2
 	return !(e0.high == e1.high) || (e0.ret0 == e2.ret0)
-})))`, buffer.String())
+})))`, printExpression(t, call))
+}
+
+func TestLabelledMonitorCall(t *testing.T) {
+	factory := NewGoMonitorFactory("sopher", "ExecutionModel")
+	guarantee := NewLabelledGuarantee(`No "Timing" Side Channel`, NewUniversal([]string{"e"}, NewGoExpression("e.ret0")))
+	call := factory.Create(guarantee)
+	assert.Equal(t, `sopher.NewLabelledHyperAssertion[ExecutionModel]("No \"Timing\" Side Channel", sopher.NewCompiledHyperAssertion[ExecutionModel](func(elements []ExecutionModel, history sopher.Timeline, from, to int) (sopher.LiftedBoolean, []int) {
+	for i0 := max(from, history.Unseen()); i0 < to; i0++ {
+		e := elements[i0]
+		_ = e
+		if !e.ret0 {
+			return sopher.LiftedFalse, []int{i0}
+		}
+	}
+	return sopher.LiftedUnknown, nil
+}))`, printExpression(t, call))
+}
+
+func TestCompositeMonitorCall(t *testing.T) {
+	factory := NewGoCompositeMonitorFactory("sopher", "Erase_CompositionModel", "Erase")
+	expression := NewGoExpression("r.path != e.path || r.ret1")
+	exists := NewTypedExistential([]string{"r"}, []string{"Read"}, expression)
+	forall := NewUniversal([]string{"e"}, exists)
+	call := factory.Create(NewGuarantee(forall))
+	assert.Equal(t, `sopher.NewUniversalHyperAssertion[Erase_CompositionModel](0, 1, sopher.NewExistentialHyperAssertion[Erase_CompositionModel](1, 1, sopher.NewPredicateHyperAssertion(func(assignments []Erase_CompositionModel) bool {
+	e, r := assignments[0].Erase, assignments[1].Read
+	_, _ = e, r
+	return e == nil || (r != nil && (r.path != e.path || r.ret1))
+})))`, printExpression(t, call))
+
+	single := NewGoMonitorFactory("sopher", "ExecutionModel")
+	assert.PanicsWithValue(t, "typed variables are only allowed in composite contracts", func() {
+		single.Create(NewGuarantee(exists))
+	})
+}
+
+func TestTemporalMonitorCall(t *testing.T) {
+	factory := NewGoMonitorFactory("sopher", "ExecutionModel")
+	expression := NewGoExpression("!next(e0, e1) || e1.attempt == e0.attempt+1 && within(e0, e1, 15 * time.Minute)")
+	call := factory.Create(NewUniversal([]string{"e0", "e1"}, expression))
+	assert.Equal(t, `sopher.NewOrderedUniversalHyperAssertion[ExecutionModel](0, 2, sopher.Asymmetric, sopher.NewOrder(0, 1, "next", 0), sopher.NewTemporalPredicateHyperAssertion(func(assignments []ExecutionModel, timeline sopher.Timeline) bool {
+	e0, e1 := assignments[0], assignments[1]
+	_, _ = e0, e1
+	return !timeline.Next(0, 1) || e1.attempt == e0.attempt+1 && timeline.Within(0, 1, 15*time.Minute)
+}))`, printExpression(t, call))
+
+	// Only the executions within the duration are assigned to the existential.
+	factory = NewGoMonitorFactory("sopher", "ExecutionModel")
+	expression = NewGoExpression("e0.ret0 && within(e1, e0, 15 * time.Minute)")
+	call = factory.Create(NewExistential([]string{"e0", "e1"}, expression))
+	assert.Equal(t, `sopher.NewOrderedExistentialHyperAssertion[ExecutionModel](0, 2, sopher.NewOrder(1, 0, "within", 15*time.Minute), sopher.NewTemporalPredicateHyperAssertion(func(assignments []ExecutionModel, timeline sopher.Timeline) bool {
+	e0, e1 := assignments[0], assignments[1]
+	_, _ = e0, e1
+	return e0.ret0 && timeline.Within(1, 0, 15*time.Minute)
+}))`, printExpression(t, call))
+
+	assert.PanicsWithValue(t, "before expects quantified variables as its first two arguments", func() {
+		factory.Create(NewGuarantee(NewUniversal([]string{"e"}, NewGoExpression("before(e, x)"))))
+	})
+	assert.PanicsWithValue(t, "within expects 3 arguments but got 2", func() {
+		factory.Create(NewGuarantee(NewUniversal([]string{"e0", "e1"}, NewGoExpression("within(e0, e1)"))))
+	})
+}
+
+func TestAggregateMonitorCall(t *testing.T) {
+	factory := NewGoMonitorFactory("sopher", "ExecutionModel")
+	value, bound := NewGoExpression("t.latency"), NewGoExpression("100ms")
+	call := factory.Create(NewPercentileAggregate(95, []string{"t"}, nil, value, "<=", bound))
+	assert.Equal(t, `sopher.NewAggregateHyperAssertion[ExecutionModel](0, func() sopher.Aggregator {
+	return sopher.NewPercentile(95)
+}, func(assignments []ExecutionModel) (float64, bool) {
+	t := assignments[0]
+	_ = t
+	return float64(t.latency), true
+}, func(assignments []ExecutionModel, aggregate float64) bool {
+	return aggregate <= float64(100000000)
+})`, printExpression(t, call))
+
+	factory = NewGoCompositeMonitorFactory("sopher", "CompositionModel", "Erase")
+	count := NewAggregate("count", []string{"r"}, []string{"Read"}, NewGoExpression("r.ret1"), ">", NewGoExpression("e.limit"))
+	call = factory.Create(NewUniversal([]string{"e"}, count))
+	assert.Equal(t, `sopher.NewUniversalHyperAssertion[CompositionModel](0, 1, sopher.NewAggregateHyperAssertion[CompositionModel](1, func() sopher.Aggregator {
+	return sopher.NewCount()
+}, func(assignments []CompositionModel) (float64, bool) {
+	e, r := assignments[0].Erase, assignments[1].Read
+	_, _ = e, r
+	if e == nil || r == nil {
+		return 0, false
+	}
+	return 1, r.ret1
+}, func(assignments []CompositionModel, aggregate float64) bool {
+	e := assignments[0].Erase
+	_ = e
+	return e == nil || (aggregate > float64(e.limit))
+}))`, printExpression(t, call))
+}
+
+func TestEstimateMonitorCall(t *testing.T) {
+	factory := NewGoMonitorFactory("sopher", "ExecutionModel")
+	value, bound := NewGoExpression("t.latency <= 100ms"), NewGoExpression("0.9")
+	call := factory.Create(NewEstimateAggregate("probability", 0.99, []string{"t"}, nil, value, ">=", bound))
+	assert.Equal(t, `sopher.NewAggregateHyperAssertion[ExecutionModel](0, func() sopher.Aggregator {
+	return sopher.NewProportion(0.99)
+}, func(assignments []ExecutionModel) (float64, bool) {
+	t := assignments[0]
+	_ = t
+	if t.latency <= 100000000 {
+		return 1, true
+	}
+	return 0, true
+}, func(assignments []ExecutionModel, aggregate float64) bool {
+	return aggregate >= float64(0.9)
+})`, printExpression(t, call))
 }
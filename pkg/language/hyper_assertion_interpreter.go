@@ -2,59 +2,417 @@ package language
 
 // TODO: Iterative evaluation should be a lot faster.
 
-import "github.com/hyperproperties/sopher/pkg/iterx"
+import (
+	"iter"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/hyperproperties/sopher/pkg/iterx"
+)
 
 type HyperAssertionInterpreter[T any] struct {
 	elements []T
 	assignments []T
+	// indices are the positions of the assigned elements and times are the
+	// times the elements were made or nil if they are untimed.
+	indices []int
+	times []time.Time
+	// history is whether the elements are a history which only grows between
+	// evaluations.
+	history bool
+	// seen is the number of elements evaluated before such that the outermost
+	// universals only evaluate the assignments of the elements after them.
+	seen int
+	// estimates are the estimates of the statistical assertions not nested in
+	// quantifiers.
+	estimates []Estimate
+	// indexes are the indexes of the joins of the quantifiers by the join
+	// built or maintained in the evaluation.
+	indexes map[*Join[T]]map[any][]int
+	// workers is the number of workers evaluating the outermost quantifier.
+	workers int
+	// witness is the positions of the elements assigned to the variables by
+	// the assignment which decided the assertion or nil if it is undecided.
+	witness []int
 	assertion HyperAssertion[T]
-	satisfied bool
+	result LiftedBoolean
 }
 
+// NewHyperAssertionInterpreter returns an interpreter with the parallelism
+// set by SetParallelism.
 func NewHyperAssertionInterpreter[T any]() HyperAssertionInterpreter[T] {
-	return HyperAssertionInterpreter[T]{}
+	return NewParallelHyperAssertionInterpreter[T](Parallelism())
+}
+
+// NewParallelHyperAssertionInterpreter returns an interpreter partitioning the
+// domain of the outermost quantifier of an assertion across the workers. The
+// result and witness are the same as those of a sequential interpreter.
+func NewParallelHyperAssertionInterpreter[T any](workers int) HyperAssertionInterpreter[T] {
+	return HyperAssertionInterpreter[T]{workers: max(1, workers)}
+}
+
+// Satisfies reports whether the elements satisfy the assertion with the
+// semantics of monitoring a set of elements which may still grow. An assertion
+// is false once it is violated by the elements and true once no more elements
+// can violate it, e.g. a universal quantifier is false once violated and an
+// existential quantifier true once witnessed, and unknown otherwise.
+func (interpreter *HyperAssertionInterpreter[T]) Satisfies(assertion HyperAssertion[T], elements []T) LiftedBoolean {
+	return interpreter.SatisfiesTimed(assertion, elements, nil)
+}
+
+// SatisfiesTimed reports whether the elements made at the times, in the order
+// they were made, satisfy the assertion as Satisfies. The times are nil if the
+// elements are untimed.
+func (interpreter *HyperAssertionInterpreter[T]) SatisfiesTimed(
+	assertion HyperAssertion[T], elements []T, times []time.Time,
+) LiftedBoolean {
+	interpreter.history, interpreter.seen = false, 0
+	return interpreter.satisfies(assertion, elements, times)
+}
+
+// SatisfiesExtension reports whether the elements made at the times satisfy
+// the assertion as SatisfiesTimed given that the seen elements before them
+// were evaluated before. An outermost universal only evaluates the assignments
+// of at least one unseen element unless the elements may violate its body for
+// the others, i.e. if its body aggregates the elements or quantifies
+// universally over them, such that it is only false if violated by the unseen
+// elements.
+func (interpreter *HyperAssertionInterpreter[T]) SatisfiesExtension(
+	assertion HyperAssertion[T], elements []T, times []time.Time, seen int,
+) LiftedBoolean {
+	interpreter.history, interpreter.seen = false, seen
+	return interpreter.satisfies(assertion, elements, times)
 }
 
-func (interpreter *HyperAssertionInterpreter[T]) Satisfies(assertion HyperAssertion[T], elements []T) bool {
+// SatisfiesHistory reports whether the history of elements made at the times
+// satisfies the assertion as SatisfiesExtension with the elements seen by the
+// last evaluation. The history must only grow between evaluations of the
+// assertion, unless its aggregates and indexes are forgotten, such that they
+// only aggregate and index the elements added since they were last evaluated.
+func (interpreter *HyperAssertionInterpreter[T]) SatisfiesHistory(
+	assertion HyperAssertion[T], elements []T, times []time.Time, seen int,
+) LiftedBoolean {
+	interpreter.history, interpreter.seen = true, seen
+	return interpreter.satisfies(assertion, elements, times)
+}
+
+func (interpreter *HyperAssertionInterpreter[T]) satisfies(
+	assertion HyperAssertion[T], elements []T, times []time.Time,
+) LiftedBoolean {
 	interpreter.elements = elements
+	interpreter.times = times
+	interpreter.estimates = nil
+	interpreter.indexes = nil
+	interpreter.witness = nil
 	interpreter.assignments = make([]T, assertion.Size())
+	interpreter.indices = make([]int, assertion.Size())
 	interpreter.assertion = assertion
 	interpreter.assertion.Accept(interpreter)
-	return interpreter.satisfied
+	return interpreter.result
 }
 
 func (interpreter *HyperAssertionInterpreter[T]) UniversalHyperAssertion(assertion UniversalHyperAssertion[T]) {
-	permutations := iterx.Permutations(assertion.size, len(interpreter.elements))
-	for permutation := range iterx.Map(interpreter.elements, permutations) {
-		for idx := 0; idx < assertion.size; idx++ {
-			interpreter.assignments[assertion.offset + idx] = permutation[idx]
+	seen := 0
+	if assertion.offset == 0 && settled(assertion.body) {
+		seen = min(interpreter.seen, len(interpreter.elements))
+	}
+	assignments := assertion.assignments(seen, len(interpreter.elements))
+	if assertion.join != nil {
+		assignments = interpreter.joined(assertion.join, assertion.size, assertion.symmetry, seen)
+	} else if assertion.order != nil {
+		assignments = interpreter.ordered(assertion.order, assertion.size, assertion.symmetry, seen)
+	}
+	if !interpreter.quantify(assertion.offset, assertion.size, assignments, assertion.body, LiftedBoolean.IsFalse) {
+		// Elements yet to come may still violate the universal.
+		interpreter.result = LiftedUnknown
+	}
+}
+
+func (interpreter *HyperAssertionInterpreter[T]) ExistentialHyperAssertion(assertion ExistentialHyperAssertion[T]) {
+	assignments := iterx.Permutations(assertion.size, len(interpreter.elements))
+	if assertion.join != nil {
+		assignments = interpreter.joined(assertion.join, assertion.size, Asymmetric, 0)
+	} else if assertion.order != nil {
+		assignments = interpreter.ordered(assertion.order, assertion.size, Asymmetric, 0)
+	}
+	if !interpreter.quantify(assertion.offset, assertion.size, assignments, assertion.body, LiftedBoolean.IsTrue) {
+		// Elements yet to come may still witness the existential.
+		interpreter.result = LiftedUnknown
+	}
+}
+
+// quantify evaluates the body for the assignments of the elements to the
+// variables at the offset in order until the result of an assignment decides
+// the quantifier and reports whether any did. The assignment is the witness
+// unless the body has a witness of its own. The assignments of an outermost
+// quantifier are partitioned across the workers.
+func (interpreter *HyperAssertionInterpreter[T]) quantify(
+	offset, size int, assignments iter.Seq[[]int], body HyperAssertion[T], decides func(LiftedBoolean) bool,
+) bool {
+	evaluate := func(interpreter *HyperAssertionInterpreter[T], permutation []int) bool {
+		for idx := 0; idx < size; idx++ {
+			interpreter.assignments[offset+idx] = interpreter.elements[permutation[idx]]
+			interpreter.indices[offset+idx] = permutation[idx]
 		}
 
-		assertion.body.Accept(interpreter)
-		if !interpreter.satisfied {
-			return
+		interpreter.witness = nil
+		body.Accept(interpreter)
+		if !decides(interpreter.result) {
+			return false
 		}
+		if interpreter.witness == nil {
+			interpreter.witness = slices.Clone(interpreter.indices[:offset+size])
+		}
+		return true
 	}
+
+	if interpreter.parallel(offset) {
+		chunks := func(yield func(func(worker *HyperAssertionInterpreter[T]) bool) bool) {
+			for assigned := range batches(assignments) {
+				chunk := func(worker *HyperAssertionInterpreter[T]) bool {
+					for _, permutation := range assigned {
+						if evaluate(worker, permutation) {
+							return true
+						}
+					}
+					return false
+				}
+				if !yield(chunk) {
+					return
+				}
+			}
+		}
+		return interpreter.partition(chunks)
+	}
+
+	for permutation := range assignments {
+		if evaluate(interpreter, permutation) {
+			return true
+		}
+	}
+	interpreter.witness = nil
+	return false
 }
 
-func (interpreter *HyperAssertionInterpreter[T]) ExistentialHyperAssertion(assertion ExistentialHyperAssertion[T]) {
-	permutations := iterx.Permutations(assertion.size, len(interpreter.elements))
-	for permutation := range iterx.Map(interpreter.elements, permutations) {
-		for idx := 0; idx < assertion.size; idx++ {
-			interpreter.assignments[assertion.offset + idx] = permutation[idx]
+// joined returns the indices of the elements assigned to the variables of a
+// quantifier for every assignment of them with equal keys of the join which
+// the symmetry of its body admits and which assigns an element after the seen
+// ones. The elements assigned to the right variable are looked up in the index
+// of the join by the key of the left variable.
+func (interpreter *HyperAssertionInterpreter[T]) joined(join *Join[T], size int, symmetry Symmetry, seen int) iter.Seq[[]int] {
+	index := interpreter.index(join)
+	pairs := func(yield func(int, int) bool) {
+		for left, element := range interpreter.elements {
+			key, ok := join.leftKey(element)
+			if !ok {
+				continue
+			}
+			rights := index[key]
+			if size == 2 && left < seen {
+				// The index is ordered by position.
+				first, _ := slices.BinarySearch(rights, seen)
+				rights = rights[first:]
+			}
+			for _, right := range rights {
+				if !yield(left, right) {
+					return
+				}
+			}
+		}
+	}
+	return interpreter.paired(join.left, join.right, size, pairs, symmetry, seen)
+}
+
+// ordered returns the indices of the elements assigned to the variables of a
+// quantifier for every assignment of them ordered by the order which the
+// symmetry of its body admits and which assigns an element after the seen
+// ones. The elements assigned to the right variable are those of the span of
+// the order after the element assigned to the left variable.
+func (interpreter *HyperAssertionInterpreter[T]) ordered(order *Order, size int, symmetry Symmetry, seen int) iter.Seq[[]int] {
+	elements := len(interpreter.elements)
+	pairs := func(yield func(int, int) bool) {
+		for left := range elements {
+			from, to := order.span(left, interpreter.times, elements)
+			if size == 2 && left < seen {
+				from = max(from, seen)
+			}
+			for right := from; right < to; right++ {
+				if !yield(left, right) {
+					return
+				}
+			}
+		}
+	}
+	return interpreter.paired(order.left, order.right, size, pairs, symmetry, seen)
+}
+
+// paired returns the indices of the elements assigned to the variables of a
+// quantifier for every pair of the elements assigned to its left and right
+// variable and every assignment of the elements to its other variables which
+// the symmetry of its body admits and which assigns an element after the seen
+// ones.
+func (interpreter *HyperAssertionInterpreter[T]) paired(
+	left, right, size int, pairs iter.Seq2[int, int], symmetry Symmetry, seen int,
+) iter.Seq[[]int] {
+	others := iterx.Permutations(size-2, len(interpreter.elements))
+	if size == 2 {
+		others = func(yield func([]int) bool) { yield(nil) }
+	}
+
+	return func(yield func([]int) bool) {
+		for leftElement, rightElement := range pairs {
+			for other := range others {
+				assignment := make([]int, size)
+				for idx := range assignment {
+					switch idx {
+					case left:
+						assignment[idx] = leftElement
+					case right:
+						assignment[idx] = rightElement
+					default:
+						assignment[idx], other = other[0], other[1:]
+					}
+				}
+				if symmetry.admits(assignment) && slices.Max(assignment) >= seen && !yield(assignment) {
+					return
+				}
+			}
 		}
+	}
+}
+
+// index returns the elements by their keys of the right variable of the join.
+// The index of a history is maintained by the join across evaluations such
+// that only the elements added to it since are indexed.
+func (interpreter *HyperAssertionInterpreter[T]) index(join *Join[T]) map[any][]int {
+	if index, exists := interpreter.indexes[join]; exists {
+		return index
+	}
 
-		assertion.body.Accept(interpreter)
-		if interpreter.satisfied {
-			return
+	index := make(map[any][]int)
+	if interpreter.history {
+		history := join.history
+		history.mutex.Lock()
+		if history.index == nil || history.seen > len(interpreter.elements) {
+			history.seen = 0
+			history.index = index
 		}
+		join.add(history.index, interpreter.elements, history.seen)
+		history.seen = len(interpreter.elements)
+		index = history.index
+		history.mutex.Unlock()
+	} else {
+		join.add(index, interpreter.elements, 0)
+	}
+
+	if interpreter.indexes == nil {
+		interpreter.indexes = make(map[*Join[T]]map[any][]int)
 	}
+	interpreter.indexes[join] = index
+	return index
 }
 
 func (interpreter *HyperAssertionInterpreter[T]) PredicateHyperAssertion(assertion PredicateHyperAssertion[T]) {
-	interpreter.satisfied = assertion.predicate(interpreter.assignments)
+	if assertion.temporal != nil {
+		timeline := Timeline{indices: interpreter.indices, times: interpreter.times}
+		interpreter.result = LiftBoolean(assertion.temporal(interpreter.assignments, timeline))
+		return
+	}
+	interpreter.result = LiftBoolean(assertion.predicate(interpreter.assignments))
 }
 
 func (interpreter *HyperAssertionInterpreter[T]) TrueHyperAssertion(assertion TrueHyperAssertion[T]) {
-	interpreter.satisfied = true
+	interpreter.result = LiftedTrue
+}
+
+// CompiledHyperAssertion evaluates the compiled assertion. The positions of
+// the elements assigned to its outermost variable are partitioned across the
+// workers.
+func (interpreter *HyperAssertionInterpreter[T]) CompiledHyperAssertion(assertion CompiledHyperAssertion[T]) {
+	timeline := Timeline{times: interpreter.times, seen: interpreter.seen}
+	if !interpreter.parallel(0) {
+		interpreter.result, interpreter.witness = assertion.evaluate(interpreter.elements, timeline, 0, len(interpreter.elements))
+		return
+	}
+
+	chunks := func(yield func(func(worker *HyperAssertionInterpreter[T]) bool) bool) {
+		for from, to := range ranges(len(interpreter.elements), interpreter.workers) {
+			chunk := func(worker *HyperAssertionInterpreter[T]) bool {
+				worker.result, worker.witness = assertion.evaluate(worker.elements, timeline, from, to)
+				return worker.witness != nil
+			}
+			if !yield(chunk) {
+				return
+			}
+		}
+	}
+	if !interpreter.partition(chunks) {
+		interpreter.result, interpreter.witness = LiftedUnknown, nil
+	}
+}
+
+// Witness returns the positions of the elements assigned to the variables by
+// the first assignment deciding the assertion, e.g. the elements violating a
+// universal, or nil if no assignment decided it. The witness of a body deciding
+// its quantifier includes the variables of the body.
+func (interpreter *HyperAssertionInterpreter[T]) Witness() []int {
+	return interpreter.witness
+}
+
+func (interpreter *HyperAssertionInterpreter[T]) AggregateHyperAssertion(assertion AggregateHyperAssertion[T]) {
+	aggregator, from := assertion.aggregator(), 0
+	if interpreter.history && assertion.offset == 0 {
+		// Only aggregates not nested in quantifiers are independent of the
+		// assignments and can resume the aggregate of the history.
+		history := assertion.history
+		history.mutex.Lock()
+		defer history.mutex.Unlock()
+
+		if history.aggregator == nil || history.seen > len(interpreter.elements) {
+			history.seen = 0
+			history.aggregator = assertion.aggregator()
+		}
+		aggregator, from = history.aggregator, history.seen
+		history.seen = len(interpreter.elements)
+	}
+
+	for idx := from; idx < len(interpreter.elements); idx++ {
+		interpreter.assignments[assertion.offset] = interpreter.elements[idx]
+		interpreter.indices[assertion.offset] = idx
+		if value, ok := assertion.value(interpreter.assignments); ok {
+			aggregator.Add(value)
+		}
+	}
+
+	aggregate := aggregator.Value()
+	estimator, statistical := aggregator.(Estimator)
+	if !statistical {
+		// Exact aggregates are of the elements so far which more elements
+		// may still violate, and the aggregate of no elements is undefined.
+		interpreter.result = LiftedUnknown
+		if !math.IsNaN(aggregate) && !assertion.compare(interpreter.assignments, aggregate) {
+			interpreter.result = LiftedFalse
+		}
+		return
+	}
+
+	// Estimates are only rejected once their interval breaches the bound.
+	lower, upper := estimator.Interval()
+	result := Resolve(lower, upper, func(value float64) bool {
+		return assertion.compare(interpreter.assignments, value)
+	})
+	interpreter.result = result
+	if assertion.offset == 0 {
+		interpreter.estimates = append(interpreter.estimates, Estimate{
+			Value: aggregate, Lower: lower, Upper: upper,
+			Confidence: estimator.Confidence(), Result: result,
+		})
+	}
+}
+
+// Estimates returns the estimates of the statistical assertions, not nested in
+// quantifiers, of the last evaluated assertion.
+func (interpreter *HyperAssertionInterpreter[T]) Estimates() []Estimate {
+	return interpreter.estimates
 }
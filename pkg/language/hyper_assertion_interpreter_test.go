@@ -0,0 +1,197 @@
+package language
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpreterMonitorability(t *testing.T) {
+	type execution struct {
+		input, output int
+	}
+
+	// Every execution is deterministic.
+	deterministic := NewUniversalHyperAssertion[execution](0, 2, NewPredicateHyperAssertion(
+		func(assignments []execution) bool {
+			e0, e1 := assignments[0], assignments[1]
+			return e0.input != e1.input || e0.output == e1.output
+		},
+	))
+	// Some execution outputs zero.
+	zero := NewExistentialHyperAssertion[execution](0, 1, NewPredicateHyperAssertion(
+		func(assignments []execution) bool {
+			return assignments[0].output == 0
+		},
+	))
+	// Every execution has an execution with its output as input.
+	chained := NewUniversalHyperAssertion[execution](0, 1, NewExistentialHyperAssertion[execution](1, 1, NewPredicateHyperAssertion(
+		func(assignments []execution) bool {
+			return assignments[1].input == assignments[0].output
+		},
+	)))
+
+	tests := []struct {
+		description string
+		assertion   HyperAssertion[execution]
+		executions  []execution
+		result      LiftedBoolean
+	}{
+		{
+			description: "universal of no executions",
+			assertion:   deterministic,
+			result:      LiftedUnknown,
+		},
+		{
+			description: "universal not yet violated",
+			assertion:   deterministic,
+			executions:  []execution{{1, 2}, {2, 3}, {1, 2}},
+			result:      LiftedUnknown,
+		},
+		{
+			description: "universal violated",
+			assertion:   deterministic,
+			executions:  []execution{{1, 2}, {2, 3}, {1, 3}},
+			result:      LiftedFalse,
+		},
+		{
+			description: "existential of no executions",
+			assertion:   zero,
+			result:      LiftedUnknown,
+		},
+		{
+			description: "existential not yet witnessed",
+			assertion:   zero,
+			executions:  []execution{{1, 2}, {2, 3}},
+			result:      LiftedUnknown,
+		},
+		{
+			description: "existential witnessed",
+			assertion:   zero,
+			executions:  []execution{{1, 2}, {2, 0}},
+			result:      LiftedTrue,
+		},
+		{
+			description: "alternation is never definite",
+			assertion:   chained,
+			executions:  []execution{{1, 2}, {2, 1}},
+			result:      LiftedUnknown,
+		},
+		{
+			description: "predicate",
+			assertion: NewPredicateHyperAssertion(func([]execution) bool {
+				return false
+			}),
+			result: LiftedFalse,
+		},
+		{
+			description: "true",
+			assertion:   NewTrueHyperAssertion[execution](),
+			result:      LiftedTrue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			interpreter := NewHyperAssertionInterpreter[execution]()
+			assert.Equal(t, tt.result, interpreter.Satisfies(tt.assertion, tt.executions))
+		})
+	}
+}
+
+// ioExecution is an execution of a function of an input to an output.
+type ioExecution struct {
+	input, output int
+}
+
+// compiledDeterminism is the monitor compiled from the guarantee
+// forall e0 e1. e0.input != e1.input || e0.output == e1.output.
+func compiledDeterminism() *CompiledHyperAssertion[ioExecution] {
+	return NewCompiledHyperAssertion(func(elements []ioExecution, _ Timeline, from, to int) (LiftedBoolean, []int) {
+		for i0 := from; i0 < to; i0++ {
+			e0 := elements[i0]
+			for i1 := i0 + 1; i1 < len(elements); i1++ {
+				e1 := elements[i1]
+				if !(e0.input != e1.input || e0.output == e1.output) {
+					return LiftedFalse, []int{i0, i1}
+				}
+			}
+		}
+		return LiftedUnknown, nil
+	})
+}
+
+func TestCompiledHyperAssertion(t *testing.T) {
+	interpreter := NewParallelHyperAssertionInterpreter[ioExecution](1)
+	deterministic := compiledDeterminism()
+	assert.Equal(t, LiftedUnknown, interpreter.Satisfies(deterministic, []ioExecution{{1, 2}, {2, 3}, {1, 2}}))
+	assert.Equal(t, LiftedFalse, interpreter.Satisfies(deterministic, []ioExecution{{1, 2}, {2, 3}, {1, 3}}))
+
+	// forall e0 e1. !next(e0, e1) || within(e0, e1, time.Second)
+	responsive := NewCompiledHyperAssertion(func(elements []ioExecution, history Timeline, from, to int) (LiftedBoolean, []int) {
+		var indices [2]int
+		timeline := history.Assigned(indices[:])
+		for i0 := from; i0 < to; i0++ {
+			indices[0] = i0
+			for i1 := range elements {
+				indices[1] = i1
+				if !(!timeline.Next(0, 1) || timeline.Within(0, 1, time.Second)) {
+					return LiftedFalse, []int{i0, i1}
+				}
+			}
+		}
+		return LiftedUnknown, nil
+	})
+	executions := []ioExecution{{1, 2}, {2, 3}, {3, 4}}
+	now := time.Now()
+	assert.Equal(t, LiftedUnknown, interpreter.SatisfiesTimed(responsive, executions, []time.Time{now, now, now.Add(time.Second)}))
+	assert.Equal(t, LiftedFalse, interpreter.SatisfiesTimed(responsive, executions, []time.Time{now, now, now.Add(time.Minute)}))
+	assert.Equal(t, []int{1, 2}, interpreter.Witness())
+
+	// Compiled assertions allocate nothing to be checked.
+	times := []time.Time{now, now, now}
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		interpreter.Satisfies(deterministic, executions)
+		interpreter.SatisfiesTimed(responsive, executions, times)
+	}))
+}
+
+// benchmarkExecutions are executions of which every two with the same input
+// have the same output.
+func benchmarkExecutions(size int) []ioExecution {
+	executions := make([]ioExecution, size)
+	for idx := range executions {
+		executions[idx] = ioExecution{idx % 10, idx % 10 * 2}
+	}
+	return executions
+}
+
+func BenchmarkInterpretedUniversal(b *testing.B) {
+	deterministic := NewSymmetricUniversalHyperAssertion(0, 2, Symmetric|Reflexive, NewPredicateHyperAssertion(
+		func(assignments []ioExecution) bool {
+			e0, e1 := assignments[0], assignments[1]
+			return e0.input != e1.input || e0.output == e1.output
+		},
+	))
+	executions := benchmarkExecutions(100)
+	interpreter := NewHyperAssertionInterpreter[ioExecution]()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		interpreter.Satisfies(deterministic, executions)
+	}
+}
+
+func BenchmarkCompiledUniversal(b *testing.B) {
+	deterministic := compiledDeterminism()
+	executions := benchmarkExecutions(100)
+	interpreter := NewHyperAssertionInterpreter[ioExecution]()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		interpreter.Satisfies(deterministic, executions)
+	}
+}
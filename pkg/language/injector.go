@@ -2,11 +2,13 @@ package language
 
 import (
 	"fmt"
+	"go/constant"
 	"go/parser"
 	"go/token"
 	"iter"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/dave/dst"
 	"github.com/dave/dst/decorator"
@@ -14,7 +16,20 @@ import (
 	"github.com/hyperproperties/sopher/pkg/filesx"
 )
 
-type Injector struct{}
+type Injector struct {
+	// observe reports guarantee violations to the fault runtime instead of
+	// panicking such that fault injection campaigns can continue.
+	observe bool
+	// sidecars are the contracts of sidecar files by the fully-qualified names
+	// of their functions.
+	sidecars map[string]SidecarContract
+	// inherited are the contracts of interface methods by the fully-qualified
+	// names of the methods implementing them.
+	inherited map[string][]Contract
+	// composites are the composite contracts of the package being instrumented
+	// by the names of the functions they compose.
+	composites map[string][]composite
+}
 
 func NewGoInjector() Injector {
 	return Injector{}
@@ -66,6 +81,9 @@ func (injector Injector) InputFields(function *dst.FuncDecl) (fields []*dst.Fiel
 }
 
 func (injector Injector) HasNamedOutputs(function *dst.FuncDecl) bool {
+	if function.Type.Results == nil {
+		return false
+	}
 	for _, output := range function.Type.Results.List {
 		if len(output.Names) > 0 {
 			return true
@@ -75,6 +93,9 @@ func (injector Injector) HasNamedOutputs(function *dst.FuncDecl) bool {
 }
 
 func (injector Injector) OutputFields(function *dst.FuncDecl) (fields []*dst.Field) {
+	if function.Type.Results == nil {
+		return nil
+	}
 	for idx, output := range function.Type.Results.List {
 		if len(output.Names) > 0 {
 			fields = append(fields, dst.Clone(output).(*dst.Field))
@@ -92,8 +113,40 @@ func (injector Injector) OutputFields(function *dst.FuncDecl) (fields []*dst.Fie
 	return fields
 }
 
+// receiverName returns the name of the receiver type of the method.
+func receiverName(function *dst.FuncDecl) (string, bool) {
+	if function.Recv == nil || len(function.Recv.List) == 0 {
+		return "", false
+	}
+
+	receiver := function.Recv.List[0].Type
+	if star, ok := receiver.(*dst.StarExpr); ok {
+		receiver = star.X
+	}
+	switch cast := receiver.(type) {
+	case *dst.IndexExpr:
+		receiver = cast.X
+	case *dst.IndexListExpr:
+		receiver = cast.X
+	}
+	if identifier, ok := receiver.(*dst.Ident); ok {
+		return identifier.Name, true
+	}
+	return "", false
+}
+
+// Name returns the prefix of the declarations generated for the function.
+// Methods are prefixed by their receiver type as the implementations of an
+// interface method may be declared in the same package.
+func (injector Injector) Name(function *dst.FuncDecl) string {
+	if receiver, ok := receiverName(function); ok {
+		return receiver + "_" + function.Name.Name
+	}
+	return function.Name.Name
+}
+
 func (injector Injector) Model(function *dst.FuncDecl) (string, *dst.GenDecl) {
-	name := function.Name.Name
+	name := injector.Name(function)
 
 	fields := make([]*dst.Field, 0)
 	fields = append(fields, injector.InputFields(function)...)
@@ -119,34 +172,72 @@ func (injector Injector) Model(function *dst.FuncDecl) (string, *dst.GenDecl) {
 }
 
 func (injector Injector) Contract(model string, function *dst.FuncDecl) (string, *dst.GenDecl) {
-	name := function.Name.Name
+	name := injector.Name(function)
 
 	comments := function.Decs.NodeDecs.Start
 	parser := NewParser(LexDocStrings(comments))
 	contract := parser.Parse()
 
-	assumptionList := make([]dst.Expr, len(contract.regions[0].assumptions))
-	guaranteeList := make([]dst.Expr, len(contract.regions[0].guarantees))
+	constructor := injector.Constructor(
+		model, contract.regions[0].assumptions, contract.regions[0].guarantees,
+	)
 
-	monitors := NewGoMonitorFactory("sopher", model)
-	for idx, assumption := range contract.regions[0].assumptions {
+	contractName := name + "_Contract"
+
+	return contractName, &dst.GenDecl{
+		Tok: token.VAR,
+		Specs: []dst.Spec{
+			&dst.ValueSpec{
+				Names: []*dst.Ident{
+					dst.NewIdent(contractName),
+				},
+				Type: &dst.IndexExpr{
+					X: &dst.SelectorExpr{
+						X:   dst.NewIdent("sopher"),
+						Sel: dst.NewIdent("AGHyperContract"),
+					},
+					Index: dst.NewIdent(model),
+				},
+				Values: []dst.Expr{constructor},
+			},
+		},
+	}
+}
+
+func (injector Injector) Constructor(model string, assumptions, guarantees []Node) *dst.CallExpr {
+	return injector.constructor(
+		NewGoMonitorFactory("sopher", model),
+		&dst.SelectorExpr{
+			Sel: dst.NewIdent("NewAGHyperContract"),
+			X:   dst.NewIdent("sopher"),
+		},
+		model, assumptions, guarantees,
+	)
+}
+
+// constructor calls the constructor of a contract with the monitors of its
+// obligations.
+func (injector Injector) constructor(
+	monitors MonitorFactory, constructor dst.Expr, model string, assumptions, guarantees []Node,
+) *dst.CallExpr {
+	assumptionList := make([]dst.Expr, len(assumptions))
+	guaranteeList := make([]dst.Expr, len(guarantees))
+
+	for idx, assumption := range assumptions {
 		assumptionList[idx] = monitors.Create(assumption)
 	}
-	for idx, guarantee := range contract.regions[0].guarantees {
+	for idx, guarantee := range guarantees {
 		guaranteeList[idx] = monitors.Create(guarantee)
 	}
 
-	constructor := &dst.CallExpr{
-		Fun: &dst.SelectorExpr{
-			Sel: dst.NewIdent("NewAGContract"),
-			X:   dst.NewIdent("sopher"),
-		},
+	return &dst.CallExpr{
+		Fun: constructor,
 		Args: []dst.Expr{
 			&dst.CompositeLit{
 				Type: &dst.ArrayType{
 					Elt: &dst.IndexExpr{
 						X: &dst.SelectorExpr{
-							Sel: dst.NewIdent("IncrementalMonitor"),
+							Sel: dst.NewIdent("HyperAssertion"),
 							X:   dst.NewIdent("sopher"),
 						},
 						Index: dst.NewIdent(model),
@@ -158,7 +249,7 @@ func (injector Injector) Contract(model string, function *dst.FuncDecl) (string,
 				Type: &dst.ArrayType{
 					Elt: &dst.IndexExpr{
 						X: &dst.SelectorExpr{
-							Sel: dst.NewIdent("IncrementalMonitor"),
+							Sel: dst.NewIdent("HyperAssertion"),
 							X:   dst.NewIdent("sopher"),
 						},
 						Index: dst.NewIdent(model),
@@ -168,27 +259,6 @@ func (injector Injector) Contract(model string, function *dst.FuncDecl) (string,
 			},
 		},
 	}
-
-	contractName := name + "_Contract"
-
-	return contractName, &dst.GenDecl{
-		Tok: token.VAR,
-		Specs: []dst.Spec{
-			&dst.ValueSpec{
-				Names: []*dst.Ident{
-					dst.NewIdent(contractName),
-				},
-				Type: &dst.IndexExpr{
-					X: &dst.SelectorExpr{
-						X:   dst.NewIdent("sopher"),
-						Sel: dst.NewIdent("AGContract"),
-					},
-					Index: dst.NewIdent(model),
-				},
-				Values: []dst.Expr{constructor},
-			},
-		},
-	}
 }
 
 func (injector Injector) Wrap(function *dst.FuncDecl) *dst.AssignStmt {
@@ -250,7 +320,14 @@ func (injector Injector) ConstructModel(model string, function *dst.FuncDecl) *d
 	}
 }
 
+// Check panics with the violation of the obligations if the execution does not
+// satisfy them. The name is either "Assume" or "Guarantee".
 func (injector Injector) Check(name string, contractName string) *dst.IfStmt {
+	violation := "GuaranteeViolation"
+	if name == "Assume" {
+		violation = "AssumptionViolation"
+	}
+
 	return &dst.IfStmt{
 		Cond: &dst.CallExpr{
 			Fun: &dst.SelectorExpr{
@@ -272,14 +349,47 @@ func (injector Injector) Check(name string, contractName string) *dst.IfStmt {
 					X: &dst.CallExpr{
 						Fun: dst.NewIdent("panic"),
 						Args: []dst.Expr{
-							&dst.BasicLit{
-								Kind:  token.STRING,
-								Value: "\"\"",
+							&dst.CallExpr{
+								Fun: &dst.SelectorExpr{
+									X:   dst.NewIdent(contractName),
+									Sel: dst.NewIdent(violation),
+								},
+								Args: []dst.Expr{
+									dst.NewIdent("execution"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (injector Injector) Observe(contractName string) *dst.ExprStmt {
+	return &dst.ExprStmt{
+		X: &dst.CallExpr{
+			Fun: &dst.SelectorExpr{
+				X:   dst.NewIdent("faults"),
+				Sel: dst.NewIdent("Observe"),
+			},
+			Args: []dst.Expr{
+				&dst.CallExpr{
+					Fun: &dst.SelectorExpr{
+						X: &dst.CallExpr{
+							Fun: &dst.SelectorExpr{
+								X:   dst.NewIdent(contractName),
+								Sel: dst.NewIdent("GuaranteeViolation"),
+							},
+							Args: []dst.Expr{
+								dst.NewIdent("execution"),
 							},
 						},
+						Sel: dst.NewIdent("Names"),
 					},
 				},
 			},
+			Ellipsis: true,
 		},
 	}
 }
@@ -350,20 +460,131 @@ func (injector Injector) Return(function *dst.FuncDecl) *dst.ReturnStmt {
 	}
 }
 
+// Interesting returns an init function registering the literals with quick
+// such that generation is biased towards them, or false if none fits a type.
+// Every literal is converted to the type it fits and the others are left out.
+func (injector Injector) Interesting(literals []constant.Value) (*dst.FuncDecl, bool) {
+	var arguments []dst.Expr
+	for _, literal := range literals {
+		if argument, ok := typedLiteral(literal); ok {
+			arguments = append(arguments, argument)
+		}
+	}
+	if len(arguments) == 0 {
+		return nil, false
+	}
+
+	return &dst.FuncDecl{
+		Name: dst.NewIdent("init"),
+		Type: &dst.FuncType{},
+		Body: &dst.BlockStmt{
+			List: []dst.Stmt{
+				&dst.ExprStmt{
+					X: &dst.CallExpr{
+						Fun: &dst.SelectorExpr{
+							X:   dst.NewIdent("quick"),
+							Sel: dst.NewIdent("Interesting"),
+						},
+						Args: arguments,
+					},
+				},
+			},
+		},
+	}, true
+}
+
+// InterestingTest returns a test file of the package of the file registering
+// the literals of its contracted functions with quick, or false if they have
+// none. The literals are registered by a test file instead of the instrumented
+// source such that the source does not depend on quick. The file must not be
+// instrumented yet such that only the literals of its own source are harvested.
+func (injector Injector) InterestingTest(file *dst.File) (*dst.File, bool) {
+	var literals []constant.Value
+	seen := make(map[string]struct{})
+	for _, decl := range file.Decls {
+		function, ok := decl.(*dst.FuncDecl)
+		if !ok {
+			continue
+		}
+		contract, ok := docContract(function)
+		if !ok {
+			continue
+		}
+		for _, literal := range Literals(file, function, contract) {
+			key := literal.Kind().String() + literal.ExactString()
+			if _, exists := seen[key]; !exists {
+				seen[key] = struct{}{}
+				literals = append(literals, literal)
+			}
+		}
+	}
+
+	interesting, ok := injector.Interesting(literals)
+	if !ok {
+		return nil, false
+	}
+
+	test := &dst.File{
+		Name:  dst.NewIdent(file.Name.Name),
+		Decls: []dst.Decl{interesting},
+	}
+	injector.Imports(test, map[string]string{"quick": quickPath})
+	return test, true
+}
+
+// interestingPath returns the path of the test file registering the literals
+// of the file at the path.
+func interestingPath(path string) string {
+	return strings.TrimSuffix(path, ".go") + "_interesting_sopher_test.go"
+}
+
+// Inject instruments the functions of the file with contracts or composed by
+// composite contracts. Functions with a composite contract declare it and every
+// composed function records its executions in it.
 func (injector Injector) Inject(file *dst.File) {
+	// Files declaring contracts import the runtime and files observing them
+	// import the fault runtime.
+	declares, observes := false, false
+
+	composites := injector.composites
+	if composites == nil {
+		composites = compose(file)
+	}
+
 	dstutil.Apply(file, nil, func(cursor *dstutil.Cursor) bool {
 		switch cast := cursor.Node().(type) {
 		case *dst.FuncDecl:
-			comments := cast.Decs.NodeDecs.Start
-			if len(comments) == 0 {
+			parsed, contracted := docContract(cast)
+			var composed []composite
+			if cast.Recv == nil {
+				composed = composites[cast.Name.Name]
+			}
+			if !contracted && len(composed) == 0 {
 				return true
 			}
+			own := contracted && len(parsed.composition) == 0
+			declares = declares || contracted
+			observes = observes || (injector.observe && (own || len(composed) > 0))
 
 			modelName, model := injector.Model(cast)
 			cursor.InsertBefore(model)
 
-			contractName, contract := injector.Contract(modelName, cast)
-			cursor.InsertBefore(contract)
+			var contractName string
+			if own {
+				var contract *dst.GenDecl
+				contractName, contract = injector.Contract(modelName, cast)
+				cursor.InsertBefore(contract)
+			}
+
+			for _, composite := range composed {
+				if composite.function != cast.Name.Name {
+					continue
+				}
+				_, declarations := injector.Composite(composite)
+				for _, declaration := range declarations {
+					cursor.InsertBefore(declaration)
+				}
+			}
 
 			body := make([]dst.Stmt, 0)
 
@@ -373,18 +594,41 @@ func (injector Injector) Inject(file *dst.File) {
 			modelConstruction := injector.ConstructModel(modelName, cast)
 			body = append(body, modelConstruction)
 
-			assumptionCheck := injector.Check("Assume", contractName)
-			body = append(body, assumptionCheck)
+			if own {
+				assumptionCheck := injector.Check("Assume", contractName)
+				body = append(body, assumptionCheck)
+			}
+
+			for _, composite := range composed {
+				body = append(body, injector.CompositeCheck("Assume", composite.function, cast.Name.Name))
+			}
 
 			wrapCall := injector.CallWrap(cast)
-			body = append(body, wrapCall)
+			if len(wrapCall.Lhs) == 0 {
+				// Functions without outputs call the wrapped body as a statement.
+				body = append(body, &dst.ExprStmt{X: wrapCall.Rhs[0]})
+			} else {
+				body = append(body, wrapCall)
+			}
 
 			for _, update := range injector.Updates(cast) {
 				body = append(body, update)
 			}
 
-			guaranteeCheck := injector.Check("Guarantee", contractName)
-			body = append(body, guaranteeCheck)
+			if own && injector.observe {
+				body = append(body, injector.Observe(contractName))
+			} else if own {
+				guaranteeCheck := injector.Check("Guarantee", contractName)
+				body = append(body, guaranteeCheck)
+			}
+
+			for _, composite := range composed {
+				if injector.observe {
+					body = append(body, injector.CompositeObserve(composite.function, cast.Name.Name))
+				} else {
+					body = append(body, injector.CompositeCheck("Guarantee", composite.function, cast.Name.Name))
+				}
+			}
 
 			returnStmt := injector.Return(cast)
 			body = append(body, returnStmt)
@@ -404,15 +648,29 @@ func (injector Injector) Inject(file *dst.File) {
 		return true
 	})
 
-	injector.Imports(file, map[string]string{
-		"sopher": "github.com/hyperproperties/sopher/pkg/language",
-	})
+	imports := make(map[string]string)
+	if declares {
+		imports["sopher"] = "github.com/hyperproperties/sopher/pkg/language"
+	}
+
+	if observes {
+		imports["faults"] = faultsPath
+	}
+
+	if len(imports) > 0 {
+		injector.Imports(file, imports)
+	}
 }
 
+// Files instruments the files in place keeping the originals next to them. The
+// files of a package are parsed before any is instrumented such that composite
+// contracts compose functions across them.
 func (injector Injector) Files(files iter.Seq[string]) {
 	fset := token.NewFileSet()
 	decor := decorator.NewDecorator(fset)
 
+	var paths []string
+	parsed := make(map[string]*dst.File)
 	for path := range files {
 		// Read the file
 		content, err := os.ReadFile(path)
@@ -421,11 +679,27 @@ func (injector Injector) Files(files iter.Seq[string]) {
 		}
 
 		// Parse the decorated syntax tree.
-		dst, err := decor.ParseFile(filepath.Base(path), content, parser.ParseComments)
+		file, err := decor.ParseFile(filepath.Base(path), content, parser.ParseComments)
 		if err != nil {
 			continue
 		}
 
+		injector.attach(path, file)
+		paths = append(paths, path)
+		parsed[path] = file
+	}
+
+	for _, path := range paths {
+		dst := parsed[path]
+
+		// The literals are harvested before the file is instrumented.
+		if test, ok := injector.InterestingTest(dst); ok {
+			if err := writeGenerated(interestingPath(path), test); err != nil {
+				panic(err)
+			}
+		}
+
+		injector.Compose(siblings(path, paths, parsed)...)
 		injector.Inject(dst)
 
 		// Move original file to keep it.
@@ -442,8 +716,23 @@ func (injector Injector) Files(files iter.Seq[string]) {
 	}
 }
 
+// siblings returns the parsed files in the directory of the path.
+func siblings(path string, paths []string, parsed map[string]*dst.File) (files []*dst.File) {
+	for _, other := range paths {
+		if filepath.Dir(other) == filepath.Dir(path) {
+			files = append(files, parsed[other])
+		}
+	}
+	return files
+}
+
+// Restore moves the originals kept by Files back in place of the instrumented
+// files and removes the test files registering their literals.
 func (injector Injector) Restore(files iter.Seq[string]) {
 	for path := range files {
-		filesx.Move(path, path+"sopher")
+		if filesx.Exists(path + "-sopher") {
+			filesx.Move(path+"-sopher", path)
+		}
+		removeGenerated(interestingPath(path))
 	}
 }
@@ -0,0 +1,259 @@
+package language
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// interfaceMethod is a method of an interface with a contract in its doc comment.
+type interfaceMethod struct {
+	iface    *types.Interface
+	method   *types.Func
+	contract Contract
+}
+
+// Interfaces inherits the contracts in the doc comments of interface methods
+// onto the methods of every type implementing the interfaces. The interfaces
+// and their implementations are those of the packages matching the patterns in
+// the directory, e.g. "./..." in the root of a module. Inherited contracts are
+// merged with the contracts of the methods when they are attached.
+func (injector *Injector) Interfaces(directory string, patterns ...string) error {
+	if injector.inherited == nil {
+		injector.inherited = make(map[string][]Contract)
+	}
+
+	config := &packages.Config{
+		Mode: packages.NeedName | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo |
+			packages.NeedImports | packages.NeedDeps,
+		Dir: directory,
+	}
+	loaded, err := packages.Load(config, patterns...)
+	if err != nil {
+		return err
+	}
+
+	var methods []interfaceMethod
+	for _, pkg := range loaded {
+		found, err := interfaceMethods(pkg)
+		if err != nil {
+			return err
+		}
+		methods = append(methods, found...)
+	}
+
+	for _, pkg := range loaded {
+		if pkg.Types == nil {
+			continue
+		}
+
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || typeName.IsAlias() {
+				continue
+			}
+			named, ok := typeName.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 || types.IsInterface(named) {
+				continue
+			}
+
+			for _, method := range methods {
+				if !types.Implements(named, method.iface) && !types.Implements(types.NewPointer(named), method.iface) {
+					continue
+				}
+
+				// Promoted methods inherit the contract through the type declaring them.
+				object, _, _ := types.LookupFieldOrMethod(named, true, typeName.Pkg(), method.method.Name())
+				implementation, ok := object.(*types.Func)
+				if !ok || receiver(implementation) != named {
+					continue
+				}
+
+				qualified := fmt.Sprintf("%v.%v.%v", typeName.Pkg().Path(), typeName.Name(), implementation.Name())
+				contract := renameFields(method.contract, renames(
+					method.method.Type().(*types.Signature), implementation.Type().(*types.Signature),
+				))
+				injector.inherited[qualified] = append(injector.inherited[qualified], contract)
+			}
+		}
+	}
+
+	return nil
+}
+
+// interfaceMethods returns the methods with contracts of the non-generic
+// interfaces declared in the package.
+func interfaceMethods(pkg *packages.Package) (methods []interfaceMethod, err error) {
+	if pkg.TypesInfo == nil {
+		return nil, nil
+	}
+
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			declaration, ok := decl.(*ast.GenDecl)
+			if !ok || declaration.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range declaration.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				interfaceType, ok := typeSpec.Type.(*ast.InterfaceType)
+				if !ok || typeSpec.TypeParams != nil {
+					continue
+				}
+				object, ok := pkg.TypesInfo.Defs[typeSpec.Name]
+				if !ok || object == nil {
+					continue
+				}
+				iface := object.Type().Underlying().(*types.Interface)
+
+				for _, field := range interfaceType.Methods.List {
+					if len(field.Names) != 1 || field.Doc == nil || !IsContract(field.Doc.Text()) {
+						continue
+					}
+					method, ok := pkg.TypesInfo.Defs[field.Names[0]].(*types.Func)
+					if !ok {
+						continue
+					}
+
+					contract, err := parseComments(pkg.Fset.Position(field.Pos()), field.Doc)
+					if err != nil {
+						return nil, err
+					}
+					methods = append(methods, interfaceMethod{iface, method, contract})
+				}
+			}
+		}
+	}
+
+	return methods, nil
+}
+
+// parseComments parses the contract in the comments returning syntax errors at
+// the position instead of panicking.
+func parseComments(position token.Position, comments *ast.CommentGroup) (contract Contract, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%v: %v", position, recovered)
+		}
+	}()
+
+	parser := NewParser(LexGo(comments))
+	return parser.Parse(), nil
+}
+
+// receiver returns the type of the receiver of the method.
+func receiver(method *types.Func) types.Type {
+	recv := method.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return nil
+	}
+	if pointer, ok := recv.Type().(*types.Pointer); ok {
+		return pointer.Elem()
+	}
+	return recv.Type()
+}
+
+// fieldNames returns the names of the fields of the execution model of a
+// function with the signature as named by Injector.Model.
+func fieldNames(signature *types.Signature) (names []string) {
+	for idx := 0; idx < signature.Params().Len(); idx++ {
+		names = append(names, signature.Params().At(idx).Name())
+	}
+	for idx := 0; idx < signature.Results().Len(); idx++ {
+		name := signature.Results().At(idx).Name()
+		if name == "" {
+			name = fmt.Sprintf("ret%v", idx)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// renames maps the names of the parameters and results of the interface method
+// to those of the implementation at the same positions.
+func renames(iface, implementation *types.Signature) map[string]string {
+	names := make(map[string]string)
+	to := fieldNames(implementation)
+	for idx, from := range fieldNames(iface) {
+		if idx >= len(to) || from == to[idx] {
+			continue
+		}
+		if from == "" || from == "_" || to[idx] == "" || to[idx] == "_" {
+			continue
+		}
+		names[from] = to[idx]
+	}
+	return names
+}
+
+// renameFields renames the fields of the execution model selected from the
+// quantified variables in the Go expressions of the contract.
+func renameFields(contract Contract, names map[string]string) Contract {
+	if len(names) == 0 {
+		return contract
+	}
+
+	var variables []string
+	Inspect(contract, func(node Node) bool {
+		switch cast := node.(type) {
+		case Universal:
+			variables = append(variables, cast.variables...)
+		case Existential:
+			variables = append(variables, cast.variables...)
+		case Aggregate:
+			variables = append(variables, cast.variables...)
+		}
+		return true
+	})
+
+	return Transform(contract, func(node Node) Node {
+		expression, ok := node.(GoExpresion)
+		if !ok {
+			return node
+		}
+
+		fset := token.NewFileSet()
+		parsed, err := parser.ParseExprFrom(fset, "", expression.code, 0)
+		if err != nil {
+			return node
+		}
+
+		type replacement struct {
+			offset int
+			from   string
+		}
+		var replacements []replacement
+		ast.Inspect(parsed, func(node ast.Node) bool {
+			selector, ok := node.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			variable, ok := selector.X.(*ast.Ident)
+			if !ok || !slices.Contains(variables, variable.Name) {
+				return true
+			}
+			if _, exists := names[selector.Sel.Name]; exists {
+				replacements = append(replacements, replacement{fset.Position(selector.Sel.Pos()).Offset, selector.Sel.Name})
+			}
+			return false
+		})
+
+		slices.SortFunc(replacements, func(a, b replacement) int {
+			return a.offset - b.offset
+		})
+
+		code := expression.code
+		for idx := len(replacements) - 1; idx >= 0; idx-- {
+			replacement := replacements[idx]
+			code = code[:replacement.offset] + names[replacement.from] + code[replacement.offset+len(replacement.from):]
+		}
+		return NewGoExpression(code)
+	}).(Contract)
+}
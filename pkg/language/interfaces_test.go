@@ -0,0 +1,132 @@
+package language
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dave/dst/decorator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterfaces(t *testing.T) {
+	directory := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(directory, path)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	write("go.mod", "module example.com/pins\n\ngo 1.23\n")
+	write("pins.go", `package pins
+
+type PinChecker interface {
+	// guarantee "Bounded": forall e. e.ret0 == (e.attempt <= 3)
+	Check(attempt int, pin string) bool
+	Reset()
+}
+
+type Card struct{}
+
+// guarantee: forall e. e.pin != "" || !e.ret0
+func (card *Card) Check(tries int, pin string) bool {
+	return tries <= 3
+}
+
+func (card *Card) Reset() {}
+
+type Phone struct{}
+
+func (Phone) Check(attempt int, pin string) bool {
+	return attempt <= 3
+}
+
+func (Phone) Reset() {}
+
+type Wallet struct {
+	Card
+}
+
+type Counter struct{}
+
+func (Counter) Check(attempt int) bool {
+	return attempt <= 3
+}
+`)
+	write("remote/remote.go", `package remote
+
+type Remote struct{}
+
+func (Remote) Check(attempt int, pin string) (ok bool) {
+	return attempt <= 3
+}
+
+func (Remote) Reset() {}
+`)
+
+	injector := NewGoInjector()
+	assert.NoError(t, injector.Interfaces(directory, "./..."))
+
+	printed := make(map[string][]string)
+	for name, contracts := range injector.inherited {
+		for _, contract := range contracts {
+			printed[name] = append(printed[name], PrintComments(contract)...)
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		"example.com/pins.Card.Check": {
+			`// guarantee "Bounded": forall e. e.ret0 == (e.tries <= 3);`,
+		},
+		"example.com/pins.Phone.Check": {
+			`// guarantee "Bounded": forall e. e.ret0 == (e.attempt <= 3);`,
+		},
+		"example.com/pins/remote.Remote.Check": {
+			`// guarantee "Bounded": forall e. e.ok == (e.attempt <= 3);`,
+		},
+	}, printed)
+
+	source, err := os.ReadFile(filepath.Join(directory, "pins.go"))
+	assert.NoError(t, err)
+	file, err := decorator.NewDecorator(token.NewFileSet()).ParseFile("pins.go", source, parser.ParseComments)
+	assert.NoError(t, err)
+
+	// Inherited contracts are merged with the contract of the implementation.
+	injector.Attach("example.com/pins", file)
+	injector.Inject(file)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, decorator.Fprint(&buffer, file))
+	instrumented := buffer.String()
+
+	// Implementations in the same package are instrumented side by side.
+	assert.Contains(t, instrumented, "var Card_Check_Contract sopher.AGHyperContract[Card_Check_ExecutionModel]")
+	assert.Contains(t, instrumented, "var Phone_Check_Contract sopher.AGHyperContract[Phone_Check_ExecutionModel]")
+	assert.Contains(t, instrumented, `// guarantee "Bounded": forall e. e.ret0 == (e.tries <= 3);
+// guarantee: forall e. e.pin != "" || !e.ret0;
+func (card *Card) Check(tries int, pin string) bool {`)
+}
+
+func TestMerge(t *testing.T) {
+	parse := func(text string) Contract {
+		parser := NewParser(LexString(text))
+		return parser.Parse()
+	}
+
+	merged := Merge(
+		parse("region a:\nguarantee: forall e. e.x;\n"),
+		parse("assume: forall e. e.y;\nregion a:\nguarantee: forall e. e.z;\nregion b:\nguarantee: forall e. e.w;\n"),
+	)
+
+	assert.Equal(t, []string{
+		"// assume: forall e. e.y;",
+		"// region a:",
+		"// guarantee: forall e. e.x;",
+		"// guarantee: forall e. e.z;",
+		"// region b:",
+		"// guarantee: forall e. e.w;",
+	}, PrintComments(merged))
+	assert.Equal(t, merged, parse(" assume: forall e. e.y;\n region a:\n guarantee: forall e. e.x;\n guarantee: forall e. e.z;\n region b:\n guarantee: forall e. e.w;\n"))
+}
@@ -0,0 +1,194 @@
+package language
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Join is an equality of the keys of the elements assigned to two variables of
+// a quantifier which its body requires, e.g. e0.low == e1.low of the universal
+// forall e0 e1. e0.low != e1.low || e0.ret0 == e1.ret0, such that only the
+// assignments of elements with equal keys are evaluated. The elements are
+// indexed by their key of the right variable and the index of a history is
+// maintained as it grows. The variables are positions in the quantifier and
+// an element without a key, e.g. an execution of another function in a
+// composite contract, is never assigned to them.
+type Join[T any] struct {
+	left, right       int
+	leftKey, rightKey func(element T) (any, bool)
+	history           *joinHistory
+}
+
+// joinHistory is the index of the elements of a history seen so far.
+type joinHistory struct {
+	mutex sync.Mutex
+	seen  int
+	index map[any][]int
+}
+
+func NewJoin[T any](
+	left int, leftKey func(element T) (any, bool),
+	right int, rightKey func(element T) (any, bool),
+) *Join[T] {
+	return &Join[T]{
+		left:     left,
+		right:    right,
+		leftKey:  leftKey,
+		rightKey: rightKey,
+		history:  &joinHistory{},
+	}
+}
+
+// add indexes the elements from the position by their key of the right
+// variable.
+func (join *Join[T]) add(index map[any][]int, elements []T, from int) {
+	for idx := from; idx < len(elements); idx++ {
+		if key, ok := join.rightKey(elements[idx]); ok {
+			index[key] = append(index[key], idx)
+		}
+	}
+}
+
+// forget forgets the index of the history.
+func (join *Join[T]) forget() {
+	join.history.mutex.Lock()
+	defer join.history.mutex.Unlock()
+	join.history.seen = 0
+	join.history.index = nil
+}
+
+// equality is the equalities of the keys of two variables of a quantifier
+// required by its body. The variables are positions in the quantifier and the
+// keys are Go expressions of their variable.
+type equality struct {
+	left, right         int
+	leftKeys, rightKeys []string
+}
+
+// equalityOf returns the equalities of the keys of two of the variables which
+// the Go expression requires. A universal is satisfied unless they are equal,
+// e.g. by e0.low != e1.low || ... or !(e0.low == e1.low) || ..., and an
+// existential is violated unless they are equal, e.g. by e0.low == e1.low &&
+// .... Only the leading comparisons are equalities such that the keys are never
+// computed when the expression would not compare them. Keys are the same
+// expression of their variable and no other bound variable, such that they
+// are of the same type, and false is returned if no variables are joined.
+func equalityOf(code string, variables, bound []string, universal bool) (equality, bool) {
+	expression, err := parser.ParseExpr(code)
+	if err != nil {
+		return equality{}, false
+	}
+
+	// comparisons returns the equalities of the disjunct of a universal or the
+	// conjunct of an existential.
+	comparisons := func(operand ast.Expr) []*ast.BinaryExpr {
+		operand = unparen(operand)
+		if binary, ok := operand.(*ast.BinaryExpr); ok && binary.Op == token.NEQ && universal ||
+			ok && binary.Op == token.EQL && !universal {
+			return []*ast.BinaryExpr{binary}
+		}
+		unary, ok := operand.(*ast.UnaryExpr)
+		if !ok || unary.Op != token.NOT || !universal {
+			return nil
+		}
+		var equalities []*ast.BinaryExpr
+		for _, conjunct := range operands(unary.X, token.LAND) {
+			binary, ok := unparen(conjunct).(*ast.BinaryExpr)
+			if !ok || binary.Op != token.EQL {
+				return nil
+			}
+			equalities = append(equalities, binary)
+		}
+		return equalities
+	}
+
+	// variable returns the position of the only variable of the key.
+	variable := func(key ast.Expr) (int, bool) {
+		selected := make(map[*ast.Ident]bool)
+		position, valid := -1, true
+		ast.Inspect(key, func(node ast.Node) bool {
+			switch cast := node.(type) {
+			case *ast.SelectorExpr:
+				// Fields and methods are not variables.
+				selected[cast.Sel] = true
+			case *ast.CallExpr:
+				// The temporal predicates are not functions of one execution.
+				if function, ok := cast.Fun.(*ast.Ident); ok &&
+					(function.Name == "before" || function.Name == "next" || function.Name == "within") {
+					valid = false
+				}
+			case *ast.Ident:
+				if selected[cast] {
+					break
+				}
+				if idx := slices.Index(variables, cast.Name); idx >= 0 {
+					valid = valid && (position < 0 || position == idx)
+					position = idx
+				} else if slices.Contains(bound, cast.Name) {
+					valid = false
+				}
+			}
+			return true
+		})
+		return position, valid && position >= 0
+	}
+
+	operator := token.LAND
+	if universal {
+		operator = token.LOR
+	}
+
+	join := equality{left: -1}
+	for _, operand := range operands(expression, operator) {
+		equalities := comparisons(operand)
+		for _, comparison := range equalities {
+			left, leftOk := variable(comparison.X)
+			right, rightOk := variable(comparison.Y)
+			if !leftOk || !rightOk || left == right {
+				return join, join.left >= 0
+			}
+
+			leftKey, rightKey := printed(comparison.X), printed(comparison.Y)
+			if renamed, _ := substitute(rightKey, map[string]string{variables[right]: variables[left]}); printed(renamed) != leftKey {
+				return join, join.left >= 0
+			}
+			if left > right {
+				left, right, leftKey, rightKey = right, left, rightKey, leftKey
+			}
+			if join.left >= 0 && (join.left != left || join.right != right) {
+				return join, true
+			}
+			join.left, join.right = left, right
+			join.leftKeys = append(join.leftKeys, leftKey)
+			join.rightKeys = append(join.rightKeys, rightKey)
+		}
+		if len(equalities) == 0 {
+			break
+		}
+	}
+
+	return join, join.left >= 0
+}
+
+// unparen removes the parentheses around the expression.
+func unparen(expression ast.Expr) ast.Expr {
+	for {
+		paren, ok := expression.(*ast.ParenExpr)
+		if !ok {
+			return expression
+		}
+		expression = paren.X
+	}
+}
+
+// printed prints the expression as Go.
+func printed(expression ast.Expr) string {
+	var builder strings.Builder
+	printer.Fprint(&builder, token.NewFileSet(), expression)
+	return builder.String()
+}
@@ -0,0 +1,183 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqualityOf(t *testing.T) {
+	tests := []struct {
+		description string
+		code        string
+		universal   bool
+		equality    equality
+		ok          bool
+	}{
+		{
+			description: "inequality",
+			code:        "e0.low != e1.low || e0.ret0 == e1.ret0",
+			universal:   true,
+			equality:    equality{0, 1, []string{"e0.low"}, []string{"e1.low"}},
+			ok:          true,
+		},
+		{
+			description: "negated equalities",
+			code:        "!(e1.low == e0.low && (e0.id == e1.id)) || e0.ret0 == e1.ret0",
+			universal:   true,
+			equality:    equality{0, 1, []string{"e0.low", "e0.id"}, []string{"e1.low", "e1.id"}},
+			ok:          true,
+		},
+		{
+			description: "existential",
+			code:        "e0.user == e1.user && e0.ret0 != e1.ret0",
+			universal:   false,
+			equality:    equality{0, 1, []string{"e0.user"}, []string{"e1.user"}},
+			ok:          true,
+		},
+		{
+			description: "equality of universal",
+			code:        "e0.user == e1.user && e0.ret0 != e1.ret0",
+			universal:   true,
+		},
+		{
+			description: "guarded keys",
+			code:        "e0.p == nil || e1.p == nil || e0.p.x != e1.p.x",
+			universal:   true,
+		},
+		{
+			description: "different keys",
+			code:        "e0.low != e1.high || e0.ret0 == e1.ret0",
+			universal:   true,
+		},
+		{
+			description: "key of both variables",
+			code:        "e0.low+e1.low != e1.low || e0.ret0 == e1.ret0",
+			universal:   true,
+		},
+		{
+			description: "key of bound variable",
+			code:        "e0.low+x.low != e1.low+x.low || e0.ret0 == e1.ret0",
+			universal:   true,
+		},
+		{
+			description: "temporal key",
+			code:        "next(e0, e2) != next(e1, e2) || e0.ret0 == e1.ret0",
+			universal:   true,
+		},
+		{
+			description: "first pair",
+			code:        "e0.low != e2.low || e1.low != e2.low || e0.ret0 == e2.ret0",
+			universal:   true,
+			equality:    equality{0, 2, []string{"e0.low"}, []string{"e2.low"}},
+			ok:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			equality, ok := equalityOf(tt.code, []string{"e0", "e1", "e2"}, []string{"x", "e0", "e1", "e2"}, tt.universal)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.equality, equality)
+			}
+		})
+	}
+}
+
+func TestJoinedUniversal(t *testing.T) {
+	type execution struct {
+		high, low, ret int
+	}
+
+	evaluations := 0
+	// Executions with the same low input return the same.
+	noninterference := func(assignments []execution) bool {
+		evaluations++
+		e0, e1 := assignments[0], assignments[1]
+		return e0.low != e1.low || e0.ret == e1.ret
+	}
+	low := func(e execution) (any, bool) { return e.low, true }
+	secure := []execution{{1, 0, 5}, {2, 0, 5}, {3, 1, 7}, {4, 1, 7}}
+	insecure := append(secure, execution{5, 1, 8})
+
+	tests := []struct {
+		description string
+		symmetry    Symmetry
+		evaluations int
+	}{
+		{"asymmetric", Asymmetric, 8},
+		{"symmetric", Symmetric, 6},
+		{"reflexive", Reflexive, 4},
+		{"symmetric and reflexive", Symmetric | Reflexive, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			join := NewJoin(0, low, 1, low)
+			universal := NewJoinedUniversalHyperAssertion(0, 2, tt.symmetry, join, NewPredicateHyperAssertion(noninterference))
+			interpreter := NewHyperAssertionInterpreter[execution]()
+
+			evaluations = 0
+			assert.Equal(t, LiftedUnknown, interpreter.Satisfies(universal, secure))
+			assert.Equal(t, tt.evaluations, evaluations)
+			assert.Equal(t, LiftedFalse, interpreter.Satisfies(universal, insecure))
+		})
+	}
+}
+
+func TestJoinedExistential(t *testing.T) {
+	type execution struct {
+		user, ret int
+	}
+
+	evaluations := 0
+	// Some user gets different results.
+	inconsistent := func(assignments []execution) bool {
+		evaluations++
+		e0, e1, e2 := assignments[0], assignments[1], assignments[2]
+		return e0.user == e2.user && e0.ret != e2.ret && e1.ret == 0
+	}
+	user := func(e execution) (any, bool) { return e.user, e.user >= 0 }
+	existential := NewJoinedExistentialHyperAssertion(0, 3, NewJoin(0, user, 2, user), NewPredicateHyperAssertion(inconsistent))
+	interpreter := NewHyperAssertionInterpreter[execution]()
+
+	// Only the 2 pairs of executions with keys of the same user are evaluated
+	// for every execution assigned to e1.
+	assert.Equal(t, LiftedUnknown, interpreter.Satisfies(existential, []execution{{0, 1}, {1, 1}, {-1, 0}}))
+	assert.Equal(t, 6, evaluations)
+	assert.Equal(t, LiftedTrue, interpreter.Satisfies(existential, []execution{{0, 1}, {1, 1}, {0, 0}}))
+}
+
+func TestJoinedHistory(t *testing.T) {
+	type execution struct {
+		low, ret int
+	}
+
+	deterministic := func(assignments []execution) bool {
+		e0, e1 := assignments[0], assignments[1]
+		return e0.low != e1.low || e0.ret == e1.ret
+	}
+	keys := 0
+	low := func(e execution) (any, bool) {
+		keys++
+		return e.low, true
+	}
+	join := NewJoin(0, func(e execution) (any, bool) { return e.low, true }, 1, low)
+	universal := NewJoinedUniversalHyperAssertion(0, 2, Symmetric|Reflexive, join, NewPredicateHyperAssertion(deterministic))
+	interpreter := NewHyperAssertionInterpreter[execution]()
+
+	history := []execution{{0, 1}, {1, 2}}
+	assert.Equal(t, LiftedUnknown, interpreter.SatisfiesHistory(universal, history, nil, 0))
+	assert.Equal(t, 2, keys)
+
+	// Only the executions added to the history are indexed.
+	history = append(history, execution{0, 1}, execution{1, 3})
+	assert.Equal(t, LiftedFalse, interpreter.SatisfiesHistory(universal, history, nil, 2))
+	assert.Equal(t, 4, keys)
+
+	// A forgotten index indexes the next history anew.
+	forget[execution](universal)
+	assert.Equal(t, LiftedUnknown, interpreter.SatisfiesHistory(universal, history[:3], nil, 0))
+	assert.Equal(t, 7, keys)
+}
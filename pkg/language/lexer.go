@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"go/ast"
 	"iter"
+	"slices"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -241,6 +243,95 @@ func (lexer *Lexer) guarantee(fields []string) iter.Seq[Token] {
 	}
 }
 
+// labelled lexes an obligation with a quoted label between its keyword and its
+// scope delimiter, e.g. guarantee "No Timing Side Channel": ...
+func (lexer *Lexer) labelled(keyword string, class TokenClass) (bool, iter.Seq[Token]) {
+	lookahead := 1
+	for _, expected := range keyword {
+		if character, ok := lexer.peek(lookahead); !ok || character != expected {
+			return false, nil
+		}
+		lookahead++
+	}
+
+	spaces := func() {
+		for character, ok := lexer.peek(lookahead); ok && lexer.isSpace(character); character, ok = lexer.peek(lookahead) {
+			lookahead++
+		}
+	}
+
+	spaces()
+	if character, ok := lexer.peek(lookahead); !ok || character != '"' {
+		return false, nil
+	}
+
+	var quoted strings.Builder
+	for escaped := false; ; {
+		character, ok := lexer.peek(lookahead)
+		if !ok || character == '\n' {
+			return false, nil
+		}
+		quoted.WriteRune(character)
+		lookahead++
+		if character == '"' && quoted.Len() > 1 && !escaped {
+			break
+		}
+		escaped = !escaped && character == '\\'
+	}
+
+	spaces()
+	if character, ok := lexer.peek(lookahead); !ok || character != ':' {
+		return false, nil
+	}
+
+	label, err := strconv.Unquote(quoted.String())
+	if err != nil {
+		panic(fmt.Sprintf("invalid label %s of %s", quoted.String(), keyword))
+	}
+
+	for idx := 0; idx < lookahead; idx++ {
+		lexer.next()
+	}
+
+	return true, func(yield func(Token) bool) {
+		if !yield(NewToken(class, keyword)) {
+			return
+		}
+		if !yield(NewToken(LabelToken, label)) {
+			return
+		}
+		yield(NewToken(ScopeDelimiterToken, ":"))
+	}
+}
+
+// isComment reports whether a line comment starts at the next character.
+func (lexer *Lexer) isComment() bool {
+	first, ok := lexer.peek(1)
+	if !ok || first != '/' {
+		return false
+	}
+	second, ok := lexer.peek(2)
+	return ok && second == '/'
+}
+
+// label lexes a comment ending the line as the label of the obligation on it.
+func (lexer *Lexer) label() Token {
+	lexer.next()
+	lexer.next()
+
+	var builder strings.Builder
+	for {
+		character, ok := lexer.peek(1)
+		if !ok || character == '\n' {
+			break
+		}
+		lexer.next()
+		builder.WriteRune(character)
+	}
+
+	return NewToken(LabelToken, strings.TrimSpace(builder.String()))
+}
+
 func (lexer *Lexer) quantifier(prefix string, class TokenClass, fields []string) iter.Seq[Token] {
 	return func(yield func(Token) bool) {
 		length := len(fields)
@@ -268,8 +359,18 @@ func (lexer *Lexer) quantifier(prefix string, class TokenClass, fields []string)
 		}
 
 		for idx := 1; idx < length-1; idx++ {
-			if !yield(NewToken(IdentifierToken, fields[idx])) {
-				return
+			// A universal over distinct executions, e.g. "forall distinct e0 e1.",
+			// is only distinct if variables follow as it is a variable otherwise.
+			if class == ForallToken && idx == 1 && idx+1 < length-1 && fields[idx] == "distinct" {
+				if !yield(NewToken(DistinctToken, fields[idx])) {
+					return
+				}
+				continue
+			}
+			for _, token := range lexer.binding(fields[idx]) {
+				if !yield(token) {
+					return
+				}
 			}
 		}
 
@@ -277,6 +378,210 @@ func (lexer *Lexer) quantifier(prefix string, class TokenClass, fields []string)
 	}
 }
 
+// isBinding reports whether the word binds quantified variables, i.e. whether
+// it is an identifier optionally preceded or followed by a type delimiter and
+// followed by a separator.
+func (lexer *Lexer) isBinding(word string) bool {
+	word = strings.TrimPrefix(word, ":")
+	word = strings.TrimSuffix(word, ",")
+	word = strings.TrimSuffix(word, ":")
+	return lexer.isIdentifier(word)
+}
+
+// binding lexes a word binding quantified variables, e.g. "r:" of "forall r:
+// Read, e: Erase." where the type of a variable is the function of which it is
+// an execution.
+func (lexer *Lexer) binding(word string) (tokens []Token) {
+	if rest, typing := strings.CutPrefix(word, ":"); typing && rest != "" {
+		tokens = append(tokens, NewToken(TypeDelimiterToken, ":"))
+		word = rest
+	}
+	word, separated := strings.CutSuffix(word, ",")
+	word, typed := strings.CutSuffix(word, ":")
+	if word != "" {
+		tokens = append(tokens, NewToken(IdentifierToken, word))
+	}
+	if typed {
+		tokens = append(tokens, NewToken(TypeDelimiterToken, ":"))
+	}
+	if separated {
+		tokens = append(tokens, NewToken(SeparatorToken, ","))
+	}
+	return tokens
+}
+
+// compose lexes the composition clause of a contract naming the functions whose
+// executions it relates, e.g. compose: Read, Erase
+func (lexer *Lexer) compose() (bool, iter.Seq[Token]) {
+	lookahead := 1
+	for _, expected := range "compose" {
+		if character, ok := lexer.peek(lookahead); !ok || character != expected {
+			return false, nil
+		}
+		lookahead++
+	}
+	for character, ok := lexer.peek(lookahead); ok && lexer.isSpace(character); character, ok = lexer.peek(lookahead) {
+		lookahead++
+	}
+	if character, ok := lexer.peek(lookahead); !ok || character != ':' {
+		return false, nil
+	}
+	for idx := 0; idx < lookahead; idx++ {
+		lexer.next()
+	}
+
+	tokens := []Token{NewToken(ComposeToken, "compose"), NewToken(ScopeDelimiterToken, ":")}
+	var builder strings.Builder
+	flush := func() {
+		if builder.Len() == 0 {
+			return
+		}
+		if !lexer.isIdentifier(builder.String()) {
+			panic(fmt.Sprintf("compose expected function names but found %q", builder.String()))
+		}
+		tokens = append(tokens, NewToken(IdentifierToken, builder.String()))
+		builder.Reset()
+	}
+
+	for {
+		character, ok := lexer.peek(1)
+		if !ok || character == '\n' || lexer.isComment() {
+			break
+		}
+		lexer.next()
+
+		switch {
+		case character == ',':
+			flush()
+			tokens = append(tokens, NewToken(SeparatorToken, ","))
+		case lexer.isSpace(character):
+			flush()
+		default:
+			builder.WriteRune(character)
+		}
+	}
+	flush()
+
+	return true, slices.Values(tokens)
+}
+
+// aggregate lexes the head of an aggregate up to its scope delimiter, e.g.
+// "percentile(95) t." of "percentile(95) t. t.latency <= 100ms". As the names
+// of the aggregates are common identifiers in Go expressions the head must be
+// on one line and is otherwise lexed as an expression.
+func (lexer *Lexer) aggregate() (bool, iter.Seq[Token]) {
+	lookahead, depth := 0, 0
+	var head strings.Builder
+	for {
+		lookahead++
+		character, ok := lexer.peek(lookahead)
+		if !ok || character == '\n' {
+			return false, nil
+		}
+		if character == '.' && depth == 0 {
+			break
+		}
+		switch character {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		head.WriteRune(character)
+	}
+
+	fields := strings.FieldsFunc(head.String(), lexer.isSpace)
+	if len(fields) < 2 || !strings.HasPrefix(head.String(), fields[0]) {
+		return false, nil
+	}
+	for _, field := range fields[1:] {
+		if !lexer.isBinding(field) {
+			return false, nil
+		}
+	}
+
+	tokens := []Token{NewToken(AggregateToken, fields[0])}
+	switch fields[0] {
+	case "mean", "sum", "count", "probability":
+	default:
+		// The percentile of percentiles or the confidence of estimates.
+		function, argument, ok := strings.Cut(fields[0], "(")
+		argument, closed := strings.CutSuffix(argument, ")")
+		if !slices.Contains([]string{"percentile", "mean", "probability"}, function) {
+			return false, nil
+		}
+		if _, err := strconv.ParseFloat(argument, 64); !ok || !closed || err != nil {
+			return false, nil
+		}
+		tokens = []Token{
+			NewToken(AggregateToken, function),
+			NewToken(LeftParenthesis, "("),
+			NewToken(NumberToken, argument),
+			NewToken(RightParenthesis, ")"),
+		}
+	}
+	for _, field := range fields[1:] {
+		tokens = append(tokens, lexer.binding(field)...)
+	}
+	tokens = append(tokens, NewToken(ScopeDelimiterToken, "."))
+
+	for idx := 0; idx < lookahead; idx++ {
+		lexer.next()
+	}
+
+	return true, slices.Values(tokens)
+}
+
+// probability lexes the opening of a probability, e.g. "P(" of "P(e.x; | e.y;)".
+// As probabilities are common calls in Go expressions the event must end with
+// an expression delimiter outside brackets before the matching parenthesis and
+// it is otherwise lexed as an expression.
+func (lexer *Lexer) probability() (bool, iter.Seq[Token]) {
+	if character, ok := lexer.peek(1); !ok || character != 'P' {
+		return false, nil
+	}
+	if character, ok := lexer.peek(2); !ok || character != '(' {
+		return false, nil
+	}
+
+	var quote rune
+	escaped, delimited := false, false
+	parentheses, brackets := 1, 0
+	for lookahead := 3; parentheses > 0; lookahead++ {
+		character, ok := lexer.peek(lookahead)
+		if !ok {
+			return false, nil
+		}
+
+		switch {
+		case quote != 0:
+			if !escaped && character == quote {
+				quote = 0
+			}
+			escaped = quote != '`' && !escaped && character == '\\'
+		case character == '"' || character == '\'' || character == '`':
+			quote = character
+		case character == '(':
+			parentheses++
+		case character == ')':
+			parentheses--
+		case character == '[' || character == '{':
+			brackets++
+		case character == ']' || character == '}':
+			brackets--
+		case character == ';' && brackets == 0:
+			delimited = true
+		}
+	}
+	if !delimited {
+		return false, nil
+	}
+
+	lexer.next()
+	lexer.next()
+	return true, slices.Values([]Token{NewToken(ProbabilityToken, "P(")})
+}
+
 func (lexer *Lexer) forall(fields []string) iter.Seq[Token] {
 	return lexer.quantifier("forall", ForallToken, fields)
 }
@@ -285,9 +590,33 @@ func (lexer *Lexer) exists(fields []string) iter.Seq[Token] {
 	return lexer.quantifier("exists", ExistsToken, fields)
 }
 
+// continues reports whether an expression continues on the next line. This is
+// the case if its brackets are unbalanced, if it ends with a binary operator
+// or a comma, or if it ends with a backslash. The increment and decrement
+// statements "++" and "--" do not continue an expression.
+func (lexer *Lexer) continues(code string, depth int) bool {
+	if depth > 0 {
+		return true
+	}
+	code = strings.TrimRightFunc(code, lexer.isSpace)
+	if strings.HasSuffix(code, "++") || strings.HasSuffix(code, "--") {
+		return false
+	}
+	return len(code) > 0 && strings.ContainsRune("\\&|=!<>+-*/%^,", rune(code[len(code)-1]))
+}
+
+// expression lexes a Go expression until a semicolon, a label or the end of a
+// line where it does not continue. Line breaks and backslashes continuing it
+// are replaced by spaces such that the expression is a single line of the same
+// length as its source.
 func (lexer *Lexer) expression() iter.Seq[Token] {
 	return func(yield func(Token) bool) {
 		var builder strings.Builder
+		// quote is the delimiter of the literal being lexed such that neither
+		// brackets, semicolons nor a "//" within it are taken for syntax.
+		var quote rune
+		escaped := false
+		depth := 0
 		for {
 			character, ok := lexer.peek(1)
 			if !ok {
@@ -296,17 +625,55 @@ func (lexer *Lexer) expression() iter.Seq[Token] {
 				return
 			}
 
+			if quote == 0 && lexer.isComment() {
+				// The label is lexed next and the padding aligning it is dropped.
+				if !yield(NewToken(ExpressionToken, strings.TrimRightFunc(builder.String(), lexer.isSpace))) {
+					return
+				}
+				yield(NewToken(ExpressionDelimiterToken, ";"))
+				return
+			}
+
 			lexer.next()
 
-			if character == ';' || character == '\n' {
+			if quote == 0 && character == '\n' && lexer.continues(builder.String(), depth) {
+				code := builder.String()
+				if strings.HasSuffix(strings.TrimRightFunc(code, lexer.isSpace), "\\") {
+					idx := strings.LastIndex(code, "\\")
+					code = code[:idx] + " " + code[idx+1:]
+				}
+				builder.Reset()
+				builder.WriteString(code)
+				builder.WriteRune(' ')
+				continue
+			}
+
+			// Only raw strings span lines.
+			if quote != 0 && quote != '`' && character == '\n' {
+				quote = 0
+			}
+
+			if quote == 0 && (character == ';' || character == '\n') {
 				if !yield(NewToken(ExpressionToken, builder.String())) {
 					return
 				}
 				yield(NewToken(ExpressionDelimiterToken, ";"))
 				return
-			} else {
-				builder.WriteRune(character)
 			}
+
+			switch {
+			case quote == 0 && (character == '"' || character == '\'' || character == '`'):
+				quote = character
+			case quote != 0 && !escaped && character == quote:
+				quote = 0
+			case quote == 0 && strings.ContainsRune("([{", character):
+				depth++
+			case quote == 0 && strings.ContainsRune(")]}", character):
+				depth = max(0, depth-1)
+			}
+			escaped = quote != 0 && quote != '`' && !escaped && character == '\\'
+
+			builder.WriteRune(character)
 		}
 	}
 }
@@ -338,6 +705,9 @@ func (lexer *Lexer) Scan() iter.Seq[Token] {
 		';': NewToken(ExpressionDelimiterToken, ";"),
 		'(': NewToken(LeftParenthesis, "("),
 		')': NewToken(RightParenthesis, ")"),
+		// No Go expression starts with the separator of the given event of a
+		// conditional probability.
+		'|': NewToken(GivenToken, "|"),
 	}
 
 	return func(yield func(Token) bool) {
@@ -348,11 +718,23 @@ func (lexer *Lexer) Scan() iter.Seq[Token] {
 				break
 			}
 
-			if token, found := keycharacters[character]; found {
+			if lexer.isComment() {
+				if !yield(lexer.label()) {
+					return
+				}
+			} else if token, found := keycharacters[character]; found {
 				lexer.next()
 				if !yield(token) {
 					return
 				}
+			} else if found, compose := lexer.compose(); found {
+				if !iterx.Pipe(compose, yield) {
+					return
+				}
+			} else if found, aggregate := lexer.aggregate(); found {
+				if !iterx.Pipe(aggregate, yield) {
+					return
+				}
 			} else if found, words := lexer.consumeWord(
 				"region",
 				lexer.isSpace,
@@ -363,6 +745,14 @@ func (lexer *Lexer) Scan() iter.Seq[Token] {
 				if !iterx.Pipe(region, yield) {
 					return
 				}
+			} else if found, labelled := lexer.labelled("assume", AssumeToken); found {
+				if !iterx.Pipe(labelled, yield) {
+					return
+				}
+			} else if found, labelled := lexer.labelled("guarantee", GuaranteeToken); found {
+				if !iterx.Pipe(labelled, yield) {
+					return
+				}
 			} else if found, words := lexer.consumeWord(
 				"assume",
 				lexer.isSpace,
@@ -386,7 +776,7 @@ func (lexer *Lexer) Scan() iter.Seq[Token] {
 			} else if found, words := lexer.consumeWord(
 				"forall",
 				lexer.isSpace,
-				lexer.isIdentifier,
+				lexer.isBinding,
 				".", "\n",
 			); found {
 				forall := lexer.forall(words)
@@ -396,13 +786,17 @@ func (lexer *Lexer) Scan() iter.Seq[Token] {
 			} else if found, words := lexer.consumeWord(
 				"exists",
 				lexer.isSpace,
-				lexer.isIdentifier,
+				lexer.isBinding,
 				".", "\n",
 			); found {
 				exists := lexer.exists(words)
 				if !iterx.Pipe(exists, yield) {
 					return
 				}
+			} else if found, probability := lexer.probability(); found {
+				if !iterx.Pipe(probability, yield) {
+					return
+				}
 			} else {
 				expression := lexer.expression()
 				if !iterx.Pipe(expression, yield) {
@@ -175,6 +175,21 @@ func TestLexClasses(t *testing.T) {
 			input:       "forall a  b d   .",
 			classes:     []TokenClass{ForallToken, IdentifierToken, IdentifierToken, IdentifierToken, ScopeDelimiterToken, EofToken},
 		},
+		{
+			description: "forall distinct",
+			input:       "forall distinct a b.",
+			classes:     []TokenClass{ForallToken, DistinctToken, IdentifierToken, IdentifierToken, ScopeDelimiterToken, EofToken},
+		},
+		{
+			description: "forall variable named distinct",
+			input:       "forall distinct.",
+			classes:     []TokenClass{ForallToken, IdentifierToken, ScopeDelimiterToken, EofToken},
+		},
+		{
+			description: "exists distinct",
+			input:       "exists distinct a.",
+			classes:     []TokenClass{ExistsToken, IdentifierToken, IdentifierToken, ScopeDelimiterToken, EofToken},
+		},
 		{
 			description: "guarantee forall",
 			input:       "guarantee: forall a  b d   .",
@@ -339,6 +354,192 @@ func TestLexClasses(t *testing.T) {
 	}
 }
 
+func TestLexLabels(t *testing.T) {
+	tests := []struct {
+		description string
+		input       string
+		tokens      []Token
+	}{
+		{
+			description: "quoted label",
+			input:       `guarantee "No \"Timing\" Side Channel" : true`,
+			tokens: []Token{
+				NewToken(GuaranteeToken, "guarantee"), NewToken(LabelToken, `No "Timing" Side Channel`),
+				NewToken(ScopeDelimiterToken, ":"), NewToken(ExpressionToken, "true"),
+				NewToken(ExpressionDelimiterToken, ";"), NewToken(EofToken, ""),
+			},
+		},
+		{
+			description: "trailing label without delimiter",
+			input:       "assume: e.attempt > 0\t\t// Valid Attempt \nguarantee: e.ret0",
+			tokens: []Token{
+				NewToken(AssumeToken, "assume"), NewToken(ScopeDelimiterToken, ":"),
+				NewToken(ExpressionToken, "e.attempt > 0"), NewToken(ExpressionDelimiterToken, ";"),
+				NewToken(LabelToken, "Valid Attempt"),
+				NewToken(GuaranteeToken, "guarantee"), NewToken(ScopeDelimiterToken, ":"),
+				NewToken(ExpressionToken, "e.ret0"), NewToken(ExpressionDelimiterToken, ";"),
+				NewToken(EofToken, ""),
+			},
+		},
+		{
+			description: "trailing label after a group",
+			input:       `guarantee: (e.url == "http://a";) // Absolute`,
+			tokens: []Token{
+				NewToken(GuaranteeToken, "guarantee"), NewToken(ScopeDelimiterToken, ":"),
+				NewToken(LeftParenthesis, "("), NewToken(ExpressionToken, `e.url == "http://a"`),
+				NewToken(ExpressionDelimiterToken, ";"), NewToken(RightParenthesis, ")"),
+				NewToken(LabelToken, "Absolute"), NewToken(EofToken, ""),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.Equal(t, tt.tokens, iterx.Collect(LexString(tt.input)))
+		})
+	}
+}
+
+func TestLexComposition(t *testing.T) {
+	tests := []struct {
+		description string
+		input       string
+		tokens      []Token
+	}{
+		{
+			description: "compose clause",
+			input:       "compose : Read,Erase\nguarantee: true",
+			tokens: []Token{
+				NewToken(ComposeToken, "compose"), NewToken(ScopeDelimiterToken, ":"),
+				NewToken(IdentifierToken, "Read"), NewToken(SeparatorToken, ","),
+				NewToken(IdentifierToken, "Erase"),
+				NewToken(GuaranteeToken, "guarantee"), NewToken(ScopeDelimiterToken, ":"),
+				NewToken(ExpressionToken, "true"), NewToken(ExpressionDelimiterToken, ";"),
+				NewToken(EofToken, ""),
+			},
+		},
+		{
+			description: "typed variables",
+			input:       "guarantee: forall r0 r1: Read, e :Erase. true",
+			tokens: []Token{
+				NewToken(GuaranteeToken, "guarantee"), NewToken(ScopeDelimiterToken, ":"),
+				NewToken(ForallToken, "forall"),
+				NewToken(IdentifierToken, "r0"), NewToken(IdentifierToken, "r1"),
+				NewToken(TypeDelimiterToken, ":"), NewToken(IdentifierToken, "Read"),
+				NewToken(SeparatorToken, ","), NewToken(IdentifierToken, "e"),
+				NewToken(TypeDelimiterToken, ":"), NewToken(IdentifierToken, "Erase"),
+				NewToken(ScopeDelimiterToken, "."),
+				NewToken(ExpressionToken, "true"), NewToken(ExpressionDelimiterToken, ";"),
+				NewToken(EofToken, ""),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.Equal(t, tt.tokens, iterx.Collect(LexString(tt.input)))
+		})
+	}
+
+	assert.PanicsWithValue(t, `compose expected function names but found "Read."`, func() {
+		iterx.Collect(LexString("compose: Read. Erase"))
+	})
+}
+
+func TestLexAggregates(t *testing.T) {
+	tests := []struct {
+		description string
+		input       string
+		tokens      []Token
+	}{
+		{
+			description: "percentile",
+			input:       "guarantee: percentile(99.5) t. t.latency <= 1s",
+			tokens: []Token{
+				NewToken(GuaranteeToken, "guarantee"), NewToken(ScopeDelimiterToken, ":"),
+				NewToken(AggregateToken, "percentile"), NewToken(LeftParenthesis, "("),
+				NewToken(NumberToken, "99.5"), NewToken(RightParenthesis, ")"),
+				NewToken(IdentifierToken, "t"), NewToken(ScopeDelimiterToken, "."),
+				NewToken(ExpressionToken, "t.latency <= 1s"), NewToken(ExpressionDelimiterToken, ";"),
+				NewToken(EofToken, ""),
+			},
+		},
+		{
+			description: "typed count",
+			input:       "guarantee: count r: Read. r.ret0 >= 1",
+			tokens: []Token{
+				NewToken(GuaranteeToken, "guarantee"), NewToken(ScopeDelimiterToken, ":"),
+				NewToken(AggregateToken, "count"), NewToken(IdentifierToken, "r"),
+				NewToken(TypeDelimiterToken, ":"), NewToken(IdentifierToken, "Read"),
+				NewToken(ScopeDelimiterToken, "."),
+				NewToken(ExpressionToken, "r.ret0 >= 1"), NewToken(ExpressionDelimiterToken, ";"),
+				NewToken(EofToken, ""),
+			},
+		},
+		{
+			description: "identifiers named as aggregates",
+			input:       "guarantee: mean.x >= sum(count)",
+			tokens: []Token{
+				NewToken(GuaranteeToken, "guarantee"), NewToken(ScopeDelimiterToken, ":"),
+				NewToken(ExpressionToken, "mean.x >= sum(count)"), NewToken(ExpressionDelimiterToken, ";"),
+				NewToken(EofToken, ""),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.Equal(t, tt.tokens, iterx.Collect(LexString(tt.input)))
+		})
+	}
+}
+
+func TestLexMultiLineExpressions(t *testing.T) {
+	tests := []struct {
+		description string
+		input       string
+		expressions []string
+	}{
+		{
+			description: "unbalanced parentheses",
+			input:       "guarantee: forall e.\n\n\te.ret == (e.attempt <= 3 &&\n\t\tdigits[0] == 0)\nguarantee: true",
+			expressions: []string{"e.ret == (e.attempt <= 3 && \t\tdigits[0] == 0)", "true"},
+		},
+		{
+			description: "trailing operator",
+			input:       "guarantee: e0.low == e1.low ||\n e0.ret0 == e1.ret0 // Label\nguarantee: e.x",
+			expressions: []string{"e0.low == e1.low ||  e0.ret0 == e1.ret0", "e.x"},
+		},
+		{
+			description: "explicit backslash",
+			input:       "guarantee: e.x \\\n\t> 0\nguarantee: e.y",
+			expressions: []string{"e.x   \t> 0", "e.y"},
+		},
+		{
+			description: "literals",
+			input:       "guarantee: e.s == \"(;//\" && e.r == ')' && e.raw == `a\n(` \nguarantee: e.y",
+			expressions: []string{"e.s == \"(;//\" && e.r == ')' && e.raw == `a\n(` ", "e.y"},
+		},
+		{
+			description: "complete lines",
+			input:       "guarantee: e.x > 0\nguarantee: e.y++\nguarantee: f(e)",
+			expressions: []string{"e.x > 0", "e.y++", "f(e)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			var expressions []string
+			for token := range LexString(tt.input) {
+				if token.class == ExpressionToken {
+					expressions = append(expressions, token.lexeme)
+				}
+			}
+			assert.Equal(t, tt.expressions, expressions)
+		})
+	}
+}
+
 func TestLexGo(t *testing.T) {
 	sourceMulti := `
 package main
@@ -0,0 +1,227 @@
+package language
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Monitorability is the definite verdicts a monitor of an assertion can reach
+// on a set of executions which may still grow. An assertion is monitorable for
+// violation if some executions violate it regardless of the executions yet to
+// come and monitorable for satisfaction if some executions satisfy it
+// regardless of them. Otherwise its verdict is unknown.
+type Monitorability uint8
+
+const (
+	Unmonitorable           = Monitorability(0b00)
+	ViolationMonitorable    = Monitorability(0b01)
+	SatisfactionMonitorable = Monitorability(0b10)
+	Monitorable             = Monitorability(0b11)
+)
+
+// MonitorabilityOf returns the monitorability of the assertion or obligation.
+// A universal can only be violated and an existential only be satisfied such
+// that every quantifier alternation, e.g. forall e0. exists e1., is
+// unmonitorable. Exact aggregates can only be violated as executions yet to
+// come may still violate them, while expressions and estimates are
+// monitorable as they are evaluated on the executions so far.
+func MonitorabilityOf(node Node) Monitorability {
+	switch cast := node.(type) {
+	case Assumption:
+		return MonitorabilityOf(cast.assertion)
+	case Guarantee:
+		return MonitorabilityOf(cast.assertion)
+	case Group:
+		return MonitorabilityOf(cast.node)
+	case Universal:
+		// Executions yet to come may still violate the universal.
+		return MonitorabilityOf(cast.assertion) & ViolationMonitorable
+	case Existential:
+		// Executions yet to come may still witness the existential.
+		return MonitorabilityOf(cast.assertion) & SatisfactionMonitorable
+	case Aggregate:
+		// Executions yet to come may still violate the exact aggregate.
+		if cast.confidence == 0 {
+			return ViolationMonitorable
+		}
+	}
+	// Estimates are unknown until their interval or test is conclusive but can
+	// then be either.
+	return Monitorable
+}
+
+// Violations reports whether the assertion can be violated at runtime.
+func (monitorability Monitorability) Violations() bool {
+	return monitorability&ViolationMonitorable != 0
+}
+
+// Satisfactions reports whether the assertion can be satisfied at runtime.
+func (monitorability Monitorability) Satisfactions() bool {
+	return monitorability&SatisfactionMonitorable != 0
+}
+
+// Verdicts returns the verdicts the monitor of the assertion can reach.
+func (monitorability Monitorability) Verdicts() []LiftedBoolean {
+	var verdicts []LiftedBoolean
+	if monitorability.Satisfactions() {
+		verdicts = append(verdicts, LiftedTrue)
+	}
+	if monitorability.Violations() {
+		verdicts = append(verdicts, LiftedFalse)
+	}
+	return append(verdicts, LiftedUnknown)
+}
+
+func (monitorability Monitorability) String() string {
+	switch monitorability {
+	case ViolationMonitorable:
+		return "monitorable for violation"
+	case SatisfactionMonitorable:
+		return "monitorable for satisfaction"
+	case Monitorable:
+		return "monitorable"
+	}
+	return "unmonitorable"
+}
+
+// ObligationMonitorability is the monitorability of an obligation of a
+// contract. Kind is either "assumption" or "guarantee".
+type ObligationMonitorability struct {
+	Kind           string
+	Obligation     Obligation
+	Monitorability Monitorability
+}
+
+// Monitorabilities returns the monitorability of every obligation of the
+// contract. The obligations of the regions are indexed in order as they are
+// by the monitors of the contract.
+func (contract Contract) Monitorabilities() []ObligationMonitorability {
+	var monitorabilities []ObligationMonitorability
+	assumptions, guarantees := 0, 0
+	for _, region := range contract.regions {
+		for _, assumption := range region.assumptions {
+			monitorabilities = append(monitorabilities, ObligationMonitorability{
+				Kind:           "assumption",
+				Obligation:     Obligation{Index: assumptions, Label: obligationLabel(assumption)},
+				Monitorability: MonitorabilityOf(assumption),
+			})
+			assumptions++
+		}
+		for _, guarantee := range region.guarantees {
+			monitorabilities = append(monitorabilities, ObligationMonitorability{
+				Kind:           "guarantee",
+				Obligation:     Obligation{Index: guarantees, Label: obligationLabel(guarantee)},
+				Monitorability: MonitorabilityOf(guarantee),
+			})
+			guarantees++
+		}
+	}
+	return monitorabilities
+}
+
+// obligationLabel returns the label of the assumption or guarantee or empty if
+// it is unlabelled.
+func obligationLabel(node Node) string {
+	switch cast := node.(type) {
+	case Assumption:
+		return cast.label
+	case Guarantee:
+		return cast.label
+	}
+	return ""
+}
+
+// Warning is a warning about the contract of a function at the line of a file.
+type Warning struct {
+	Path     string
+	Line     int
+	Function string
+	Message  string
+}
+
+func (warning Warning) String() string {
+	return fmt.Sprintf("%v:%v: %v: %v", warning.Path, warning.Line, warning.Function, warning.Message)
+}
+
+// Warnings returns a warning for every obligation of the contract of the
+// function at the line of the file which can never be violated at runtime.
+func (contract Contract) Warnings(path string, line int, function string) []Warning {
+	var warnings []Warning
+	for _, obligation := range contract.Monitorabilities() {
+		if obligation.Monitorability.Violations() {
+			continue
+		}
+
+		name := obligation.Obligation.String()
+		if obligation.Obligation.Label != "" {
+			name = strconv.Quote(obligation.Obligation.Label)
+		}
+		message := fmt.Sprintf(
+			"%v %v can never be violated at runtime: it is %v and its verdicts are %v",
+			obligation.Kind, name, obligation.Monitorability, describeVerdicts(obligation.Monitorability.Verdicts()),
+		)
+		warnings = append(warnings, Warning{path, line, function, message})
+	}
+	return warnings
+}
+
+// CheckSource returns the warnings of the contracts in the doc comments of the
+// functions in the Go source of the file at the path. Syntax errors of the
+// contracts are returned at the name of their function.
+func CheckSource(path string, source []byte) (warnings []Warning, err error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, source, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, decl := range file.Decls {
+		function, ok := decl.(*ast.FuncDecl)
+		if !ok || function.Doc == nil || !IsContract(function.Doc.Text()) {
+			continue
+		}
+
+		comments := make([]string, len(function.Doc.List))
+		for idx, comment := range function.Doc.List {
+			comments[idx] = comment.Text
+		}
+
+		line := fset.Position(function.Name.Pos()).Line
+		contract, err := parseContract(comments)
+		if err != nil {
+			return nil, fmt.Errorf("%v:%v: %v: %w", path, line, function.Name.Name, err)
+		}
+		warnings = append(warnings, contract.Warnings(path, line, function.Name.Name)...)
+	}
+
+	return warnings, nil
+}
+
+// parseContract parses the contract of the doc comment and returns its syntax
+// errors instead of panicking.
+func parseContract(comments []string) (contract Contract, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%v", recovered)
+		}
+	}()
+
+	parser := NewParser(LexDocStrings(comments))
+	return parser.Parse(), nil
+}
+
+// describeVerdicts names the verdicts, e.g. "true, false or unknown".
+func describeVerdicts(verdicts []LiftedBoolean) string {
+	names := make([]string, len(verdicts))
+	for idx, verdict := range verdicts {
+		names[idx] = verdict.String()
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
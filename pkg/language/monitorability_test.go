@@ -0,0 +1,116 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitorabilityOf(t *testing.T) {
+	tests := []struct {
+		description    string
+		source         string
+		monitorability Monitorability
+		verdicts       []LiftedBoolean
+	}{
+		{
+			description:    "universal",
+			source:         "guarantee: forall e0 e1. e0.x != e1.x || e0.ret0 == e1.ret0",
+			monitorability: ViolationMonitorable,
+			verdicts:       []LiftedBoolean{LiftedFalse, LiftedUnknown},
+		},
+		{
+			description:    "existential",
+			source:         "guarantee: exists e. e.ret0 == 0",
+			monitorability: SatisfactionMonitorable,
+			verdicts:       []LiftedBoolean{LiftedTrue, LiftedUnknown},
+		},
+		{
+			description:    "universal of existential",
+			source:         "guarantee: forall e0. exists e1. e1.x == e0.ret0",
+			monitorability: Unmonitorable,
+			verdicts:       []LiftedBoolean{LiftedUnknown},
+		},
+		{
+			description:    "existential of universal",
+			source:         "guarantee: exists e0. forall e1. e0.ret0 >= e1.ret0",
+			monitorability: Unmonitorable,
+		},
+		{
+			description:    "grouped universal",
+			source:         "guarantee: (forall e. e.ret0 > 0; )",
+			monitorability: ViolationMonitorable,
+		},
+		{
+			description:    "aggregate",
+			source:         "guarantee: mean t. t.ret1 <= 100",
+			monitorability: ViolationMonitorable,
+			verdicts:       []LiftedBoolean{LiftedFalse, LiftedUnknown},
+		},
+		{
+			description:    "existential of aggregate",
+			source:         "guarantee: exists e. count t. t.x == e.x >= 2",
+			monitorability: Unmonitorable,
+		},
+		{
+			description:    "estimate",
+			source:         "guarantee: probability t. t.ret0 >= 0.9",
+			monitorability: Monitorable,
+		},
+		{
+			description:    "universal of estimate",
+			source:         "guarantee: forall e. probability t. t.x == e.x >= 0.9",
+			monitorability: ViolationMonitorable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			parser := NewParser(LexString(tt.source))
+			monitorabilities := parser.Parse().Monitorabilities()
+			assert.Len(t, monitorabilities, 1)
+			assert.Equal(t, tt.monitorability, monitorabilities[0].Monitorability)
+			if tt.verdicts != nil {
+				assert.Equal(t, tt.verdicts, tt.monitorability.Verdicts())
+			}
+		})
+	}
+}
+
+func TestMonitorabilities(t *testing.T) {
+	parser := NewParser(LexString(`assume: forall e. e.x >= 0
+guarantee "Deterministic": forall e0 e1. e0.x != e1.x || e0.ret0 == e1.ret0
+region Negative:
+assume: exists e. e.x < 0
+guarantee "Chained": forall e0. exists e1. e1.x == e0.ret0`))
+
+	assert.Equal(t, []ObligationMonitorability{
+		{"assumption", Obligation{Index: 0}, ViolationMonitorable},
+		{"guarantee", Obligation{Index: 0, Label: "Deterministic"}, ViolationMonitorable},
+		{"assumption", Obligation{Index: 1}, SatisfactionMonitorable},
+		{"guarantee", Obligation{Index: 1, Label: "Chained"}, Unmonitorable},
+	}, parser.Parse().Monitorabilities())
+}
+
+func TestCheckSource(t *testing.T) {
+	source := `package p
+
+// guarantee: forall e. e.ret0 > 0
+func Positive() int { return 1 }
+
+// assume: exists e. e.x == 0
+// guarantee "Chained": forall e0. exists e1. e1.x == e0.ret0
+func Chain(x int) int { return x }
+`
+
+	warnings, err := CheckSource("p.go", []byte(source))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"p.go:8: Chain: assumption #0 can never be violated at runtime: it is monitorable for satisfaction and its verdicts are true or unknown",
+		`p.go:8: Chain: guarantee "Chained" can never be violated at runtime: it is unmonitorable and its verdicts are unknown`,
+	}, []string{warnings[0].String(), warnings[1].String()})
+	assert.Len(t, warnings, 2)
+
+	_, err = CheckSource("p.go", []byte("package p\n\n// guarantee: forall e.\nfunc F() {}\n"))
+	assert.ErrorContains(t, err, "p.go:4: F: ")
+}
@@ -0,0 +1,677 @@
+package language
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"iter"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+)
+
+// MutationOperator is the kind of source change of a mutant.
+type MutationOperator uint8
+
+const (
+	// MutationOperatorReplacement replaces arithmetic, relational and logical operators.
+	MutationOperatorReplacement = MutationOperator(iota)
+	// MutationConstantChange changes integer and boolean constants.
+	MutationConstantChange
+	// MutationConditionNegation negates branch and loop conditions.
+	MutationConditionNegation
+	// MutationReturnSwap swaps adjacent return values of the same type.
+	MutationReturnSwap
+)
+
+func (operator MutationOperator) String() string {
+	switch operator {
+	case MutationOperatorReplacement:
+		return "operator"
+	case MutationConstantChange:
+		return "constant"
+	case MutationConditionNegation:
+		return "negation"
+	case MutationReturnSwap:
+		return "swap"
+	}
+	return strconv.Itoa(int(operator))
+}
+
+// replacements are the operators each operator is replaced by.
+var replacements = map[token.Token]token.Token{
+	token.ADD:        token.SUB,
+	token.SUB:        token.ADD,
+	token.MUL:        token.QUO,
+	token.QUO:        token.MUL,
+	token.REM:        token.MUL,
+	token.LSS:        token.LEQ,
+	token.LEQ:        token.LSS,
+	token.GTR:        token.GEQ,
+	token.GEQ:        token.GTR,
+	token.EQL:        token.NEQ,
+	token.NEQ:        token.EQL,
+	token.LAND:       token.LOR,
+	token.LOR:        token.LAND,
+	token.AND:        token.OR,
+	token.OR:         token.AND,
+	token.ADD_ASSIGN: token.SUB_ASSIGN,
+	token.SUB_ASSIGN: token.ADD_ASSIGN,
+	token.INC:        token.DEC,
+	token.DEC:        token.INC,
+}
+
+// Mutant is a single source change of a contracted function.
+type Mutant struct {
+	ID       int
+	Operator MutationOperator
+	Function string
+	Position string
+	// Original and Mutation are the source before and after the change.
+	Original string
+	Mutation string
+}
+
+// mutation is a mutant which can be applied to and reverted from the tree.
+type mutation struct {
+	Mutant
+	apply  func()
+	revert func()
+}
+
+// mutations returns every mutation of the body of the function. The describe
+// function returns the position and source of a node for reporting.
+func (injector Injector) mutations(function *dst.FuncDecl, describe func(dst.Node) (string, string)) []mutation {
+	if function.Body == nil || function.Type.Results == nil {
+		return nil
+	}
+
+	var mutations []mutation
+	add := func(operator MutationOperator, node dst.Node, original, mutated string, apply, revert func()) {
+		position, _ := describe(node)
+		mutations = append(mutations, mutation{
+			Mutant: Mutant{
+				ID:       len(mutations),
+				Operator: operator,
+				Function: function.Name.Name,
+				Position: position,
+				Original: original,
+				Mutation: mutated,
+			},
+			apply:  apply,
+			revert: revert,
+		})
+	}
+
+	operator := func(node dst.Node, field *token.Token) {
+		original := *field
+		replacement, exists := replacements[original]
+		if !exists {
+			return
+		}
+		add(MutationOperatorReplacement, node, original.String(), replacement.String(),
+			func() { *field = replacement }, func() { *field = original })
+	}
+
+	negate := func(node dst.Node, field *dst.Expr) {
+		original := *field
+		_, expression := describe(original)
+		add(MutationConditionNegation, node, expression, "!("+expression+")", func() {
+			*field = &dst.UnaryExpr{Op: token.NOT, X: &dst.ParenExpr{X: original}}
+		}, func() {
+			*field = original
+		})
+	}
+
+	var types []string
+	for _, field := range function.Type.Results.List {
+		for range max(len(field.Names), 1) {
+			_, typ := describe(field.Type)
+			types = append(types, typ)
+		}
+	}
+
+	dst.Inspect(function.Body, func(node dst.Node) bool {
+		switch cast := node.(type) {
+		case *dst.FuncLit:
+			// Closures have their own results and are not part of the contract.
+			return false
+		case *dst.BinaryExpr:
+			operator(cast, &cast.Op)
+		case *dst.AssignStmt:
+			operator(cast, &cast.Tok)
+		case *dst.IncDecStmt:
+			operator(cast, &cast.Tok)
+		case *dst.BasicLit:
+			if cast.Kind != token.INT {
+				return true
+			}
+			value, err := strconv.ParseInt(cast.Value, 0, 64)
+			if err != nil {
+				return true
+			}
+			original := cast.Value
+			changes := []int64{value + 1}
+			if value > 0 {
+				changes = append(changes, value-1)
+			}
+			for _, change := range changes {
+				mutated := strconv.FormatInt(change, 10)
+				add(MutationConstantChange, cast, original, mutated,
+					func() { cast.Value = mutated }, func() { cast.Value = original })
+			}
+		case *dst.Ident:
+			// Only the predeclared constants are mutated as the tree is untyped.
+			if cast.Path != "" || (cast.Name != "true" && cast.Name != "false") {
+				return true
+			}
+			original := cast.Name
+			mutated := map[string]string{"true": "false", "false": "true"}[original]
+			add(MutationConstantChange, cast, original, mutated,
+				func() { cast.Name = mutated }, func() { cast.Name = original })
+		case *dst.IfStmt:
+			negate(cast, &cast.Cond)
+		case *dst.ForStmt:
+			if cast.Cond != nil {
+				negate(cast, &cast.Cond)
+			}
+		case *dst.ReturnStmt:
+			if len(cast.Results) != len(types) {
+				return true
+			}
+			for idx := 0; idx+1 < len(cast.Results); idx++ {
+				if types[idx] != types[idx+1] {
+					continue
+				}
+				left, right := cast.Results[idx], cast.Results[idx+1]
+				_, leftExpression := describe(left)
+				_, rightExpression := describe(right)
+				add(MutationReturnSwap, cast,
+					leftExpression+", "+rightExpression, rightExpression+", "+leftExpression,
+					func() { cast.Results[idx], cast.Results[idx+1] = right, left },
+					func() { cast.Results[idx], cast.Results[idx+1] = left, right })
+			}
+		}
+		return true
+	})
+
+	return mutations
+}
+
+// MutationHarness generates a test file checking every guarantee of the
+// function with the property harness in a subtest of its own such that the
+// guarantees killing a mutant can be told apart. The guarantees are returned
+// in the order of the subtests. False is returned if the function has no
+// guarantees, a composite contract, no outputs or cannot be called from a
+// generated test.
+func (injector Injector) MutationHarness(packageName string, function *dst.FuncDecl, seed uint64) (*dst.File, []Node, bool) {
+	if function.Recv != nil || function.Type.TypeParams != nil || function.Type.Results == nil {
+		return nil, nil, false
+	}
+	for _, field := range function.Type.Params.List {
+		if _, variadic := field.Type.(*dst.Ellipsis); variadic {
+			return nil, nil, false
+		}
+	}
+
+	comments := function.Decs.NodeDecs.Start
+	if len(comments) == 0 {
+		return nil, nil, false
+	}
+	parser := NewParser(LexDocStrings(comments))
+	contract := parser.Parse()
+	if len(contract.composition) > 0 || len(contract.regions) == 0 || len(contract.regions[0].guarantees) == 0 {
+		return nil, nil, false
+	}
+	region := contract.regions[0]
+
+	name := function.Name.Name
+	modelName := name + "_MutationModel"
+	contractsName := name + "_MutationContracts"
+
+	_, model := injector.Model(function)
+	model.Specs[0].(*dst.TypeSpec).Name = dst.NewIdent(modelName)
+
+	contracts := make([]dst.Expr, len(region.guarantees))
+	for idx, guarantee := range region.guarantees {
+		contracts[idx] = injector.Constructor(modelName, region.assumptions, []Node{guarantee})
+		contracts[idx].Decorations().Before = dst.NewLine
+		contracts[idx].Decorations().After = dst.NewLine
+	}
+
+	contractsDecl := &dst.GenDecl{
+		Tok: token.VAR,
+		Specs: []dst.Spec{
+			&dst.ValueSpec{
+				Names: []*dst.Ident{dst.NewIdent(contractsName)},
+				Values: []dst.Expr{
+					&dst.CompositeLit{
+						Type: &dst.ArrayType{
+							Elt: &dst.IndexExpr{
+								X:     &dst.SelectorExpr{X: dst.NewIdent("sopher"), Sel: dst.NewIdent("AGHyperContract")},
+								Index: dst.NewIdent(modelName),
+							},
+						},
+						Elts: contracts,
+					},
+				},
+			},
+		},
+	}
+
+	var arguments, outputs []string
+	for _, input := range function.Type.Params.List {
+		for _, identifier := range input.Names {
+			arguments = append(arguments, "e."+identifier.Name)
+		}
+	}
+	for _, output := range injector.OutputFields(function) {
+		for _, identifier := range output.Names {
+			outputs = append(outputs, "e."+identifier.Name)
+		}
+	}
+
+	source := fmt.Sprintf(`package p
+
+func %[7]s_Mutation(t *testing.T) {
+	call := func(e %[2]s) %[2]s {
+		%[3]s = %[1]s(%[4]s)
+		return e
+	}
+	for idx, contract := range %[5]s {
+		t.Run(fmt.Sprintf("guarantee%%v", idx), func(t *testing.T) {
+			sopher.Check(t, contract, call, sopher.WithSeed(%[6]v))
+		})
+	}
+}`, name, modelName, strings.Join(outputs, ", "), strings.Join(arguments, ", "), contractsName, seed, testName(name))
+
+	test, err := decorator.Parse(source)
+	if err != nil {
+		panic(err)
+	}
+
+	file := &dst.File{
+		Name: dst.NewIdent(packageName),
+		Decls: []dst.Decl{
+			&dst.GenDecl{
+				Tok: token.IMPORT,
+				Specs: []dst.Spec{
+					&dst.ImportSpec{Path: &dst.BasicLit{Kind: token.STRING, Value: "\"fmt\""}},
+					&dst.ImportSpec{
+						Name: dst.NewIdent("quick"),
+						Path: &dst.BasicLit{Kind: token.STRING, Value: strconv.Quote(quickPath)},
+					},
+					&dst.ImportSpec{Path: &dst.BasicLit{Kind: token.STRING, Value: "\"testing\""}},
+					&dst.ImportSpec{
+						Name: dst.NewIdent("sopher"),
+						Path: &dst.BasicLit{Kind: token.STRING, Value: "\"github.com/hyperproperties/sopher/pkg/language\""},
+					},
+				},
+			},
+			model,
+			contractsDecl,
+			test.Decls[0],
+		},
+	}
+
+	// Bias the generation towards the literals such that mutated constants
+	// and boundaries are reached by the harness.
+	if interesting, ok := injector.Interesting(Literals(nil, function, contract)); ok {
+		file.Decls = append(file.Decls, interesting)
+	} else {
+		file.Decls[0].(*dst.GenDecl).Specs = slices.Delete(file.Decls[0].(*dst.GenDecl).Specs, 1, 2)
+	}
+
+	return file, region.guarantees, true
+}
+
+// MutantStatus is the result of running the harness against a mutant.
+type MutantStatus uint8
+
+const (
+	// MutantSurvived mutants were not detected by any guarantee.
+	MutantSurvived = MutantStatus(iota)
+	// MutantKilled mutants violated at least one guarantee.
+	MutantKilled
+	// MutantCrashed mutants panicked or timed out while being checked.
+	MutantCrashed
+	// MutantInvalid mutants do not compile.
+	MutantInvalid
+)
+
+func (status MutantStatus) String() string {
+	switch status {
+	case MutantSurvived:
+		return "survived"
+	case MutantKilled:
+		return "killed"
+	case MutantCrashed:
+		return "crashed"
+	case MutantInvalid:
+		return "invalid"
+	}
+	return strconv.Itoa(int(status))
+}
+
+// MutantResult is the outcome of a mutant where KilledBy tells which of the
+// guarantees of the contract were violated by it.
+type MutantResult struct {
+	Mutant
+	Status   MutantStatus
+	KilledBy []bool
+}
+
+// MutationScore is the results of all mutants of a contracted function.
+type MutationScore struct {
+	Function   string
+	Guarantees []Node
+	Results    []MutantResult
+}
+
+// Mutator runs mutation testing of contracted functions.
+type Mutator struct {
+	injector Injector
+	// Seed is the seed of the property harness shared by all mutants.
+	Seed uint64
+	// Timeout is the duration after which a mutant is considered crashed.
+	Timeout time.Duration
+	// Progress is written a line for each mutant if not nil.
+	Progress io.Writer
+}
+
+func NewMutator() *Mutator {
+	return &Mutator{
+		injector: NewGoInjector(),
+		Timeout:  time.Minute,
+	}
+}
+
+// Files applies every mutant of the contracted functions of the files one at
+// a time and checks it against each guarantee with the property harness. The
+// files are restored to their original content afterwards, also if mutation
+// testing is interrupted, and an error is returned if they could not be.
+func (mutator *Mutator) Files(files iter.Seq[string]) ([]MutationScore, error) {
+	// An interrupt stops the mutant being run such that the file it was
+	// applied to is restored before returning.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var scores []MutationScore
+
+	for path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		fset := token.NewFileSet()
+		decor := decorator.NewDecorator(fset)
+		file, err := decor.ParseFile(filepath.Base(path), content, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+
+		describe := func(node dst.Node) (string, string) {
+			original, exists := decor.Ast.Nodes[node]
+			if !exists {
+				return filepath.Base(path), ""
+			}
+
+			var buffer bytes.Buffer
+			printer.Fprint(&buffer, fset, original)
+			return fset.Position(original.Pos()).String(), buffer.String()
+		}
+
+		for _, decl := range file.Decls {
+			function, ok := decl.(*dst.FuncDecl)
+			if !ok {
+				continue
+			}
+
+			score, ok, err := mutator.function(ctx, path, content, file, function, describe)
+			if err != nil {
+				return scores, err
+			}
+			if ok {
+				scores = append(scores, score)
+			}
+		}
+	}
+
+	return scores, nil
+}
+
+// function writes the harness of the function next to the file at the path,
+// checks every mutant of the function against it and restores the content of
+// the file and removes the harness afterwards. An existing harness is not
+// overwritten.
+func (mutator *Mutator) function(
+	ctx context.Context, path string, content []byte, file *dst.File, function *dst.FuncDecl,
+	describe func(dst.Node) (string, string),
+) (MutationScore, bool, error) {
+	harness, guarantees, ok := mutator.injector.MutationHarness(file.Name.Name, function, mutator.Seed)
+	if !ok {
+		return MutationScore{}, false, nil
+	}
+
+	directory := filepath.Dir(path)
+	harnessPath := filepath.Join(directory, strings.ToLower(function.Name.Name)+"_mutation_sopher_test.go")
+	if err := writeHarness(harnessPath, harness); err != nil {
+		return MutationScore{}, false, err
+	}
+
+	score, ok, err := mutator.mutants(ctx, path, file, function, guarantees, describe)
+
+	if restoreErr := os.WriteFile(path, content, 0o644); restoreErr != nil {
+		err = errors.Join(err, fmt.Errorf("restoring %v: %w", path, restoreErr))
+	}
+	if removeErr := os.Remove(harnessPath); removeErr != nil {
+		err = errors.Join(err, fmt.Errorf("removing the harness: %w", removeErr))
+	}
+	return score, ok && err == nil, err
+}
+
+// writeHarness writes the harness to the path unless a file exists there.
+func writeHarness(path string, harness *dst.File) error {
+	output, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("writing the harness: %w", err)
+	}
+
+	err = decorator.Fprint(output, harness)
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Join(fmt.Errorf("writing the harness %v: %w", path, err), os.Remove(path))
+	}
+	return nil
+}
+
+// mutants writes every mutant of the function to the file at the path and
+// runs the harness against it. The file is left with the last mutant.
+func (mutator *Mutator) mutants(
+	ctx context.Context, path string, file *dst.File, function *dst.FuncDecl, guarantees []Node,
+	describe func(dst.Node) (string, string),
+) (MutationScore, bool, error) {
+	directory := filepath.Dir(path)
+	score := MutationScore{
+		Function:   function.Name.Name,
+		Guarantees: guarantees,
+	}
+
+	// The unmutated function must satisfy its contract for the mutants to be
+	// meaningful.
+	original, err := mutator.run(ctx, directory, function.Name.Name, len(guarantees))
+	if err != nil {
+		return score, false, err
+	}
+	if original.Status != MutantSurvived {
+		return score, false, fmt.Errorf("%v does not satisfy its contract before mutation", function.Name.Name)
+	}
+
+	for _, mutation := range mutator.injector.mutations(function, describe) {
+		mutation.apply()
+		var buffer bytes.Buffer
+		err := decorator.Fprint(&buffer, file)
+		mutation.revert()
+		if err != nil {
+			return score, false, err
+		}
+
+		if err := os.WriteFile(path, buffer.Bytes(), 0o644); err != nil {
+			return score, false, err
+		}
+
+		result, err := mutator.run(ctx, directory, function.Name.Name, len(guarantees))
+		if err != nil {
+			return score, false, err
+		}
+		result.Mutant = mutation.Mutant
+		score.Results = append(score.Results, result)
+
+		if mutator.Progress != nil {
+			fmt.Fprintf(mutator.Progress, "%v mutant %v (%v %v -> %v at %v): %v\n",
+				mutation.Function, mutation.ID, mutation.Operator, mutation.Original, mutation.Mutation,
+				mutation.Position, result.Status)
+		}
+	}
+
+	return score, true, nil
+}
+
+// run runs the harness of the function in the directory and classifies the
+// outcome by the subtests that failed.
+func (mutator *Mutator) run(ctx context.Context, directory, function string, guarantees int) (MutantResult, error) {
+	command := exec.CommandContext(
+		ctx, "go", "test", "-count=1", "-json", "-timeout="+mutator.Timeout.String(),
+		"-run", fmt.Sprintf("^%v_Mutation$", testName(function)), ".",
+	)
+	command.Dir = directory
+
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	stdout, runErr := command.Output()
+	if ctx.Err() != nil {
+		return MutantResult{}, fmt.Errorf("mutation testing of %v was interrupted: %w", function, ctx.Err())
+	}
+
+	result := MutantResult{KilledBy: make([]bool, guarantees)}
+	if runErr != nil {
+		if _, exited := runErr.(*exec.ExitError); !exited {
+			return result, runErr
+		}
+	}
+
+	crashed, ran := false, false
+	for _, line := range bytes.Split(stdout, []byte("\n")) {
+		var event struct {
+			Action string
+			Test   string
+			Output string
+		}
+		if json.Unmarshal(line, &event) != nil {
+			continue
+		}
+
+		if strings.Contains(event.Output, "panic:") {
+			crashed = true
+		}
+		if event.Test != "" {
+			ran = true
+		}
+
+		subtest, isSubtest := strings.CutPrefix(event.Test, fmt.Sprintf("%v_Mutation/guarantee", testName(function)))
+		if event.Action != "fail" || !isSubtest {
+			continue
+		}
+		if idx, err := strconv.Atoi(subtest); err == nil && idx < guarantees {
+			result.KilledBy[idx] = true
+			result.Status = MutantKilled
+		}
+	}
+
+	switch {
+	case !ran:
+		if runErr == nil {
+			return result, fmt.Errorf("the harness of %v was not run", function)
+		}
+		result.Status = MutantInvalid
+	case crashed:
+		result.Status = MutantCrashed
+	case runErr != nil && result.Status != MutantKilled:
+		return result, fmt.Errorf("the harness of %v failed:\n%s%s", function, stdout, stderr.Bytes())
+	}
+
+	return result, nil
+}
+
+// FprintMutations writes for every guarantee of the contracts the number of
+// mutants it killed followed by the mutants surviving it.
+func FprintMutations(writer io.Writer, scores []MutationScore) error {
+	for _, score := range scores {
+		var valid []MutantResult
+		counts := make(map[MutantStatus]int)
+		for _, result := range score.Results {
+			counts[result.Status]++
+			if result.Status == MutantKilled || result.Status == MutantSurvived {
+				valid = append(valid, result)
+			}
+		}
+
+		fmt.Fprintf(writer, "%v: %v mutants, %v killed, %v survived, %v crashed, %v invalid\n",
+			score.Function, len(score.Results), counts[MutantKilled], counts[MutantSurvived], counts[MutantCrashed], counts[MutantInvalid])
+
+		for idx, guarantee := range score.Guarantees {
+			killed := 0
+			var survivors []MutantResult
+			for _, result := range valid {
+				if result.KilledBy[idx] {
+					killed++
+				} else {
+					survivors = append(survivors, result)
+				}
+			}
+
+			mutationScore := "-"
+			if len(valid) > 0 {
+				mutationScore = fmt.Sprintf("%.1f%%", 100*float64(killed)/float64(len(valid)))
+			}
+			fmt.Fprintf(writer, "\n  %v\n  killed %v/%v (%v)\n", Print(guarantee), killed, len(valid), mutationScore)
+
+			if len(survivors) == 0 {
+				continue
+			}
+
+			table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(table, "    MUTANT\tOPERATOR\tPOSITION\tORIGINAL\tMUTATION")
+			for _, survivor := range survivors {
+				fmt.Fprintf(table, "    %v\t%v\t%v\t%v\t%v\n",
+					survivor.ID, survivor.Operator, survivor.Position, survivor.Original, survivor.Mutation)
+			}
+			if err := table.Flush(); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintln(writer)
+	}
+
+	return nil
+}
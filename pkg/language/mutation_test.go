@@ -0,0 +1,156 @@
+package language
+
+import (
+	"bytes"
+	"context"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/stretchr/testify/assert"
+)
+
+const mutationSource = `package examples
+
+// guarantee: forall e. e.ret0 <= e.ret1
+func Order(a int, b int) (int, int) {
+	if a < b {
+		return a, b
+	}
+	return b, a + 1
+}`
+
+func TestMutations(t *testing.T) {
+	file, err := decorator.Parse(mutationSource)
+	assert.NoError(t, err)
+	function := file.Decls[0].(*dst.FuncDecl)
+
+	var before bytes.Buffer
+	assert.NoError(t, decorator.Fprint(&before, file))
+
+	mutations := NewGoInjector().mutations(function, func(dst.Node) (string, string) {
+		return "", "int"
+	})
+
+	operators := make(map[MutationOperator]int)
+	for _, mutation := range mutations {
+		operators[mutation.Operator]++
+	}
+	assert.Equal(t, map[MutationOperator]int{
+		MutationConditionNegation:   1,
+		MutationOperatorReplacement: 2,
+		MutationConstantChange:      2,
+		MutationReturnSwap:          2,
+	}, operators)
+
+	for _, mutation := range mutations {
+		mutation.apply()
+		var mutated bytes.Buffer
+		assert.NoError(t, decorator.Fprint(&mutated, file))
+		assert.NotEqual(t, before.String(), mutated.String(), mutation.Operator.String())
+
+		mutation.revert()
+		var reverted bytes.Buffer
+		assert.NoError(t, decorator.Fprint(&reverted, file))
+		assert.Equal(t, before.String(), reverted.String(), mutation.Operator.String())
+	}
+}
+
+func TestMutationHarness(t *testing.T) {
+	file, err := decorator.Parse(mutationSource)
+	assert.NoError(t, err)
+
+	harness, guarantees, ok := NewGoInjector().MutationHarness("examples", file.Decls[0].(*dst.FuncDecl), 7)
+	assert.True(t, ok)
+	assert.Len(t, guarantees, 1)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, decorator.Fprint(&buffer, harness))
+	source := buffer.String()
+
+	assert.Contains(t, source, "type Order_MutationModel struct")
+	assert.Contains(t, source, "var Order_MutationContracts = []sopher.AGHyperContract[Order_MutationModel]{")
+	assert.Contains(t, source, "e.ret0, e.ret1 = Order(e.a, e.b)")
+	assert.Contains(t, source, "sopher.Check(t, contract, call, sopher.WithSeed(7))")
+	assert.Contains(t, source, "quick.Interesting(int64(1))")
+
+	file, err = decorator.Parse("package examples\n\n// guarantee: forall e. true\nfunc Skip(a int) {}")
+	assert.NoError(t, err)
+	_, _, ok = NewGoInjector().MutationHarness("examples", file.Decls[0].(*dst.FuncDecl), 7)
+	assert.False(t, ok, "functions without outputs cannot be checked")
+}
+
+func TestFprintMutations(t *testing.T) {
+	parser := NewParser(LexString("guarantee: forall e. e.ret0 <= e.ret1\nguarantee: forall e. e.ret0 >= 0"))
+	contract := parser.Parse()
+
+	scores := []MutationScore{
+		{
+			Function:   "Order",
+			Guarantees: contract.regions[0].guarantees,
+			Results: []MutantResult{
+				{
+					Mutant:   Mutant{ID: 0, Operator: MutationConditionNegation, Position: "order.go:5:2", Original: "a < b", Mutation: "!(a < b)"},
+					Status:   MutantKilled,
+					KilledBy: []bool{true, false},
+				},
+				{
+					Mutant:   Mutant{ID: 1, Operator: MutationOperatorReplacement, Position: "order.go:5:5", Original: "<", Mutation: "<="},
+					Status:   MutantSurvived,
+					KilledBy: []bool{false, false},
+				},
+				{
+					Mutant:   Mutant{ID: 2, Operator: MutationConstantChange},
+					Status:   MutantInvalid,
+					KilledBy: []bool{false, false},
+				},
+			},
+		},
+	}
+
+	var builder strings.Builder
+	assert.NoError(t, FprintMutations(&builder, scores))
+	report := builder.String()
+
+	assert.Contains(t, report, "Order: 3 mutants, 1 killed, 1 survived, 0 crashed, 1 invalid")
+	assert.Contains(t, report, "guarantee: forall e. e.ret0 <= e.ret1;\n  killed 1/2 (50.0%)")
+	assert.Contains(t, report, "guarantee: forall e. e.ret0 >= 0;\n  killed 0/2 (0.0%)")
+	assert.Equal(t, 1, strings.Count(report, "order.go:5:2"), "the negation only survives the second guarantee")
+	assert.Equal(t, 2, strings.Count(report, "order.go:5:5"))
+}
+
+func TestMutatorRestores(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "order.go")
+	assert.NoError(t, os.WriteFile(path, []byte(mutationSource), 0o644))
+	harness := filepath.Join(directory, "order_mutation_sopher_test.go")
+
+	// An existing harness is not overwritten.
+	assert.NoError(t, os.WriteFile(harness, []byte("package examples\n"), 0o644))
+	_, err := NewMutator().Files(slices.Values([]string{path}))
+	assert.ErrorIs(t, err, os.ErrExist)
+	content, err := os.ReadFile(harness)
+	assert.NoError(t, err)
+	assert.Equal(t, "package examples\n", string(content))
+	assert.NoError(t, os.Remove(harness))
+
+	// An interrupted run restores the file and removes the harness.
+	file, err := decorator.NewDecorator(token.NewFileSet()).ParseFile("order.go", mutationSource, parser.ParseComments)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, os.WriteFile(path, []byte("package examples\n\n// mutant\n"), 0o644))
+	_, ok, err := NewMutator().function(ctx, path, []byte(mutationSource), file, file.Decls[0].(*dst.FuncDecl), nil)
+	assert.False(t, ok)
+	assert.ErrorIs(t, err, context.Canceled)
+	content, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, mutationSource, string(content))
+	assert.NoFileExists(t, harness)
+}
@@ -0,0 +1,139 @@
+package language
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Order is a temporal predicate on the elements assigned to two variables of
+// a quantifier which its body requires, e.g. next(e0, e1) of the universal
+// forall e0 e1. !next(e0, e1) || e1.attempt == e0.attempt + 1, such that only
+// the assignments of elements it orders are evaluated. The elements it orders
+// after an element assigned to the left variable are consecutive in the order
+// they were made: every later element for before, the element right after it
+// for next, and the elements made at most the duration after it for within,
+// which are found by binary search of their times. The variables are
+// positions in the quantifier.
+type Order struct {
+	left, right int
+	predicate   string
+	duration    time.Duration
+}
+
+// NewOrder returns the order of the predicate, either "before", "next" or
+// "within", of the elements assigned to the left and right variable. The
+// duration is only used by within.
+func NewOrder(left, right int, predicate string, duration time.Duration) *Order {
+	return &Order{
+		left:      left,
+		right:     right,
+		predicate: predicate,
+		duration:  duration,
+	}
+}
+
+// span returns the positions from and to which the elements are ordered after
+// the element at the position of the left variable by the predicate. The
+// times are those of the elements or nil if they were made at the same time.
+func (order *Order) span(left int, times []time.Time, elements int) (int, int) {
+	switch order.predicate {
+	case "before":
+		return left + 1, elements
+	case "next":
+		return left + 1, min(left+2, elements)
+	}
+	if order.duration < 0 {
+		return left, left
+	}
+	if times == nil {
+		return left, elements
+	}
+	return left, left + sort.Search(elements-left, func(idx int) bool {
+		return times[left+idx].Sub(times[left]) > order.duration
+	})
+}
+
+// ordering is the temporal predicate on two variables of a quantifier
+// required by its body. The variables are positions in the quantifier and the
+// duration of within is a Go expression.
+type ordering struct {
+	left, right         int
+	predicate, duration string
+}
+
+// orderingOf returns the temporal predicate on two of the variables which the
+// Go expression requires. A universal is satisfied by the assignments it does
+// not order, e.g. by !before(e0, e1) || ..., and an existential is violated by
+// them, e.g. by before(e0, e1) && .... The duration of within is of no bound
+// variable, such that it is the same for every assignment, and false is
+// returned if no variables are ordered.
+func orderingOf(code string, variables, bound []string, universal bool) (ordering, bool) {
+	expression, err := parser.ParseExpr(code)
+	if err != nil {
+		return ordering{}, false
+	}
+
+	operator := token.LAND
+	if universal {
+		operator = token.LOR
+	}
+
+	for _, operand := range operands(expression, operator) {
+		operand = unparen(operand)
+		if universal {
+			unary, ok := operand.(*ast.UnaryExpr)
+			if !ok || unary.Op != token.NOT {
+				continue
+			}
+			operand = unparen(unary.X)
+		}
+		call, ok := operand.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		function, ok := call.Fun.(*ast.Ident)
+		if !ok || (function.Name != "before" && function.Name != "next" && function.Name != "within") {
+			continue
+		}
+
+		arity := 2
+		if function.Name == "within" {
+			arity = 3
+		}
+		if len(call.Args) != arity {
+			continue
+		}
+		left, right := -1, -1
+		if identifier, ok := call.Args[0].(*ast.Ident); ok {
+			left = slices.Index(variables, identifier.Name)
+		}
+		if identifier, ok := call.Args[1].(*ast.Ident); ok {
+			right = slices.Index(variables, identifier.Name)
+		}
+		if left < 0 || right < 0 || left == right {
+			continue
+		}
+
+		found := ordering{left: left, right: right, predicate: function.Name}
+		if arity == 3 {
+			free := true
+			ast.Inspect(call.Args[2], func(node ast.Node) bool {
+				if identifier, ok := node.(*ast.Ident); ok && slices.Contains(bound, identifier.Name) {
+					free = false
+				}
+				return free
+			})
+			if !free {
+				continue
+			}
+			found.duration = printed(call.Args[2])
+		}
+		return found, true
+	}
+
+	return ordering{}, false
+}
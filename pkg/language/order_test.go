@@ -0,0 +1,153 @@
+package language
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderingOf(t *testing.T) {
+	tests := []struct {
+		description string
+		code        string
+		universal   bool
+		ordering    ordering
+		ok          bool
+	}{
+		{
+			description: "negated next",
+			code:        "!next(e0, e1) || e1.attempt == e0.attempt+1",
+			universal:   true,
+			ordering:    ordering{0, 1, "next", ""},
+			ok:          true,
+		},
+		{
+			description: "later disjunct",
+			code:        "e0.ret0 || !(before(e1, e0))",
+			universal:   true,
+			ordering:    ordering{1, 0, "before", ""},
+			ok:          true,
+		},
+		{
+			description: "existential within",
+			code:        "e0.ret0 && within(e0, e2, 15 * time.Minute)",
+			universal:   false,
+			ordering:    ordering{0, 2, "within", "15 * time.Minute"},
+			ok:          true,
+		},
+		{
+			description: "predicate of universal",
+			code:        "before(e0, e1) || e0.ret0",
+			universal:   true,
+		},
+		{
+			description: "negated predicate of existential",
+			code:        "!before(e0, e1) && e0.ret0",
+			universal:   false,
+		},
+		{
+			description: "disjunct of existential",
+			code:        "before(e0, e1) || e0.ret0",
+			universal:   false,
+		},
+		{
+			description: "bound variable",
+			code:        "!before(x, e1) || e1.ret0",
+			universal:   true,
+		},
+		{
+			description: "duration of bound variable",
+			code:        "!within(e0, e1, e0.timeout) || e1.ret0",
+			universal:   true,
+		},
+		{
+			description: "same variable",
+			code:        "!next(e0, e0) || e0.ret0",
+			universal:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			ordering, ok := orderingOf(tt.code, []string{"e0", "e1", "e2"}, []string{"x", "e0", "e1", "e2"}, tt.universal)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.ordering, ordering)
+			}
+		})
+	}
+}
+
+func TestOrderSpan(t *testing.T) {
+	start := time.Now()
+	times := []time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute), start.Add(5 * time.Minute)}
+
+	tests := []struct {
+		description string
+		order       *Order
+		times       []time.Time
+		left        int
+		from, to    int
+	}{
+		{"before", NewOrder(0, 1, "before", 0), times, 1, 2, 4},
+		{"next", NewOrder(0, 1, "next", 0), times, 1, 2, 3},
+		{"next of last", NewOrder(0, 1, "next", 0), times, 3, 4, 4},
+		{"within", NewOrder(0, 1, "within", 2*time.Minute), times, 1, 1, 3},
+		{"within untimed", NewOrder(0, 1, "within", time.Minute), nil, 1, 1, 4},
+		{"within negative duration", NewOrder(0, 1, "within", -time.Minute), times, 1, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			from, to := tt.order.span(tt.left, tt.times, len(times))
+			assert.Equal(t, tt.from, from)
+			assert.Equal(t, tt.to, to)
+		})
+	}
+}
+
+func TestOrderedQuantifiers(t *testing.T) {
+	type attempt struct {
+		attempt int
+	}
+
+	evaluations := 0
+	// Attempts increment on consecutive calls.
+	consecutive := NewOrderedUniversalHyperAssertion(0, 2, Asymmetric, NewOrder(0, 1, "next", 0), NewTemporalPredicateHyperAssertion(
+		func(assignments []attempt, timeline Timeline) bool {
+			evaluations++
+			e0, e1 := assignments[0], assignments[1]
+			return !timeline.Next(0, 1) || e1.attempt == e0.attempt+1
+		},
+	))
+	// Some attempt is retried within a minute.
+	retried := NewOrderedExistentialHyperAssertion(0, 2, NewOrder(0, 1, "within", time.Minute), NewTemporalPredicateHyperAssertion(
+		func(assignments []attempt, timeline Timeline) bool {
+			evaluations++
+			e0, e1 := assignments[0], assignments[1]
+			return timeline.Within(0, 1, time.Minute) && timeline.Before(0, 1) && e0.attempt == e1.attempt
+		},
+	))
+	interpreter := NewHyperAssertionInterpreter[attempt]()
+
+	// Only the adjacent pairs are evaluated.
+	evaluations = 0
+	assert.Equal(t, LiftedUnknown, interpreter.Satisfies(consecutive, []attempt{{1}, {2}, {3}, {4}}))
+	assert.Equal(t, 3, evaluations)
+	assert.Equal(t, LiftedFalse, interpreter.Satisfies(consecutive, []attempt{{1}, {2}, {4}}))
+
+	// Only the pair of the execution added to a history is evaluated.
+	evaluations = 0
+	assert.Equal(t, LiftedUnknown, interpreter.SatisfiesHistory(consecutive, []attempt{{1}, {2}, {3}, {4}}, nil, 3))
+	assert.Equal(t, 1, evaluations)
+
+	// Only the pairs of an attempt and those made within a minute of it are
+	// evaluated.
+	start := time.Now()
+	times := []time.Time{start, start.Add(2 * time.Minute), start.Add(4 * time.Minute), start.Add(4*time.Minute + time.Second)}
+	evaluations = 0
+	assert.Equal(t, LiftedUnknown, interpreter.SatisfiesTimed(retried, []attempt{{1}, {1}, {2}, {3}}, times))
+	assert.Equal(t, 5, evaluations)
+	assert.Equal(t, LiftedTrue, interpreter.SatisfiesTimed(retried, []attempt{{1}, {1}, {2}, {2}}, times))
+}
@@ -0,0 +1,182 @@
+package language
+
+import (
+	"iter"
+	"maps"
+	"math"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ParallelismVariable is the environment variable configuring the number of
+// workers evaluating the outermost quantifiers of the contracts of an
+// instrumented program, e.g. SOPHER_PARALLELISM=8. Zero uses a worker for
+// every core and the quantifiers are evaluated sequentially if it is unset.
+const ParallelismVariable = "SOPHER_PARALLELISM"
+
+// parallelism is the number of workers of the interpreters created.
+var parallelism atomic.Int64
+
+func init() {
+	workers, err := strconv.Atoi(os.Getenv(ParallelismVariable))
+	if err != nil {
+		workers = 1
+	}
+	SetParallelism(workers)
+}
+
+// SetParallelism sets the number of workers of the interpreters created
+// afterwards, including those of the contracts. One evaluates sequentially and
+// less than one uses a worker for every core.
+func SetParallelism(workers int) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	parallelism.Store(int64(workers))
+}
+
+// Parallelism returns the number of workers of the interpreters created.
+func Parallelism() int {
+	return int(parallelism.Load())
+}
+
+// batch is the number of assignments of an outermost quantifier evaluated by
+// a worker at a time.
+const batch = 256
+
+// sequential is the number of elements below which the outermost quantifier is
+// evaluated sequentially as starting the workers would take longer.
+const sequential = 64
+
+// parallel reports whether the quantifier bound at the offset is partitioned
+// across the workers.
+func (interpreter *HyperAssertionInterpreter[T]) parallel(offset int) bool {
+	return interpreter.workers > 1 && offset == 0 && len(interpreter.elements) >= sequential
+}
+
+// chunk is a part of the domain of an outermost quantifier. Evaluating it by
+// a worker reports whether it decided the quantifier.
+type chunk[T any] struct {
+	index    int
+	evaluate func(worker *HyperAssertionInterpreter[T]) bool
+}
+
+// partition evaluates the chunks of the domain of an outermost quantifier in
+// parallel by the workers and reports whether any chunk decided it. The result
+// and witness are those of the first chunk deciding it, such that they are the
+// same as if the chunks were evaluated in order, and the chunks after it are
+// cancelled. A panic of a chunk is raised again unless an earlier chunk
+// decided the quantifier.
+func (interpreter *HyperAssertionInterpreter[T]) partition(
+	chunks iter.Seq[func(worker *HyperAssertionInterpreter[T]) bool],
+) bool {
+	var (
+		mutex     sync.Mutex
+		group     sync.WaitGroup
+		first     atomic.Int64
+		result    LiftedBoolean
+		witness   []int
+		recovered any
+	)
+	first.Store(math.MaxInt64)
+
+	work := make(chan chunk[T])
+	for range interpreter.workers {
+		worker := interpreter.worker()
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for chunk := range work {
+				if first.Load() < int64(chunk.index) {
+					continue
+				}
+
+				decided, panicked := func() (decided bool, panicked any) {
+					defer func() {
+						panicked = recover()
+					}()
+					return chunk.evaluate(worker), nil
+				}()
+				if !decided && panicked == nil {
+					continue
+				}
+
+				mutex.Lock()
+				if int64(chunk.index) < first.Load() {
+					first.Store(int64(chunk.index))
+					result, witness, recovered = worker.result, worker.witness, panicked
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	index := 0
+	for evaluate := range chunks {
+		if first.Load() < int64(index) {
+			break
+		}
+		work <- chunk[T]{index, evaluate}
+		index++
+	}
+	close(work)
+	group.Wait()
+
+	if recovered != nil {
+		panic(recovered)
+	}
+	if first.Load() == math.MaxInt64 {
+		return false
+	}
+	interpreter.result, interpreter.witness = result, witness
+	return true
+}
+
+// worker returns an interpreter evaluating the assertion of the interpreter
+// sequentially with assignments of its own.
+func (interpreter *HyperAssertionInterpreter[T]) worker() *HyperAssertionInterpreter[T] {
+	worker := *interpreter
+	worker.workers = 1
+	worker.assignments = slices.Clone(interpreter.assignments)
+	worker.indices = slices.Clone(interpreter.indices)
+	worker.indexes = maps.Clone(interpreter.indexes)
+	worker.estimates = nil
+	worker.witness = nil
+	return &worker
+}
+
+// batches partitions the assignments into batches in their order.
+func batches(assignments iter.Seq[[]int]) iter.Seq[[][]int] {
+	return func(yield func([][]int) bool) {
+		var assigned [][]int
+		for assignment := range assignments {
+			assigned = append(assigned, assignment)
+			if len(assigned) == batch {
+				if !yield(assigned) {
+					return
+				}
+				assigned = nil
+			}
+		}
+		if len(assigned) > 0 {
+			yield(assigned)
+		}
+	}
+}
+
+// ranges partitions the positions of the elements into ranges in their order
+// such that every worker evaluates several of them.
+func ranges(elements, workers int) iter.Seq2[int, int] {
+	size := max(1, elements/(workers*8))
+	return func(yield func(from, to int) bool) {
+		for from := 0; from < elements; from += size {
+			if !yield(from, min(from+size, elements)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package language
+
+import (
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelInterpreter(t *testing.T) {
+	// Every two executions with the same input have the same output but for
+	// the last executions of some inputs.
+	executions := benchmarkExecutions(1000)
+	executions[700].output = -1
+	executions[900].output = -1
+	executions[950].output = -1
+
+	predicate := NewPredicateHyperAssertion(func(assignments []ioExecution) bool {
+		e0, e1 := assignments[0], assignments[1]
+		return e0.input != e1.input || e0.output == e1.output
+	})
+	input := func(e ioExecution) (any, bool) { return e.input, true }
+
+	tests := []struct {
+		description string
+		assertion   HyperAssertion[ioExecution]
+		result      LiftedBoolean
+		witness     []int
+	}{
+		{
+			description: "universal",
+			assertion:   NewUniversalHyperAssertion(0, 2, predicate),
+			result:      LiftedFalse,
+			witness:     []int{0, 700},
+		},
+		{
+			description: "symmetric universal",
+			assertion:   NewSymmetricUniversalHyperAssertion(0, 2, Symmetric|Reflexive, predicate),
+			result:      LiftedFalse,
+			witness:     []int{0, 700},
+		},
+		{
+			description: "joined universal",
+			assertion:   NewJoinedUniversalHyperAssertion(0, 2, Asymmetric, NewJoin(0, input, 1, input), predicate),
+			result:      LiftedFalse,
+			witness:     []int{0, 700},
+		},
+		{
+			description: "nested universal",
+			assertion:   NewUniversalHyperAssertion(0, 1, NewUniversalHyperAssertion(1, 1, predicate)),
+			result:      LiftedFalse,
+			witness:     []int{0, 700},
+		},
+		{
+			description: "existential",
+			assertion: NewExistentialHyperAssertion(0, 1, NewPredicateHyperAssertion(func(assignments []ioExecution) bool {
+				return assignments[0].output < 0
+			})),
+			result:  LiftedTrue,
+			witness: []int{700},
+		},
+		{
+			description: "compiled universal",
+			assertion:   compiledDeterminism(),
+			result:      LiftedFalse,
+			witness:     []int{0, 700},
+		},
+		{
+			description: "undecided universal",
+			assertion:   NewSymmetricUniversalHyperAssertion(0, 2, Symmetric|Reflexive, predicate),
+			result:      LiftedUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			elements := executions
+			if tt.witness == nil {
+				elements = benchmarkExecutions(1000)
+			}
+			for _, workers := range []int{1, 2, 3, 8} {
+				interpreter := NewParallelHyperAssertionInterpreter[ioExecution](workers)
+				assert.Equal(t, tt.result, interpreter.Satisfies(tt.assertion, elements), "%v workers", workers)
+				assert.Equal(t, tt.witness, interpreter.Witness(), "%v workers", workers)
+			}
+		})
+	}
+}
+
+func TestParallelCancellation(t *testing.T) {
+	var evaluations atomic.Int64
+	universal := NewUniversalHyperAssertion(0, 2, NewPredicateHyperAssertion(func(assignments []ioExecution) bool {
+		evaluations.Add(1)
+		return assignments[0].output == assignments[1].output
+	}))
+
+	// The first assignment violates the universal such that the chunks after
+	// those already evaluated are cancelled.
+	interpreter := NewParallelHyperAssertionInterpreter[ioExecution](4)
+	assert.Equal(t, LiftedFalse, interpreter.Satisfies(universal, benchmarkExecutions(1000)))
+	assert.Equal(t, []int{0, 1}, interpreter.Witness())
+	assert.Less(t, evaluations.Load(), int64(1000*1000/10))
+}
+
+func TestParallelPanic(t *testing.T) {
+	universal := NewUniversalHyperAssertion(0, 1, NewPredicateHyperAssertion(func(assignments []ioExecution) bool {
+		if assignments[0].input == 5 {
+			panic("five")
+		}
+		return assignments[0].output >= 0
+	}))
+	interpreter := NewParallelHyperAssertionInterpreter[ioExecution](4)
+	assert.PanicsWithValue(t, "five", func() {
+		interpreter.Satisfies(universal, benchmarkExecutions(1000))
+	})
+
+	// Panics after the first violation are never raised by a sequential
+	// interpreter.
+	executions := benchmarkExecutions(1000)
+	executions[3].output = -1
+	assert.Equal(t, LiftedFalse, interpreter.Satisfies(universal, executions))
+	assert.Equal(t, []int{3}, interpreter.Witness())
+}
+
+func TestSetParallelism(t *testing.T) {
+	defer SetParallelism(Parallelism())
+
+	SetParallelism(4)
+	assert.Equal(t, 4, Parallelism())
+	interpreter := NewHyperAssertionInterpreter[ioExecution]()
+	assert.Equal(t, 4, interpreter.workers)
+
+	SetParallelism(0)
+	assert.Equal(t, runtime.GOMAXPROCS(0), Parallelism())
+}
+
+func BenchmarkParallelUniversal(b *testing.B) {
+	deterministic := NewSymmetricUniversalHyperAssertion(0, 2, Symmetric|Reflexive, NewPredicateHyperAssertion(
+		func(assignments []ioExecution) bool {
+			e0, e1 := assignments[0], assignments[1]
+			return e0.input != e1.input || e0.output == e1.output
+		},
+	))
+	executions := benchmarkExecutions(1000)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(strconv.Itoa(workers), func(b *testing.B) {
+			interpreter := NewParallelHyperAssertionInterpreter[ioExecution](workers)
+			for range b.N {
+				interpreter.Satisfies(deterministic, executions)
+			}
+		})
+	}
+}
@@ -1,7 +1,14 @@
 package language
 
 import (
+	"fmt"
+	"go/ast"
+	goparser "go/parser"
+	"go/scanner"
+	"go/token"
 	"iter"
+	"strconv"
+	"strings"
 
 	"github.com/hyperproperties/sopher/pkg/iterx"
 )
@@ -54,6 +61,8 @@ func (parser *Parser) Parse() Contract {
 }
 
 func (parser *Parser) contract() Contract {
+	composition := parser.composition()
+
 	var regions []Region
 
 	if parser.match(AssumeToken, GuaranteeToken) {
@@ -69,7 +78,32 @@ func (parser *Parser) contract() Contract {
 		regions = append(regions, parser.region())
 	}
 
-	return NewContract(regions...)
+	return NewCompositeContract(composition, regions...)
+}
+
+// composition parses the functions named by the compose clause of a contract.
+func (parser *Parser) composition() (functions []string) {
+	if _, exists := parser.consume(ComposeToken); !exists {
+		return nil
+	}
+
+	if _, exists := parser.consume(ScopeDelimiterToken); !exists {
+		panic("compose expected a scope delimiter")
+	}
+
+	for {
+		function, exists := parser.consume(IdentifierToken)
+		if !exists {
+			panic("compose expected a function")
+		}
+		functions = append(functions, function.lexeme)
+
+		if _, exists := parser.consume(SeparatorToken); !exists {
+			break
+		}
+	}
+
+	return functions
 }
 
 func (parser *Parser) region() Region {
@@ -116,13 +150,15 @@ func (parser *Parser) assumption() Assumption {
 		panic("assume token expected for assumption")
 	}
 
+	label, _ := parser.consume(LabelToken)
+
 	if _, ok := parser.consume(ScopeDelimiterToken); !ok {
 		panic("assume expected scope delimiter toke")
 	}
 
 	assertion := parser.assertion()
 
-	return NewAssumption(assertion)
+	return NewLabelledAssumption(parser.label(label.lexeme), assertion)
 }
 
 func (parser *Parser) guarantee() Guarantee {
@@ -130,13 +166,27 @@ func (parser *Parser) guarantee() Guarantee {
 		panic("guarantee token expected for guarantee")
 	}
 
+	label, _ := parser.consume(LabelToken)
+
 	if _, ok := parser.consume(ScopeDelimiterToken); !ok {
 		panic("guarantee expected scope delimiter toke")
 	}
 
 	assertion := parser.assertion()
 
-	return NewGuarantee(assertion)
+	return NewLabelledGuarantee(parser.label(label.lexeme), assertion)
+}
+
+// label returns the label of an obligation which is either given after its
+// keyword or by a comment ending the obligation.
+func (parser *Parser) label(label string) string {
+	if trailing, ok := parser.consume(LabelToken); ok {
+		if label != "" {
+			panic("obligation is labelled twice")
+		}
+		return trailing.lexeme
+	}
+	return label
 }
 
 func (parser *Parser) assertion() Node {
@@ -145,14 +195,64 @@ func (parser *Parser) assertion() Node {
 		return parser.universal()
 	case parser.match(ExistsToken):
 		return parser.existential()
+	case parser.match(AggregateToken):
+		return parser.aggregate()
+	case parser.match(ProbabilityToken):
+		return parser.probability()
 	case parser.match(ExpressionToken):
-		return parser.expression()
+		return parser.constant(parser.expression())
 	case parser.match(LeftParenthesis):
 		return parser.group()
 	}
 	panic("unknown assertion")
 }
 
+// probability parses the probability of an event optionally given another,
+// e.g. "P(e.x; | e.y;)".
+func (parser *Parser) probability() Node {
+	if _, ok := parser.consume(ProbabilityToken); !ok {
+		panic("probability expected its opening")
+	}
+
+	event := parser.assertion()
+
+	var given Node
+	if _, conditional := parser.consume(GivenToken); conditional {
+		given = parser.assertion()
+	}
+
+	if _, ok := parser.consume(RightParenthesis); !ok {
+		panic("probability expected right parenthesis")
+	}
+
+	if given != nil {
+		return NewConditionalProbabilityQuantifier(event, given)
+	}
+	return NewProbabilisticQuantifier(event)
+}
+
+// constant returns the number of a Go expression which is a numeric literal and
+// the expression otherwise.
+func (parser *Parser) constant(expression Node) Node {
+	code := expression.(GoExpresion).code
+	literal, err := goparser.ParseExpr(code)
+	if err != nil {
+		return expression
+	}
+	if unary, ok := literal.(*ast.UnaryExpr); ok && unary.Op == token.SUB {
+		literal = unary.X
+	}
+	if basic, ok := literal.(*ast.BasicLit); !ok || (basic.Kind != token.INT && basic.Kind != token.FLOAT) {
+		return expression
+	}
+
+	number, err := strconv.ParseFloat(code, 32)
+	if err != nil {
+		return expression
+	}
+	return Number(float32(number))
+}
+
 func (parser *Parser) group() Group {
 	if _, ok := parser.consume(LeftParenthesis); !ok {
 		panic("group expected left parenthesis")
@@ -166,16 +266,37 @@ func (parser *Parser) group() Group {
 	return NewGroup(assertion)
 }
 
-func (parser *Parser) variables() (variables []string) {
+// variables parses the variables of a quantifier and their types. A type types
+// the variables since the previous separator, e.g. "r0 r1: Read, e: Erase", and
+// the types are nil if no variable is typed.
+func (parser *Parser) variables() (variables, types []string) {
+	typed, untyped := false, 0
 	for {
-		identifier, exists := parser.consume(IdentifierToken)
-		if !exists {
+		if identifier, exists := parser.consume(IdentifierToken); exists {
+			variables = append(variables, identifier.lexeme)
+			types = append(types, "")
+			untyped++
+		} else if _, exists := parser.consume(TypeDelimiterToken); exists {
+			function, exists := parser.consume(IdentifierToken)
+			if !exists || untyped == 0 {
+				panic("type delimiter expected variables before and a function after it")
+			}
+			for idx := len(types) - untyped; idx < len(types); idx++ {
+				types[idx] = function.lexeme
+			}
+			typed, untyped = true, 0
+		} else if _, exists := parser.consume(SeparatorToken); exists {
+			untyped = 0
+		} else {
 			break
 		}
-		variables = append(variables, identifier.lexeme)
 	}
 
-	return variables
+	if !typed {
+		types = nil
+	}
+
+	return variables, types
 }
 
 func (parser *Parser) universal() Universal {
@@ -183,7 +304,9 @@ func (parser *Parser) universal() Universal {
 		panic("forall token expected for universal quantifier")
 	}
 
-	variables := parser.variables()
+	_, distinct := parser.consume(DistinctToken)
+
+	variables, types := parser.variables()
 
 	if _, ok := parser.consume(ScopeDelimiterToken); !ok {
 		panic("expected scope delimiter toke")
@@ -191,7 +314,10 @@ func (parser *Parser) universal() Universal {
 
 	assertion := parser.assertion()
 
-	return NewUniversal(variables, assertion)
+	if distinct {
+		return NewDistinctUniversal(variables, types, assertion)
+	}
+	return NewTypedUniversal(variables, types, assertion)
 }
 
 func (parser *Parser) existential() Existential {
@@ -199,7 +325,7 @@ func (parser *Parser) existential() Existential {
 		panic("forall token expected for universal quantifier")
 	}
 
-	variables := parser.variables()
+	variables, types := parser.variables()
 
 	if _, ok := parser.consume(ScopeDelimiterToken); !ok {
 		panic("expected scope delimiter toke")
@@ -207,7 +333,7 @@ func (parser *Parser) existential() Existential {
 
 	assertion := parser.assertion()
 
-	return NewExistential(variables, assertion)
+	return NewTypedExistential(variables, types, assertion)
 }
 
 func (parser *Parser) expression() (expression Node) {
@@ -224,3 +350,102 @@ func (parser *Parser) expression() (expression Node) {
 		code: code.lexeme,
 	}
 }
+
+func (parser *Parser) aggregate() Aggregate {
+	function, ok := parser.consume(AggregateToken)
+	if !ok {
+		panic("aggregate token expected for aggregate")
+	}
+
+	// The argument is the percentile of percentiles and the confidence of
+	// estimates which is 95% for probabilities unless given.
+	argument := 0.0
+	if function.lexeme == "probability" {
+		argument = 0.95
+	}
+	if _, exists := parser.consume(LeftParenthesis); exists {
+		number, numbered := parser.consume(NumberToken)
+		if _, right := parser.consume(RightParenthesis); !numbered || !right {
+			panic(fmt.Sprintf("%v expected a number in parentheses", function.lexeme))
+		}
+		argument, _ = strconv.ParseFloat(number.lexeme, 64)
+		if function.lexeme == "percentile" && (argument < 0 || argument > 100) {
+			panic(fmt.Sprintf("percentile %v is not between 0 and 100", number.lexeme))
+		}
+		if function.lexeme != "percentile" && (argument <= 0 || argument >= 1) {
+			panic(fmt.Sprintf("confidence %v is not between 0 and 1", number.lexeme))
+		}
+	} else if function.lexeme == "percentile" {
+		panic("percentile expected its percentile in parentheses")
+	}
+
+	variables, types := parser.variables()
+	if len(variables) != 1 {
+		panic(fmt.Sprintf("%v aggregates exactly one variable but got %v", function.lexeme, len(variables)))
+	}
+
+	if _, ok := parser.consume(ScopeDelimiterToken); !ok {
+		panic("expected scope delimiter toke")
+	}
+
+	expression := parser.expression().(GoExpresion)
+	value, operator, bound := comparison(expression.code)
+	if operator == "" {
+		panic(fmt.Sprintf("%v expected a comparison of a value with a bound but got %q", function.lexeme, expression.code))
+	}
+
+	if argument > 0 && function.lexeme != "percentile" && (operator == "==" || operator == "!=") {
+		panic(fmt.Sprintf("%v estimates are only compared by <, <=, > and >=", function.lexeme))
+	}
+
+	switch {
+	case function.lexeme == "percentile":
+		return NewPercentileAggregate(argument, variables, types, NewGoExpression(value), operator, NewGoExpression(bound))
+	case argument > 0:
+		return NewEstimateAggregate(function.lexeme, argument, variables, types, NewGoExpression(value), operator, NewGoExpression(bound))
+	}
+	return NewAggregate(function.lexeme, variables, types, NewGoExpression(value), operator, NewGoExpression(bound))
+}
+
+// comparison splits the Go expression by its outermost comparison into the
+// compared expressions, e.g. "a > b <= c" into "a > b", "<=" and "c". The
+// operator is empty if the expression is not a comparison.
+func comparison(code string) (left, operator, right string) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(code))
+	var tokens scanner.Scanner
+	tokens.Init(file, []byte(code), nil, 0)
+
+	depth, split := 0, -1
+	for {
+		position, class, _ := tokens.Scan()
+		if class == token.EOF {
+			break
+		}
+		switch class {
+		case token.LPAREN, token.LBRACK, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACK, token.RBRACE:
+			depth--
+		case token.LOR, token.LAND:
+			if depth == 0 {
+				// Comparisons bind tighter than the logical operators.
+				return code, "", ""
+			}
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			if depth == 0 {
+				split, operator = file.Offset(position), class.String()
+			}
+		}
+	}
+
+	if split < 0 {
+		return code, "", ""
+	}
+	left = strings.TrimSpace(code[:split])
+	right = strings.TrimSpace(code[split+len(operator):])
+	if left == "" || right == "" {
+		return code, "", ""
+	}
+	return left, operator, right
+}
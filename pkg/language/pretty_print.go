@@ -1,21 +1,42 @@
 package language
 
-import "strings"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
+// Print returns the source of the AST. Parsing the source of a contract yields
+// the same contract. Obligations are separated by a space and every Go
+// expression and constant number is terminated by a semicolon such that it also
+// ends inside groups and probabilities. The compose clause of a composite
+// contract ends its line. Labels are quoted after the keyword of their
+// obligation. Probabilities are printed as P(event) and P(event | given) and
+// predicate expressions, being compiled Go functions without a source, cannot
+// be printed.
 func Print(ast Node) string {
 	var builder strings.Builder
 
+	separate := func() {
+		if builder.Len() > 0 && !strings.HasSuffix(builder.String(), " ") && !strings.HasSuffix(builder.String(), "\n") {
+			builder.WriteString(" ")
+		}
+	}
+
 	var recursive func(ast Node)
 	recursive = func(ast Node) {
 		switch cast := ast.(type) {
 		case Contract:
+			if len(cast.composition) > 0 {
+				builder.WriteString("compose: ")
+				builder.WriteString(strings.Join(cast.composition, ", "))
+				builder.WriteString("\n")
+			}
 			for idx := range cast.regions {
 				recursive(cast.regions[idx])
 			}
 		case Region:
-			if builder.Len() > 0 {
-				builder.WriteString(" ")
-			}
+			separate()
 			builder.WriteString("region")
 			for idx := range cast.name {
 				builder.WriteString(" ")
@@ -32,28 +53,51 @@ func Print(ast Node) string {
 			}
 		case Universal:
 			builder.WriteString("forall")
-			for idx := range cast.variables {
-				builder.WriteString(" ")
-				builder.WriteString(cast.variables[idx])
+			if cast.distinct {
+				builder.WriteString(" distinct")
 			}
+			builder.WriteString(printVariables(cast.variables, cast.types))
 			builder.WriteString(". ")
 			recursive(cast.assertion)
 		case Existential:
 			builder.WriteString("exists")
-			for idx := range cast.variables {
-				builder.WriteString(" ")
-				builder.WriteString(cast.variables[idx])
-			}
+			builder.WriteString(printVariables(cast.variables, cast.types))
 			builder.WriteString(". ")
 			recursive(cast.assertion)
 		case Assumption:
+			separate()
 			builder.WriteString("assume")
+			if cast.label != "" {
+				builder.WriteString(" ")
+				builder.WriteString(strconv.Quote(cast.label))
+			}
 			builder.WriteString(": ")
 			recursive(cast.assertion)
 		case Guarantee:
+			separate()
 			builder.WriteString("guarantee")
+			if cast.label != "" {
+				builder.WriteString(" ")
+				builder.WriteString(strconv.Quote(cast.label))
+			}
 			builder.WriteString(": ")
 			recursive(cast.assertion)
+		case Aggregate:
+			builder.WriteString(cast.function)
+			if cast.function == "percentile" {
+				builder.WriteString("(")
+				builder.WriteString(strconv.FormatFloat(cast.percentile, 'g', -1, 64))
+				builder.WriteString(")")
+			} else if cast.confidence > 0 {
+				builder.WriteString("(")
+				builder.WriteString(strconv.FormatFloat(cast.confidence, 'g', -1, 64))
+				builder.WriteString(")")
+			}
+			builder.WriteString(printVariables(cast.variables, cast.types))
+			builder.WriteString(". ")
+			builder.WriteString(cast.value.(GoExpresion).code)
+			builder.WriteString(" " + cast.operator + " ")
+			recursive(cast.bound)
 		case GoExpresion:
 			builder.WriteString(cast.code)
 			builder.WriteString(";")
@@ -61,8 +105,21 @@ func Print(ast Node) string {
 			builder.WriteRune('(')
 			recursive(cast.node)
 			builder.WriteRune(')')
+		case ProbabilisticQuantifier:
+			builder.WriteString("P(")
+			recursive(cast.event)
+			builder.WriteRune(')')
+		case ConditionalProbabilityQuantifier:
+			builder.WriteString("P(")
+			recursive(cast.event)
+			builder.WriteString(" | ")
+			recursive(cast.given)
+			builder.WriteRune(')')
+		case ConstantNumber:
+			builder.WriteString(strconv.FormatFloat(float64(cast.value), 'g', -1, 32))
+			builder.WriteString(";")
 		default:
-			panic("unknown node")
+			panic(fmt.Sprintf("%T has no source to print", ast))
 		}
 	}
 
@@ -70,3 +127,45 @@ func Print(ast Node) string {
 
 	return builder.String()
 }
+
+// printVariables returns the variables of a quantifier each preceded by a space
+// and, if any is typed, separated by commas with their types.
+func printVariables(variables, types []string) string {
+	if len(variables) == 0 {
+		return ""
+	}
+	if types == nil {
+		return " " + strings.Join(variables, " ")
+	}
+
+	printed := make([]string, len(variables))
+	for idx, variable := range variables {
+		printed[idx] = variable
+		if types[idx] != "" {
+			printed[idx] += ": " + types[idx]
+		}
+	}
+	return " " + strings.Join(printed, ", ")
+}
+
+// PrintComments returns the lines of a doc comment of the contract with one
+// obligation per line. Regions start with their name on a line of their own
+// except for the first region if it has no name.
+func PrintComments(contract Contract) []string {
+	var comments []string
+	if len(contract.composition) > 0 {
+		comments = append(comments, "// compose: "+strings.Join(contract.composition, ", "))
+	}
+	for idx, region := range contract.regions {
+		if idx > 0 || len(region.name) > 0 {
+			comments = append(comments, strings.TrimSpace("// region "+strings.Join(region.name, " "))+":")
+		}
+		for _, obligation := range region.assumptions {
+			comments = append(comments, "// "+Print(obligation))
+		}
+		for _, obligation := range region.guarantees {
+			comments = append(comments, "// "+Print(obligation))
+		}
+	}
+	return comments
+}
@@ -38,11 +38,51 @@ func TestPrettyPrint(t *testing.T) {
 			source:      "guarantee: forall e0 e1. exists e2. e2.high == e0.high && e2.low == e1.low",
 			print:       "region: guarantee: forall e0 e1. exists e2. e2.high == e0.high && e2.low == e1.low;",
 		},
+		{
+			description: "Universal over distinct executions",
+			source:      "guarantee: forall distinct e0 e1. e0.id != e1.id",
+			print:       "region: guarantee: forall distinct e0 e1. e0.id != e1.id;",
+		},
+		{
+			description: "Labelled obligations",
+			source:      "assume \"Valid Attempt\": forall e. e.attempt > 0\nguarantee: forall e. e.ret0 // Successful Check",
+			print:       "region: assume \"Valid Attempt\": forall e. e.attempt > 0; guarantee \"Successful Check\": forall e. e.ret0;",
+		},
 		{
 			description: "Two named regions with a single quantifier",
 			source:      "region Positive: guarantee: forall e. e >= 0; region Negative: guarantee: forall e. e < 0",
 			print:       "region Positive: guarantee: forall e. e >= 0; region Negative: guarantee: forall e. e < 0;",
 		},
+		{
+			description: "Composite contract with typed variables",
+			source:      "compose: Read\nguarantee: forall r0 r1: Read, e. exists r: Read. e.path != r.path",
+			print:       "compose: Read\nregion: guarantee: forall r0: Read, r1: Read, e. exists r: Read. e.path != r.path;",
+		},
+		{
+			description: "Aggregates",
+			source:      "guarantee: mean t. t.latency<=100ms\nguarantee: forall e. percentile(99.9) t. t.latency < 2 * e.latency",
+			print:       "region: guarantee: mean t. t.latency <= 100ms; guarantee: forall e. percentile(99.9) t. t.latency < 2 * e.latency;",
+		},
+		{
+			description: "Estimates",
+			source:      "guarantee: probability t. t.latency <= 100ms >= 0.9\nguarantee: mean(0.99) t. t.latency < 1s",
+			print:       "region: guarantee: probability(0.95) t. t.latency <= 100ms >= 0.9; guarantee: mean(0.99) t. t.latency < 1s;",
+		},
+		{
+			description: "Count compares the number of counted executions",
+			source:      "guarantee: count e. e.ret0 > 10 >= 3",
+			print:       "region: guarantee: count e. e.ret0 > 10 >= 3;",
+		},
+		{
+			description: "Probabilities and constant numbers",
+			source:      "guarantee: P(forall e. e.ret0;) guarantee: P(exists e. e.ret0; | forall e. e.ok;) guarantee: -0.5",
+			print:       "region: guarantee: P(forall e. e.ret0;) guarantee: P(exists e. e.ret0; | forall e. e.ok;) guarantee: -0.5;",
+		},
+		{
+			description: "Calls of P in Go expressions",
+			source:      "guarantee: forall e. P(e.x) > 0.5",
+			print:       "region: guarantee: forall e. P(e.x) > 0.5;",
+		},
 	}
 
 	for _, tt := range tests {
@@ -54,3 +94,41 @@ func TestPrettyPrint(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintNodes(t *testing.T) {
+	tests := []struct {
+		description string
+		node        Node
+		print       string
+	}{
+		{
+			description: "Probability of an event",
+			node:        NewProbabilisticQuantifier(NewGoExpression("e.ret0")),
+			print:       "P(e.ret0;)",
+		},
+		{
+			description: "Conditional probability of an event",
+			node:        NewConditionalProbabilityQuantifier(NewGoExpression("e.ret0"), NewGoExpression("e.x > 0")),
+			print:       "P(e.ret0; | e.x > 0;)",
+		},
+		{
+			description: "Constant number",
+			node:        Number(0.95),
+			print:       "0.95;",
+		},
+		{
+			description: "Region without assumptions followed by an empty region",
+			node: NewContract(
+				NewRegion([]string{"A"}, nil, []Node{NewGuarantee(NewGoExpression("true")), NewGuarantee(NewGoExpression("false"))}),
+				NewRegion(nil, nil, nil),
+			),
+			print: "region A: guarantee: true; guarantee: false; region: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.Equal(t, tt.print, Print(tt.node))
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package language
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+)
+
+// unwrap removes any groups surrounding the node.
+func unwrap(node Node) Node {
+	for {
+		group, ok := node.(Group)
+		if !ok {
+			return node
+		}
+		node = group.node
+	}
+}
+
+// binary returns the universally quantified 2-safety hyper-assertion of the
+// obligation, i.e. "forall a b. expression".
+func binary(obligation Node) (Universal, GoExpresion, bool) {
+	var assertion Node
+	switch cast := obligation.(type) {
+	case Assumption:
+		assertion = cast.assertion
+	case Guarantee:
+		assertion = cast.assertion
+	default:
+		return Universal{}, GoExpresion{}, false
+	}
+
+	universal, ok := unwrap(assertion).(Universal)
+	if !ok || len(universal.variables) != 2 {
+		return Universal{}, GoExpresion{}, false
+	}
+
+	expression, ok := unwrap(universal.assertion).(GoExpresion)
+	return universal, expression, ok
+}
+
+// conjuncts returns the operands of a chain of conjunctions.
+func conjuncts(expr ast.Expr) []ast.Expr {
+	switch cast := expr.(type) {
+	case *ast.ParenExpr:
+		return conjuncts(cast.X)
+	case *ast.BinaryExpr:
+		if cast.Op == token.LAND {
+			return append(conjuncts(cast.X), conjuncts(cast.Y)...)
+		}
+	}
+	return []ast.Expr{expr}
+}
+
+// Equalities returns the fields which the expression requires to be equal
+// between the two execution variables, e.g. "low" for "e0.low == e1.low". It
+// considers conjunctions and the antecedent of implications written as
+// "!(antecedent) || consequent".
+func Equalities(code string, left, right string) (fields []string) {
+	expr, err := parser.ParseExpr(code)
+	if err != nil {
+		return nil
+	}
+
+	selector := func(expr ast.Expr) (string, string, bool) {
+		cast, ok := expr.(*ast.SelectorExpr)
+		if !ok {
+			return "", "", false
+		}
+		identifier, ok := cast.X.(*ast.Ident)
+		if !ok {
+			return "", "", false
+		}
+		return identifier.Name, cast.Sel.Name, true
+	}
+
+	candidates := conjuncts(expr)
+	if disjunction, ok := expr.(*ast.BinaryExpr); ok && disjunction.Op == token.LOR {
+		if negation, ok := disjunction.X.(*ast.UnaryExpr); ok && negation.Op == token.NOT {
+			candidates = conjuncts(negation.X)
+		}
+	}
+
+	for _, candidate := range candidates {
+		comparison, ok := candidate.(*ast.BinaryExpr)
+		if !ok || comparison.Op != token.EQL {
+			continue
+		}
+		lhsVariable, lhsField, lhsOk := selector(comparison.X)
+		rhsVariable, rhsField, rhsOk := selector(comparison.Y)
+		if !lhsOk || !rhsOk || lhsField != rhsField {
+			continue
+		}
+		if (lhsVariable == left && rhsVariable == right) || (lhsVariable == right && rhsVariable == left) {
+			fields = append(fields, lhsField)
+		}
+	}
+
+	return fields
+}
+
+// SelfComposition generates a standalone test file checking the 2-safety
+// guarantees of the function by self-composition with CheckSelfComposition.
+// The generated test runs the function twice on paired inputs where the inputs
+// required to be equal by the assumptions or the antecedent of the guarantee
+// are shared and the rest are generated from the seed for the number of runs.
+// Every region of the contract is tested separately under its own
+// assumptions. False is returned if the function has no "forall a b."
+// guarantee, a composite contract, no outputs or cannot be called from a
+// generated test.
+func (injector Injector) SelfComposition(packageName string, function *dst.FuncDecl, runs int, seed uint64) (*dst.File, bool) {
+	if function.Recv != nil || function.Type.TypeParams != nil || function.Type.Results == nil {
+		return nil, false
+	}
+
+	inputs := make(map[string]struct{})
+	for _, field := range function.Type.Params.List {
+		if _, variadic := field.Type.(*dst.Ellipsis); variadic {
+			return nil, false
+		}
+		for _, name := range field.Names {
+			inputs[name.Name] = struct{}{}
+		}
+	}
+
+	comments := function.Decs.NodeDecs.Start
+	if len(comments) == 0 {
+		return nil, false
+	}
+	parser := NewParser(LexDocStrings(comments))
+	contract := parser.Parse()
+	if len(contract.composition) > 0 {
+		return nil, false
+	}
+
+	modelName := function.Name.Name + "_SelfCompositionModel"
+	_, model := injector.Model(function)
+	model.Specs[0].(*dst.TypeSpec).Name = dst.NewIdent(modelName)
+
+	decls := []dst.Decl{
+		&dst.GenDecl{
+			Tok: token.IMPORT,
+			Specs: []dst.Spec{
+				&dst.ImportSpec{Path: &dst.BasicLit{Kind: token.STRING, Value: "\"testing\""}},
+				&dst.ImportSpec{
+					Name: dst.NewIdent("sopher"),
+					Path: &dst.BasicLit{Kind: token.STRING, Value: "\"github.com/hyperproperties/sopher/pkg/language\""},
+				},
+			},
+		},
+		model,
+	}
+
+	for idx, region := range contract.regions {
+		// The declarations of a region are named after it but for those of
+		// the unnamed region preceding the named ones.
+		name := function.Name.Name
+		if len(region.name) > 0 {
+			name += "_" + strings.Join(region.name, "")
+		} else if idx > 0 {
+			name += fmt.Sprintf("_Region%v", idx)
+		}
+
+		if regionDecls, ok := injector.selfCompositionRegion(function, name, modelName, inputs, region, runs, seed); ok {
+			decls = append(decls, regionDecls...)
+		}
+	}
+	if len(decls) == 2 {
+		return nil, false
+	}
+
+	return &dst.File{
+		Name:  dst.NewIdent(packageName),
+		Decls: decls,
+	}, true
+}
+
+// selfCompositionRegion returns the contract and test of the 2-safety
+// guarantees of the region or false if it has none.
+func (injector Injector) selfCompositionRegion(
+	function *dst.FuncDecl, name, modelName string, inputs map[string]struct{}, region Region, runs int, seed uint64,
+) ([]dst.Decl, bool) {
+	var guarantees []Node
+	var paired []string
+	seen := make(map[string]struct{})
+	pair := func(universal Universal, expression GoExpresion) {
+		for _, field := range Equalities(expression.code, universal.variables[0], universal.variables[1]) {
+			if _, isInput := inputs[field]; !isInput {
+				continue
+			}
+			if _, exists := seen[field]; !exists {
+				seen[field] = struct{}{}
+				paired = append(paired, field)
+			}
+		}
+	}
+
+	for _, guarantee := range region.guarantees {
+		if universal, expression, ok := binary(guarantee); ok {
+			guarantees = append(guarantees, guarantee)
+			pair(universal, expression)
+		}
+	}
+	if len(guarantees) == 0 {
+		return nil, false
+	}
+
+	for _, assumption := range region.assumptions {
+		if universal, expression, ok := binary(assumption); ok {
+			pair(universal, expression)
+		}
+	}
+
+	contractName := name + "_SelfCompositionContract"
+	contractDecl := &dst.GenDecl{
+		Tok: token.VAR,
+		Specs: []dst.Spec{
+			&dst.ValueSpec{
+				Names:  []*dst.Ident{dst.NewIdent(contractName)},
+				Values: []dst.Expr{injector.Constructor(modelName, region.assumptions, guarantees)},
+			},
+		},
+	}
+
+	test := injector.selfCompositionTest(function, name, modelName, contractName, paired, runs, seed)
+
+	return []dst.Decl{contractDecl, test}, true
+}
+
+func (injector Injector) selfCompositionTest(
+	function *dst.FuncDecl, name, modelName, contractName string, paired []string, runs int, seed uint64,
+) *dst.FuncDecl {
+	var pairs []string
+	for _, field := range paired {
+		pairs = append(pairs, fmt.Sprintf("e1.%s = e0.%s", field, field))
+	}
+
+	var arguments []string
+	for _, input := range function.Type.Params.List {
+		for _, identifier := range input.Names {
+			arguments = append(arguments, "e."+identifier.Name)
+		}
+	}
+
+	var outputs []string
+	for _, output := range injector.OutputFields(function) {
+		for _, identifier := range output.Names {
+			outputs = append(outputs, "e."+identifier.Name)
+		}
+	}
+
+	// Paired inputs are shared between the two runs of the product program.
+	source := fmt.Sprintf(`package p
+
+func %[1]s_SelfComposition(t *testing.T) {
+	pair := func(e0, e1 %[2]s) %[2]s {
+		%[3]s
+		return e1
+	}
+	call := func(e %[2]s) %[2]s {
+		%[4]s = %[5]s(%[6]s)
+		return e
+	}
+	sopher.CheckSelfComposition(t, %[7]s, pair, call, sopher.WithRuns(%[8]v), sopher.WithSeed(%[9]v))
+}`, testName(name), modelName, strings.Join(pairs, "\n"), strings.Join(outputs, ", "), function.Name.Name,
+		strings.Join(arguments, ", "), contractName, runs, seed)
+
+	test, err := decorator.Parse(source)
+	if err != nil {
+		panic(err)
+	}
+	return test.Decls[0].(*dst.FuncDecl)
+}
+
+// SelfCompositions writes a "<file>_selfcomposition_sopher_test.go" file
+// next to each of the source files with the self-composition tests of its
+// functions with a 2-safety guarantee, which run the number of runs from the
+// seed. Files which cannot be read or parsed are skipped as they have no
+// contracts to test, and test files sopher did not generate are not
+// overwritten.
+func (injector Injector) SelfCompositions(files iter.Seq[string], runs int, seed uint64) error {
+	for path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		file, err := decorator.ParseFile(token.NewFileSet(), filepath.Base(path), content, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		injector.attach(path, file)
+
+		// The tests of the functions of the file share its test file such
+		// that functions differing only in case do not collide.
+		var tests *dst.File
+		for _, decl := range file.Decls {
+			function, ok := decl.(*dst.FuncDecl)
+			if !ok {
+				continue
+			}
+
+			test, ok := injector.SelfComposition(file.Name.Name, function, runs, seed)
+			if !ok {
+				continue
+			}
+			if tests == nil {
+				tests = test
+			} else {
+				tests.Decls = append(tests.Decls, test.Decls[1:]...)
+			}
+		}
+		if tests == nil {
+			continue
+		}
+
+		if err := writeGenerated(selfCompositionPath(path), tests); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// selfCompositionPath returns the path of the self-composition tests of the
+// file at the path.
+func selfCompositionPath(path string) string {
+	return strings.TrimSuffix(path, ".go") + "_selfcomposition_sopher_test.go"
+}
@@ -0,0 +1,170 @@
+package language
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqualities(t *testing.T) {
+	tests := []struct {
+		description string
+		code        string
+		fields      []string
+	}{
+		{
+			description: "single equality",
+			code:        "e0.low == e1.low",
+			fields:      []string{"low"},
+		},
+		{
+			description: "reversed equality",
+			code:        "e1.low == e0.low",
+			fields:      []string{"low"},
+		},
+		{
+			description: "conjunction of equalities",
+			code:        "e0.user == e1.user && (e0.password == e1.password)",
+			fields:      []string{"user", "password"},
+		},
+		{
+			description: "antecedent of implication",
+			code:        "!(e0.high == e1.high) || e0.ret0 == e1.ret0",
+			fields:      []string{"high"},
+		},
+		{
+			description: "different fields",
+			code:        "e0.low == e1.high",
+			fields:      nil,
+		},
+		{
+			description: "other variables",
+			code:        "e0.low == e2.low",
+			fields:      nil,
+		},
+		{
+			description: "disjunction",
+			code:        "e0.low == e1.low || e0.high == e1.high",
+			fields:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.Equal(t, tt.fields, Equalities(tt.code, "e0", "e1"))
+		})
+	}
+}
+
+func TestSelfComposition(t *testing.T) {
+	source := `package examples
+
+// assume: forall e0 e1. e0.low == e1.low
+// guarantee: forall e0 e1. e0.ret0 == e1.ret0
+func NonInterference(low, high int) int {
+	return low
+}
+
+// guarantee: forall e. e.ret0 >= 0
+func Safety(value int) int {
+	return value
+}
+
+// guarantee: forall e. e.ret0 >= 0
+// region Public:
+// assume: forall e0 e1. e0.low == e1.low
+// guarantee: forall e0 e1. e0.ret0 == e1.ret0
+// region Secret:
+// guarantee: forall e0 e1. !(e0.high == e1.high) || e0.ret0 == e1.ret0
+func Regions(low, high int) int {
+	return low
+}`
+
+	file, err := decorator.NewDecorator(token.NewFileSet()).ParseFile("examples.go", source, parser.ParseComments)
+	assert.NoError(t, err)
+
+	injector := NewGoInjector()
+
+	_, ok := injector.SelfComposition("examples", file.Decls[1].(*dst.FuncDecl), 100, 0)
+	assert.False(t, ok, "safety properties have no self-composition")
+
+	test, ok := injector.SelfComposition("examples", file.Decls[0].(*dst.FuncDecl), 100, 0)
+	assert.True(t, ok)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, decorator.Fprint(&buffer, test))
+	generated := buffer.String()
+
+	assert.Contains(t, generated, "type NonInterference_SelfCompositionModel struct")
+	assert.Contains(t, generated, "func TestNonInterference_SelfComposition(t *testing.T)")
+	assert.Contains(t, generated, "e1.low = e0.low")
+	assert.NotContains(t, generated, "e1.high = e0.high")
+	assert.Contains(t, generated, "e.ret0 = NonInterference(e.low, e.high)")
+	assert.Contains(t, generated, "sopher.CheckSelfComposition(t, NonInterference_SelfCompositionContract, pair, call, sopher.WithRuns(100), sopher.WithSeed(0))")
+
+	// Every region with a 2-safety guarantee is tested under its own
+	// assumptions.
+	test, ok = injector.SelfComposition("examples", file.Decls[2].(*dst.FuncDecl), 50, 7)
+	assert.True(t, ok)
+
+	buffer.Reset()
+	assert.NoError(t, decorator.Fprint(&buffer, test))
+	generated = buffer.String()
+
+	assert.Equal(t, 1, strings.Count(generated, "type Regions_SelfCompositionModel struct"))
+	assert.NotContains(t, generated, "func TestRegions_SelfComposition(")
+	assert.Contains(t, generated, "func TestRegions_Public_SelfComposition(t *testing.T)")
+	assert.Contains(t, generated, "func TestRegions_Secret_SelfComposition(t *testing.T)")
+	public, secret, _ := strings.Cut(generated, "func TestRegions_Secret_SelfComposition")
+	assert.Contains(t, public, "e1.low = e0.low")
+	assert.NotContains(t, public, "e1.high = e0.high")
+	assert.Contains(t, secret, "e1.high = e0.high")
+	assert.NotContains(t, secret, "e1.low = e0.low")
+	assert.Contains(t, secret, "sopher.CheckSelfComposition(t, Regions_Secret_SelfCompositionContract, pair, call, sopher.WithRuns(50), sopher.WithSeed(7))")
+}
+
+func TestSelfCompositions(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "examples.go")
+	assert.NoError(t, os.WriteFile(path, []byte(`package examples
+
+// guarantee: forall e0 e1. e0.ret0 == e1.ret0
+func Constant(value int) int {
+	return 0
+}
+
+// guarantee: forall e0 e1. e0.ret0 == e1.ret0
+func constant(value int) int {
+	return 0
+}
+`), 0644))
+
+	injector := NewGoInjector()
+	assert.NoError(t, injector.SelfCompositions(slices.Values([]string{path}), 100, 0))
+
+	// Functions differing only in case share the test file of their file.
+	tests := filepath.Join(directory, "examples_selfcomposition_sopher_test.go")
+	content, err := os.ReadFile(tests)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "func TestConstant_SelfComposition(t *testing.T)")
+	assert.Contains(t, string(content), "func Test_constant_SelfComposition(t *testing.T)")
+
+	// Generated test files are regenerated.
+	assert.NoError(t, injector.SelfCompositions(slices.Values([]string{path}), 100, 0))
+
+	// Test files sopher did not generate are not overwritten.
+	assert.NoError(t, os.WriteFile(tests, []byte("package examples\n"), 0644))
+	assert.ErrorIs(t, injector.SelfCompositions(slices.Values([]string{path}), 100, 0), ErrNotGenerated)
+	content, err = os.ReadFile(tests)
+	assert.NoError(t, err)
+	assert.Equal(t, "package examples\n", string(content))
+}
@@ -0,0 +1,272 @@
+package language
+
+import (
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dave/dst"
+	"github.com/hyperproperties/sopher/pkg/filesx"
+)
+
+// SidecarExtension is the extension of standalone contract files.
+const SidecarExtension = ".sopher"
+
+// functionPattern matches the part of a fully-qualified function name after
+// the import path, i.e. "pkg.Function", "pkg.Type.Method" or with the receiver
+// in parentheses "pkg.(*Type).Method".
+var functionPattern = regexp.MustCompile(`^([\pL_][\pL\pN_]*)\.(?:\(\*?([\pL_][\pL\pN_]*)\)\.|([\pL_][\pL\pN_]*)\.)?([\pL_][\pL\pN_]*)$`)
+
+// SidecarContract is the contract of a function written in a sidecar file
+// instead of the doc comment of the function. Sidecar files contain contracts
+// for code which cannot be annotated such as generated or third-party code:
+//
+//	// Contracts of the pins package.
+//
+//	func example.com/pins.CheckPIN
+//	guarantee: forall e0 e1.
+//		e0.pin != e1.pin || e0.ret0 == e1.ret0
+//
+// Every contract follows a line starting with "func" and the fully-qualified
+// name of its function and is written like the text of a doc comment. Lines
+// starting with "//" are comments of the sidecar file.
+type SidecarContract struct {
+	// Function is the fully-qualified name of the function where methods are
+	// named by the type of their receiver, e.g. "example.com/pins.Pin.Check".
+	Function string
+	// Path is the path of the sidecar file, Line the line of the function name
+	// and Offset the offset of the contract in it.
+	Path   string
+	Line   int
+	Offset int
+	// Text is the contract where comments are replaced by spaces such that its
+	// offsets are those of the sidecar file less the offset of the contract.
+	Text string
+}
+
+// SidecarError is an error in a sidecar file.
+type SidecarError struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+func (err *SidecarError) Error() string {
+	return fmt.Sprintf("%v:%v: %v", err.Path, err.Line, err.Message)
+}
+
+// QualifiedName returns the fully-qualified name of the function declared in
+// the package with the import path.
+func QualifiedName(importPath string, function *dst.FuncDecl) string {
+	if receiver, ok := receiverName(function); ok {
+		return importPath + "." + receiver + "." + function.Name.Name
+	}
+	return importPath + "." + function.Name.Name
+}
+
+// qualify normalises the fully-qualified function name such that receivers are
+// not in parentheses or returns false if it is not a function name.
+func qualify(name string) (string, bool) {
+	slash := strings.LastIndex(name, "/")
+	matches := functionPattern.FindStringSubmatch(name[slash+1:])
+	if matches == nil {
+		return "", false
+	}
+
+	qualified := name[:slash+1] + matches[1] + "."
+	if receiver := matches[2] + matches[3]; receiver != "" {
+		qualified += receiver + "."
+	}
+	return qualified + matches[4], true
+}
+
+// ParseSidecar splits the text of the sidecar file at the path into the
+// contracts of its functions.
+func ParseSidecar(path, text string) ([]SidecarContract, error) {
+	var contracts []SidecarContract
+	seen := make(map[string]int)
+
+	offset := 0
+	var builder strings.Builder
+	flush := func() {
+		if len(contracts) > 0 {
+			contracts[len(contracts)-1].Text = builder.String()
+		}
+		builder.Reset()
+	}
+
+	for idx, line := range strings.SplitAfter(text, "\n") {
+		number := idx + 1
+		content := strings.TrimRight(line, "\r\n")
+
+		if rest, ok := strings.CutPrefix(content, "func"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			fields := strings.Fields(rest)
+			if len(fields) != 1 {
+				return nil, &SidecarError{path, number, "expected a fully-qualified function name after func"}
+			}
+			function, ok := qualify(fields[0])
+			if !ok {
+				return nil, &SidecarError{path, number, fmt.Sprintf("%v is not a fully-qualified function name", fields[0])}
+			}
+			if previous, exists := seen[function]; exists {
+				return nil, &SidecarError{path, number, fmt.Sprintf("%v already has a contract on line %v", function, previous)}
+			}
+			seen[function] = number
+
+			flush()
+			offset += len(line)
+			contracts = append(contracts, SidecarContract{
+				Function: function,
+				Path:     path,
+				Line:     number,
+				Offset:   offset,
+			})
+			continue
+		}
+		offset += len(line)
+
+		if strings.HasPrefix(strings.TrimLeft(content, " \t"), "//") {
+			builder.WriteString(strings.Repeat(" ", len(content)))
+			builder.WriteString(line[len(content):])
+			continue
+		}
+
+		if len(contracts) == 0 && strings.TrimSpace(content) != "" {
+			return nil, &SidecarError{path, number, "expected func before the first contract"}
+		}
+		builder.WriteString(line)
+	}
+	flush()
+
+	return contracts, nil
+}
+
+// ReadSidecar reads and parses the sidecar file at the path.
+func ReadSidecar(path string) ([]SidecarContract, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSidecar(path, string(content))
+}
+
+// Parse parses the contract. Syntax errors are returned instead of panicking
+// and refer to the function name in the sidecar file.
+func (sidecar SidecarContract) Parse() (contract Contract, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = &SidecarError{sidecar.Path, sidecar.Line, fmt.Sprintf("%v: %v", sidecar.Function, recovered)}
+		}
+	}()
+
+	parser := NewParser(LexString(sidecar.Text))
+	return parser.Parse(), nil
+}
+
+// Comments returns the contract as the lines of a doc comment.
+func (sidecar SidecarContract) Comments() []string {
+	lines := strings.Split(strings.TrimRight(sidecar.Text, " \t\r\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+
+	comments := make([]string, len(lines))
+	for idx, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case line == "":
+			comments[idx] = "//"
+		case strings.HasPrefix(line, "\t"):
+			comments[idx] = "//" + line
+		default:
+			comments[idx] = "// " + line
+		}
+	}
+	return comments
+}
+
+// Sidecars reads the contracts of the sidecar files and checks their syntax.
+func (injector *Injector) Sidecars(paths iter.Seq[string]) error {
+	if injector.sidecars == nil {
+		injector.sidecars = make(map[string]SidecarContract)
+	}
+
+	for path := range paths {
+		contracts, err := ReadSidecar(path)
+		if err != nil {
+			return err
+		}
+
+		for _, contract := range contracts {
+			if previous, exists := injector.sidecars[contract.Function]; exists {
+				return &SidecarError{path, contract.Line, fmt.Sprintf("%v already has a contract in %v:%v",
+					contract.Function, previous.Path, previous.Line)}
+			}
+			if _, err := contract.Parse(); err != nil {
+				return err
+			}
+			injector.sidecars[contract.Function] = contract
+		}
+	}
+
+	return nil
+}
+
+// Attach replaces the doc comments of the functions declared in the file by
+// their sidecar contracts and merges the contracts inherited from interfaces
+// into them such that they are injected as if they were written there. Prose is
+// replaced as it cannot precede a contract in a doc comment. The file is of the
+// package with the import path.
+func (injector Injector) Attach(importPath string, file *dst.File) {
+	if len(injector.sidecars) == 0 && len(injector.inherited) == 0 {
+		return
+	}
+
+	for _, decl := range file.Decls {
+		function, ok := decl.(*dst.FuncDecl)
+		if !ok {
+			continue
+		}
+
+		name := QualifiedName(importPath, function)
+		sidecar, exists := injector.sidecars[name]
+		inherited := injector.inherited[name]
+		if !exists && len(inherited) == 0 {
+			continue
+		}
+
+		comments := function.Decs.NodeDecs.Start
+		documented := IsContract(docText(comments))
+
+		if exists {
+			if documented {
+				panic(fmt.Sprintf("%v:%v: %v already has a contract in its doc comment",
+					sidecar.Path, sidecar.Line, sidecar.Function))
+			}
+			comments, documented = sidecar.Comments(), true
+		}
+
+		if len(inherited) > 0 {
+			var contracts []Contract
+			if documented {
+				parser := NewParser(LexDocStrings(comments))
+				contracts = append(contracts, parser.Parse())
+			}
+			comments = PrintComments(Merge(slices.Concat(inherited, contracts)...))
+		}
+
+		function.Decs.NodeDecs.Start = comments
+	}
+}
+
+// attach attaches the sidecar and inherited contracts to the file at the path if the import
+// path of its package can be determined.
+func (injector Injector) attach(path string, file *dst.File) {
+	if importPath, err := filesx.ImportPath(filepath.Dir(path)); err == nil {
+		injector.Attach(importPath, file)
+	}
+}
@@ -0,0 +1,137 @@
+package language
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dave/dst/decorator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSidecar(t *testing.T) {
+	text := `// Contracts of the pins package.
+
+func example.com/pins.CheckPIN
+guarantee: forall e0 e1.
+	e0.pin != e1.pin || e0.ret0 == e1.ret0
+
+  // A method named with its receiver in parentheses.
+func example.com/pins.(*Pin).Check
+guarantee: forall e. e.ret0 // Accepted
+`
+
+	contracts, err := ParseSidecar("pins.sopher", text)
+	assert.NoError(t, err)
+	if !assert.Len(t, contracts, 2) {
+		return
+	}
+
+	assert.Equal(t, "example.com/pins.CheckPIN", contracts[0].Function)
+	assert.Equal(t, 3, contracts[0].Line)
+	assert.Equal(t, "example.com/pins.Pin.Check", contracts[1].Function)
+	assert.Equal(t, 8, contracts[1].Line)
+
+	for _, contract := range contracts {
+		assert.Equal(t, "pins.sopher", contract.Path)
+		assert.True(t, strings.HasPrefix(text[contract.Offset:], "guarantee:"))
+	}
+
+	// Comments are blanked while keeping the offsets of the sidecar file.
+	assert.Equal(t, "guarantee: forall e0 e1.\n\te0.pin != e1.pin || e0.ret0 == e1.ret0\n\n", contracts[0].Text[:66])
+	assert.Equal(t, strings.Repeat(" ", 53)+"\n", contracts[0].Text[66:])
+
+	assert.Equal(t, []string{
+		"// guarantee: forall e0 e1.",
+		"//\te0.pin != e1.pin || e0.ret0 == e1.ret0",
+	}, contracts[0].Comments())
+
+	contract, err := contracts[1].Parse()
+	assert.NoError(t, err)
+	assert.Equal(t, "Accepted", contract.regions[0].guarantees[0].(Guarantee).Label())
+}
+
+func TestParseSidecarErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		text        string
+		err         string
+	}{
+		{
+			description: "contract before func",
+			text:        "guarantee: true\n",
+			err:         "pins.sopher:1: expected func before the first contract",
+		},
+		{
+			description: "missing name",
+			text:        "// Contracts.\nfunc\n",
+			err:         "pins.sopher:2: expected a fully-qualified function name after func",
+		},
+		{
+			description: "unqualified name",
+			text:        "func CheckPIN\n",
+			err:         "pins.sopher:1: CheckPIN is not a fully-qualified function name",
+		},
+		{
+			description: "duplicate contract",
+			text:        "func pins.Pin.Check\nguarantee: true\nfunc pins.(Pin).Check\nguarantee: false\n",
+			err:         "pins.sopher:3: pins.Pin.Check already has a contract on line 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			_, err := ParseSidecar("pins.sopher", tt.text)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+
+	contracts, err := ParseSidecar("pins.sopher", "\nfunc pins.CheckPIN\nguarantee: forall e.\n")
+	assert.NoError(t, err)
+	_, err = contracts[0].Parse()
+	assert.ErrorContains(t, err, "pins.sopher:2: pins.CheckPIN: ")
+}
+
+func TestAttachSidecar(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "pins.sopher")
+	sidecar := "func example.com/pins.CheckPIN\nguarantee: forall e. e.ret0 == (e.attempt <= 3)\n"
+	assert.NoError(t, os.WriteFile(path, []byte(sidecar), 0644))
+
+	injector := NewGoInjector()
+	assert.NoError(t, injector.Sidecars(func(yield func(string) bool) {
+		yield(path)
+	}))
+
+	source := `package pins
+
+// CheckPIN checks the attempt.
+func CheckPIN(attempt int) bool {
+	return attempt <= 3
+}
+
+func Unrelated() {}`
+
+	file, err := decorator.NewDecorator(token.NewFileSet()).ParseFile("pins.go", source, parser.ParseComments)
+	assert.NoError(t, err)
+
+	injector.Attach("example.com/pins", file)
+	injector.Inject(file)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, decorator.Fprint(&buffer, file))
+	instrumented := buffer.String()
+
+	assert.Contains(t, instrumented, "\n// guarantee: forall e. e.ret0 == (e.attempt <= 3)\nfunc CheckPIN(")
+	assert.Contains(t, instrumented, "var CheckPIN_Contract sopher.AGHyperContract[CheckPIN_ExecutionModel]")
+	assert.NotContains(t, instrumented, "Unrelated_Contract")
+
+	// Contracts are either written in the doc comment or the sidecar file.
+	assert.PanicsWithValue(t, path+":1: example.com/pins.CheckPIN already has a contract in its doc comment", func() {
+		injector.Attach("example.com/pins", file)
+	})
+}
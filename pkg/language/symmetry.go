@@ -0,0 +1,140 @@
+package language
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// Symmetry is how the body of a universal is invariant in its variables such
+// that only some of the assignments of its variables are evaluated.
+type Symmetry uint8
+
+const (
+	// Asymmetric bodies are evaluated for every assignment of the variables.
+	Asymmetric = Symmetry(0b00)
+	// Symmetric bodies are satisfied by an assignment if and only if they are
+	// satisfied by every reordering of it, e.g. e0.x == e1.x.
+	Symmetric = Symmetry(0b01)
+	// Reflexive bodies are not evaluated for assignments of the same element to
+	// two of the variables, as declared by a distinct universal or by hand.
+	Reflexive = Symmetry(0b10)
+)
+
+// SymmetryOf detects the symmetry of the Go expression in the variables. It is
+// symmetric if swapping any two of the variables yields the same expression up
+// to the order of the operands of ==, !=, && and || and the direction of
+// comparisons. Reflexivity is never detected as an expression such as
+// e0.x == e1.x is false of the same element if x is a NaN or a call whose
+// result changes. Expressions which are not Go are asymmetric.
+func SymmetryOf(code string, variables []string) Symmetry {
+	if len(variables) < 2 {
+		return Asymmetric
+	}
+
+	expression, ok := substitute(code, nil)
+	if !ok {
+		return Asymmetric
+	}
+	original := canonical(expression)
+
+	// The transpositions of neighbouring variables generate every reordering.
+	for idx := 0; idx+1 < len(variables); idx++ {
+		swapped, _ := substitute(code, map[string]string{
+			variables[idx]:   variables[idx+1],
+			variables[idx+1]: variables[idx],
+		})
+		if canonical(swapped) != original {
+			return Asymmetric
+		}
+	}
+	return Symmetric
+}
+
+// substitute parses the expression with its identifiers renamed by the
+// substitution. Fields and methods are not renamed.
+func substitute(code string, substitution map[string]string) (ast.Expr, bool) {
+	expression, err := parser.ParseExpr(code)
+	if err != nil {
+		return nil, false
+	}
+
+	selected := make(map[*ast.Ident]bool)
+	ast.Inspect(expression, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			selected[selector.Sel] = true
+		}
+		return true
+	})
+	ast.Inspect(expression, func(node ast.Node) bool {
+		if identifier, ok := node.(*ast.Ident); ok && !selected[identifier] {
+			if name, ok := substitution[identifier.Name]; ok {
+				identifier.Name = name
+			}
+		}
+		return true
+	})
+
+	return expression, true
+}
+
+// canonical prints the expression fully parenthesised with the operands of
+// commutative operators sorted and comparisons directed by < and <= such that
+// expressions equal up to them are printed the same.
+func canonical(expression ast.Expr) string {
+	switch cast := expression.(type) {
+	case *ast.ParenExpr:
+		return canonical(cast.X)
+	case *ast.UnaryExpr:
+		return cast.Op.String() + "(" + canonical(cast.X) + ")"
+	case *ast.BinaryExpr:
+		operator, x, y := cast.Op, canonical(cast.X), canonical(cast.Y)
+		switch operator {
+		case token.GTR:
+			operator, x, y = token.LSS, y, x
+		case token.GEQ:
+			operator, x, y = token.LEQ, y, x
+		case token.EQL, token.NEQ:
+			if y < x {
+				x, y = y, x
+			}
+		case token.LAND, token.LOR:
+			var sorted []string
+			for _, operand := range slices.Concat(operands(cast.X, operator), operands(cast.Y, operator)) {
+				sorted = append(sorted, canonical(operand))
+			}
+			slices.Sort(sorted)
+			return "(" + strings.Join(sorted, " "+operator.String()+" ") + ")"
+		}
+		return "(" + x + " " + operator.String() + " " + y + ")"
+	case *ast.CallExpr:
+		arguments := make([]string, len(cast.Args))
+		for idx, argument := range cast.Args {
+			arguments[idx] = canonical(argument)
+		}
+		if cast.Ellipsis.IsValid() {
+			arguments[len(arguments)-1] += "..."
+		}
+		return canonical(cast.Fun) + "(" + strings.Join(arguments, ", ") + ")"
+	case *ast.SelectorExpr:
+		return canonical(cast.X) + "." + cast.Sel.Name
+	case *ast.IndexExpr:
+		return canonical(cast.X) + "[" + canonical(cast.Index) + "]"
+	}
+
+	return printed(expression)
+}
+
+// operands returns the operands of the chain of the operator, e.g. a, b and c
+// of a && (b && c).
+func operands(expression ast.Expr, operator token.Token) []ast.Expr {
+	if paren, ok := expression.(*ast.ParenExpr); ok {
+		return operands(paren.X, operator)
+	}
+	if binary, ok := expression.(*ast.BinaryExpr); ok && binary.Op == operator {
+		return slices.Concat(operands(binary.X, operator), operands(binary.Y, operator))
+	}
+	return []ast.Expr{expression}
+}
@@ -0,0 +1,175 @@
+package language
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymmetryOf(t *testing.T) {
+	tests := []struct {
+		description string
+		code        string
+		variables   []string
+		symmetry    Symmetry
+	}{
+		{
+			description: "non-interference",
+			code:        "!(e0.high == e1.high) || (e0.ret == e1.ret)",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Symmetric,
+		},
+		{
+			description: "reordered operands",
+			code:        "e1.high != e0.high || e0.ret == e1.ret",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Symmetric,
+		},
+		{
+			description: "equality",
+			code:        "e0.ret0 == e1.ret0",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Symmetric,
+		},
+		{
+			description: "distinctness",
+			code:        "e0.id != e1.id",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Symmetric,
+		},
+		{
+			description: "fields named as variables",
+			code:        "e0.e1 == e1.e0",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Asymmetric,
+		},
+		{
+			description: "order",
+			code:        "e0.x >= e1.x",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Asymmetric,
+		},
+		{
+			description: "monotonicity",
+			code:        "(e0.x >= e1.x) == (e0.ret0 >= e1.ret0)",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Asymmetric,
+		},
+		{
+			description: "strict order",
+			code:        "e0.x < e1.x || e1.x < e0.x",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Symmetric,
+		},
+		{
+			description: "temporal",
+			code:        "!before(e0, e1) || e0.x < e1.x",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Asymmetric,
+		},
+		{
+			description: "symmetric calls",
+			code:        "within(e0, e1, d) || within(e1, e0, d)",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Symmetric,
+		},
+		{
+			description: "grouping",
+			code:        "(e0.a && e1.a) || e0.b",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Asymmetric,
+		},
+		{
+			description: "three variables",
+			code:        "e0.k == e1.k && e1.k == e2.k && e0.k == e2.k",
+			variables:   []string{"e0", "e1", "e2"},
+			symmetry:    Symmetric,
+		},
+		{
+			description: "three variables in a different order",
+			code:        "e0.x - e1.x == e2.x",
+			variables:   []string{"e0", "e1", "e2"},
+			symmetry:    Asymmetric,
+		},
+		{
+			description: "single variable",
+			code:        "e.x == e.x",
+			variables:   []string{"e"},
+			symmetry:    Asymmetric,
+		},
+		{
+			description: "not go",
+			code:        "e0.x ==",
+			variables:   []string{"e0", "e1"},
+			symmetry:    Asymmetric,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.Equal(t, tt.symmetry, SymmetryOf(tt.code, tt.variables))
+		})
+	}
+}
+
+func TestSymmetricUniversal(t *testing.T) {
+	type execution struct {
+		high, low, ret int
+	}
+
+	evaluations := 0
+	// Executions with the same low input return the same.
+	noninterference := func(assignments []execution) bool {
+		evaluations++
+		e0, e1 := assignments[0], assignments[1]
+		return e0.low != e1.low || e0.ret == e1.ret
+	}
+	secure := []execution{{1, 0, 5}, {2, 0, 5}, {3, 1, 7}, {4, 1, 7}}
+	insecure := append(secure, execution{5, 1, 8})
+
+	tests := []struct {
+		description string
+		symmetry    Symmetry
+		evaluations int
+	}{
+		{"asymmetric", Asymmetric, 16},
+		{"symmetric", Symmetric, 10},
+		{"reflexive", Reflexive, 12},
+		{"symmetric and reflexive", Symmetric | Reflexive, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			universal := NewSymmetricUniversalHyperAssertion(0, 2, tt.symmetry, NewPredicateHyperAssertion(noninterference))
+			interpreter := NewHyperAssertionInterpreter[execution]()
+
+			evaluations = 0
+			assert.Equal(t, LiftedUnknown, interpreter.Satisfies(universal, secure))
+			assert.Equal(t, tt.evaluations, evaluations)
+			assert.Equal(t, LiftedFalse, interpreter.Satisfies(universal, insecure))
+		})
+	}
+}
+
+func TestSymmetryOfNaN(t *testing.T) {
+	type execution struct {
+		x    int
+		ret0 float64
+	}
+
+	// A NaN is not equal to itself such that the same execution violates the
+	// determinism and reflexivity is not detected.
+	code := "e0.x != e1.x || e0.ret0 == e1.ret0"
+	symmetry := SymmetryOf(code, []string{"e0", "e1"})
+	assert.Equal(t, Symmetric, symmetry)
+
+	deterministic := NewSymmetricUniversalHyperAssertion(0, 2, symmetry, NewPredicateHyperAssertion(
+		func(assignments []execution) bool {
+			e0, e1 := assignments[0], assignments[1]
+			return e0.x != e1.x || e0.ret0 == e1.ret0
+		},
+	))
+	interpreter := NewHyperAssertionInterpreter[execution]()
+	assert.Equal(t, LiftedFalse, interpreter.Satisfies(deterministic, []execution{{0, math.NaN()}}))
+}
@@ -0,0 +1,67 @@
+package language
+
+import "time"
+
+// Timeline orders the executions assigned to the variables of an assertion by
+// the time they were made. Executions are indexed in the order they were made
+// such that comparing their positions is constant time. The times are nil if
+// the executions were not timed, e.g. those checked by a single call, in which
+// case they were all made at the same time.
+type Timeline struct {
+	// indices are the positions of the executions assigned to the variables.
+	indices []int
+	// times are the times of the executions by their positions.
+	times []time.Time
+	// seen is the number of executions of a history seen by its last
+	// evaluation.
+	seen int
+}
+
+// Assigned returns the timeline of the executions at the positions of the
+// indices assigned to the variables. The indices are not copied such that
+// compiled assertions assign them without allocating a timeline for every
+// assignment.
+func (timeline Timeline) Assigned(indices []int) Timeline {
+	return Timeline{indices: indices, times: timeline.times, seen: timeline.seen}
+}
+
+// Unseen returns the position of the first execution to assign to the
+// innermost variable of a universal given the positions of the executions
+// assigned to its outer variables. Assignments of only executions seen by the
+// last evaluation of a history were evaluated then, so if the outer positions
+// are all seen it is the first unseen execution and otherwise the first one.
+func (timeline Timeline) Unseen(outer ...int) int {
+	for _, position := range outer {
+		if position >= timeline.seen {
+			return 0
+		}
+	}
+	return timeline.seen
+}
+
+// Before reports whether the execution assigned to the variable a was made
+// before the execution assigned to b.
+func (timeline Timeline) Before(a, b int) bool {
+	return timeline.indices[a] < timeline.indices[b]
+}
+
+// Next reports whether the execution assigned to the variable b was made right
+// after the execution assigned to a without any other execution between them.
+// The executions of a composite contract are of every composed function such
+// that an execution of another function is between them.
+func (timeline Timeline) Next(a, b int) bool {
+	return timeline.indices[b] == timeline.indices[a]+1
+}
+
+// Within reports whether the execution assigned to the variable b was made at
+// most the duration after the execution assigned to a.
+func (timeline Timeline) Within(a, b int, duration time.Duration) bool {
+	if timeline.indices[b] < timeline.indices[a] {
+		return false
+	}
+	if timeline.times == nil {
+		return duration >= 0
+	}
+	elapsed := timeline.times[timeline.indices[b]].Sub(timeline.times[timeline.indices[a]])
+	return elapsed <= duration
+}
@@ -0,0 +1,134 @@
+package language
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeline(t *testing.T) {
+	type attempt struct {
+		attempt int
+	}
+
+	// Attempts increment on consecutive calls.
+	consecutive := NewUniversalHyperAssertion[attempt](0, 2, NewTemporalPredicateHyperAssertion(
+		func(assignments []attempt, timeline Timeline) bool {
+			e0, e1 := assignments[0], assignments[1]
+			return !timeline.Next(0, 1) || e1.attempt == e0.attempt+1
+		},
+	))
+	// Attempts are never repeated later on.
+	increasing := NewUniversalHyperAssertion[attempt](0, 2, NewTemporalPredicateHyperAssertion(
+		func(assignments []attempt, timeline Timeline) bool {
+			e0, e1 := assignments[0], assignments[1]
+			return !timeline.Before(0, 1) || e0.attempt < e1.attempt
+		},
+	))
+	// Attempts are at least a minute apart.
+	apart := NewUniversalHyperAssertion[attempt](0, 2, NewTemporalPredicateHyperAssertion(
+		func(assignments []attempt, timeline Timeline) bool {
+			return !timeline.Before(0, 1) || !timeline.Within(0, 1, time.Minute)
+		},
+	))
+
+	start := time.Now()
+	tests := []struct {
+		description string
+		assertion   HyperAssertion[attempt]
+		executions  []attempt
+		times       []time.Time
+		result      LiftedBoolean
+	}{
+		{
+			description: "consecutive attempts",
+			assertion:   consecutive,
+			executions:  []attempt{{1}, {2}, {3}},
+			result:      LiftedUnknown,
+		},
+		{
+			description: "skipped attempt",
+			assertion:   consecutive,
+			executions:  []attempt{{1}, {3}},
+			result:      LiftedFalse,
+		},
+		{
+			description: "increasing attempts",
+			assertion:   increasing,
+			executions:  []attempt{{1}, {3}, {7}},
+			result:      LiftedUnknown,
+		},
+		{
+			description: "repeated attempt",
+			assertion:   increasing,
+			executions:  []attempt{{1}, {3}, {2}},
+			result:      LiftedFalse,
+		},
+		{
+			description: "untimed attempts are made at the same time",
+			assertion:   apart,
+			executions:  []attempt{{1}, {2}},
+			result:      LiftedFalse,
+		},
+		{
+			description: "attempts a minute apart",
+			assertion:   apart,
+			executions:  []attempt{{1}, {2}, {3}},
+			times:       []time.Time{start, start.Add(2 * time.Minute), start.Add(4 * time.Minute)},
+			result:      LiftedUnknown,
+		},
+		{
+			description: "attempts seconds apart",
+			assertion:   apart,
+			executions:  []attempt{{1}, {2}, {3}},
+			times:       []time.Time{start, start.Add(2 * time.Minute), start.Add(2*time.Minute + time.Second)},
+			result:      LiftedFalse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			interpreter := NewHyperAssertionInterpreter[attempt]()
+			assert.Equal(t, tt.result, interpreter.SatisfiesTimed(tt.assertion, tt.executions, tt.times))
+		})
+	}
+}
+
+func TestTimelineUnseen(t *testing.T) {
+	tests := []struct {
+		description string
+		seen        int
+		outer       []int
+		unseen      int
+	}{
+		{
+			description: "nothing seen",
+			outer:       []int{2},
+			unseen:      0,
+		},
+		{
+			description: "innermost variable",
+			seen:        3,
+			unseen:      3,
+		},
+		{
+			description: "seen outer executions",
+			seen:        3,
+			outer:       []int{0, 2},
+			unseen:      3,
+		},
+		{
+			description: "unseen outer execution",
+			seen:        3,
+			outer:       []int{0, 3},
+			unseen:      0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			assert.Equal(t, tt.unseen, Timeline{seen: tt.seen}.Unseen(tt.outer...))
+		})
+	}
+}
@@ -30,6 +30,22 @@ func (class TokenClass) String() string {
 		return "("
 	case RightParenthesis:
 		return ")"
+	case LabelToken:
+		return "label"
+	case ComposeToken:
+		return "compose"
+	case TypeDelimiterToken:
+		return "type delimiter"
+	case SeparatorToken:
+		return "separator"
+	case AggregateToken:
+		return "aggregate"
+	case NumberToken:
+		return "number"
+	case GivenToken:
+		return "given"
+	case DistinctToken:
+		return "distinct"
 	case EofToken:
 		return "eof"
 	}
@@ -49,6 +65,14 @@ const (
 	ExpressionDelimiterToken
 	LeftParenthesis
 	RightParenthesis
+	LabelToken
+	ComposeToken
+	TypeDelimiterToken
+	SeparatorToken
+	AggregateToken
+	NumberToken
+	GivenToken
+	DistinctToken
 	EofToken
 )
 
@@ -63,3 +87,11 @@ func NewToken(class TokenClass, lexeme string) Token {
 		lexeme: lexeme,
 	}
 }
+
+func (token Token) Class() TokenClass {
+	return token.class
+}
+
+func (token Token) Lexeme() string {
+	return token.lexeme
+}
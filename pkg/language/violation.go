@@ -0,0 +1,113 @@
+package language
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Obligation identifies an assumption or a guarantee of a contract.
+type Obligation struct {
+	// Index is the position of the obligation among the assumptions or the
+	// guarantees of the contract.
+	Index int
+	// Label is the label of the obligation or empty if it is unlabelled.
+	Label string
+}
+
+// String returns the label of the obligation or its index if it is unlabelled.
+func (obligation Obligation) String() string {
+	if obligation.Label != "" {
+		return obligation.Label
+	}
+	return "#" + strconv.Itoa(obligation.Index)
+}
+
+// Estimate is the estimate of a statistical obligation, e.g. of the
+// probability of a condition, with an interval containing the estimated value
+// with the confidence. The result is true if the interval clears the threshold
+// of the obligation, false if it is breached and unknown otherwise.
+type Estimate struct {
+	Obligation   Obligation
+	Value        float64
+	Lower, Upper float64
+	Confidence   float64
+	Result       LiftedBoolean
+}
+
+// String describes the estimate, e.g. 0.93 in [0.9, 0.95] at 95% confidence.
+func (estimate Estimate) String() string {
+	return fmt.Sprintf(
+		"%.3g in [%.3g, %.3g] at %v%% confidence",
+		estimate.Value, estimate.Lower, estimate.Upper,
+		strconv.FormatFloat(estimate.Confidence*100, 'g', -1, 64),
+	)
+}
+
+// Witness is the executions assigned to the variables of a violated obligation
+// by the first assignment violating it, e.g. two executions with the same
+// input and different outputs. The executions are their positions in the
+// executions the obligation was evaluated on.
+type Witness struct {
+	Obligation Obligation
+	Executions []int
+}
+
+// Violation is the obligations of a contract violated by a set of executions.
+// The estimates are those of the statistical obligations whether they are
+// violated or not such that it shows how close they are to being violated.
+type Violation struct {
+	// Kind is either "assumption" or "guarantee".
+	Kind        string
+	Obligations []Obligation
+	Estimates   []Estimate
+	// Witnesses are the witnesses of the violated obligations violated by an
+	// assignment of executions to their variables.
+	Witnesses []Witness
+	// Result is the conjunction of the obligations. It is false if any is
+	// violated, true if every obligation holds regardless of executions yet to
+	// come and unknown if more executions can still violate it.
+	Result LiftedBoolean
+}
+
+// Violated reports whether any obligation was violated.
+func (violation Violation) Violated() bool {
+	return len(violation.Obligations) > 0
+}
+
+// Names returns the names of the violated obligations.
+func (violation Violation) Names() []string {
+	names := make([]string, len(violation.Obligations))
+	for idx, obligation := range violation.Obligations {
+		names[idx] = obligation.String()
+	}
+	return names
+}
+
+// Error describes the violation with the labels of the violated obligations
+// quoted, e.g. guarantees "Successful Check", #2 violated, and the estimates of
+// the violated statistical obligations.
+func (violation Violation) Error() string {
+	if !violation.Violated() {
+		return fmt.Sprintf("no %v violated", violation.Kind)
+	}
+
+	names := make([]string, len(violation.Obligations))
+	for idx, obligation := range violation.Obligations {
+		names[idx] = obligation.String()
+		if obligation.Label != "" {
+			names[idx] = strconv.Quote(obligation.Label)
+		}
+		for _, estimate := range violation.Estimates {
+			if estimate.Obligation == obligation && estimate.Result.IsFalse() {
+				names[idx] += " (" + estimate.String() + ")"
+			}
+		}
+	}
+
+	kind := violation.Kind
+	if len(names) > 1 {
+		kind += "s"
+	}
+	return fmt.Sprintf("%v %v violated", kind, strings.Join(names, ", "))
+}
@@ -0,0 +1,644 @@
+package lsp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/hyperproperties/sopher/pkg/filesx"
+	"github.com/hyperproperties/sopher/pkg/language"
+)
+
+// field is a field of the execution model of a contracted function.
+type field struct {
+	name string
+	typ  string
+	// declaration is the offset of the parameter or result declaring it.
+	declaration int
+	output      bool
+}
+
+// span is a highlighted range of a document.
+type span struct {
+	offset int
+	length int
+	typ    int
+}
+
+// contract is the analysis of the contract of a single function.
+type contract struct {
+	function string
+	// start and end are the offsets of the doc comment in the document.
+	start, end int
+	// uri and text are of the Go file declaring the function.
+	uri, text string
+	// unresolved reports whether the function of a sidecar contract could not
+	// be found such that the fields of its execution model are unknown.
+	unresolved bool
+	model      string
+	fields     []field
+	variables  []string
+	// types are the functions typing the variables of composite contracts and
+	// functions are the fields of the execution models of the functions
+	// declared next to the function.
+	types       map[string]string
+	functions   map[string][]field
+	spans       []span
+	diagnostics []diagnostic
+}
+
+type diagnostic struct {
+	offset, end int
+	message     string
+}
+
+// field returns the field with the name of the execution model of the function
+// typing the variable.
+func (contract *contract) field(variable, name string) (field, bool) {
+	fields, _ := contract.typed(variable)
+	for _, field := range fields {
+		if field.name == name {
+			return field, true
+		}
+	}
+	return field{}, false
+}
+
+// typed returns the fields of the execution model of the function typing the
+// variable. False is returned if the function is not declared next to the
+// function of the contract such that its fields are unknown.
+func (contract *contract) typed(variable string) ([]field, bool) {
+	function, exists := contract.types[variable]
+	if !exists || function == contract.function {
+		return contract.fields, !contract.unresolved
+	}
+	fields, exists := contract.functions[function]
+	return fields, exists
+}
+
+// document is an open text document and the contracts in it.
+type document struct {
+	uri       string
+	text      string
+	contracts []*contract
+}
+
+func newDocument(uri, text string) *document {
+	document := &document{uri: uri, text: text}
+	document.analyse()
+	return document
+}
+
+// contract returns the contract whose doc comment contains the offset.
+func (document *document) contract(offset int) (*contract, bool) {
+	for _, contract := range document.contracts {
+		if contract.start <= offset && offset <= contract.end {
+			return contract, true
+		}
+	}
+	return nil, false
+}
+
+// segment maps a part of the contract text to the doc comment in the document.
+type segment struct {
+	text   int
+	source int
+	length int
+}
+
+func (document *document) analyse() {
+	if strings.HasSuffix(document.uri, language.SidecarExtension) {
+		document.analyseSidecar()
+		return
+	}
+
+	fset := token.NewFileSet()
+	// Partial files are analysed as contracts are edited while the Go code is
+	// incomplete.
+	file, _ := parser.ParseFile(fset, document.uri, document.text, parser.ParseComments)
+	if file == nil {
+		return
+	}
+
+	decor := decorator.NewDecorator(fset)
+	decorated, err := decor.DecorateFile(file)
+	if err != nil {
+		decorated = nil
+	}
+
+	functions := make(map[string][]field)
+	for _, decl := range file.Decls {
+		if function, ok := decl.(*ast.FuncDecl); ok && function.Recv == nil {
+			functions[function.Name.Name] = fields(fset, function)
+		}
+	}
+
+	for _, decl := range file.Decls {
+		function, ok := decl.(*ast.FuncDecl)
+		if !ok || function.Doc == nil {
+			continue
+		}
+
+		// The comments are concatenated like language.LexDocStrings while
+		// remembering where each part came from.
+		var builder strings.Builder
+		var segments []segment
+		for _, comment := range function.Doc.List {
+			text := comment.Text
+			offset := fset.Position(comment.Slash).Offset + 2
+			if rest, ok := strings.CutPrefix(text, "/*"); ok {
+				text = strings.TrimSuffix(rest, "*/")
+			} else {
+				text = strings.TrimPrefix(text, "//")
+			}
+			segments = append(segments, segment{text: builder.Len(), source: offset, length: len(text)})
+			builder.WriteString(text)
+			if strings.HasPrefix(comment.Text, "//") {
+				builder.WriteRune('\n')
+			}
+		}
+
+		text := builder.String()
+		if !language.IsContract(text) {
+			continue
+		}
+
+		source := func(offset int) int {
+			for idx := len(segments) - 1; idx >= 0; idx-- {
+				if segments[idx].text <= offset {
+					return segments[idx].source + min(offset-segments[idx].text, segments[idx].length)
+				}
+			}
+			return segments[0].source
+		}
+
+		contract := &contract{
+			function:  function.Name.Name,
+			start:     fset.Position(function.Doc.Pos()).Offset,
+			end:       fset.Position(function.Doc.End()).Offset,
+			uri:       document.uri,
+			text:      document.text,
+			functions: functions,
+		}
+		contract.fields = fields(fset, function)
+		if decorated != nil {
+			if node, ok := decor.Dst.Nodes[function].(*dst.FuncDecl); ok {
+				contract.model = model(decorated.Name.Name, node)
+			}
+		}
+
+		contract.analyse(text, source)
+		document.contracts = append(document.contracts, contract)
+	}
+}
+
+// analyseSidecar analyses the contracts of a sidecar file. Their functions are
+// looked up in the module containing the sidecar file.
+func (document *document) analyseSidecar() {
+	sidecars, err := language.ParseSidecar(document.uri, document.text)
+	if err != nil {
+		start, end := 0, len(document.text)
+		if sidecarErr, ok := err.(*language.SidecarError); ok {
+			err = errors.New(sidecarErr.Message)
+			start, end = line(document.text, sidecarErr.Line)
+		}
+		document.contracts = append(document.contracts, &contract{
+			start:       start,
+			end:         end,
+			diagnostics: []diagnostic{{offset: start, end: end, message: err.Error()}},
+		})
+		return
+	}
+
+	for _, sidecar := range sidecars {
+		start, end := line(document.text, sidecar.Line)
+		contract := &contract{
+			function: sidecar.Function,
+			start:    start,
+			end:      sidecar.Offset + len(sidecar.Text),
+		}
+
+		// The function name follows the keyword starting the line.
+		name := strings.TrimLeft(document.text[start+len("func"):end], " \t")
+		contract.spans = append(contract.spans,
+			span{start, len("func"), keywordType},
+			span{end - len(name), len(strings.TrimRight(name, " \t\r")), namespaceType},
+		)
+
+		if err := contract.resolve(filepath.Dir(filename(document.uri)), sidecar.Function); err != nil {
+			contract.unresolved = true
+			if !errors.Is(err, filesx.ErrNoModule) {
+				contract.diagnostics = append(contract.diagnostics, diagnostic{start, end, err.Error()})
+			}
+		}
+
+		contract.analyse(sidecar.Text, func(offset int) int {
+			return sidecar.Offset + offset
+		})
+		document.contracts = append(document.contracts, contract)
+	}
+}
+
+// resolve finds the function with the fully-qualified name in the module of the
+// directory to determine the fields and the execution model of the contract.
+func (contract *contract) resolve(directory, function string) error {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	importPath := function[:slash+1+dot]
+
+	packageDirectory, err := filesx.PackageDirectory(directory, importPath)
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(packageDirectory)
+	if err != nil {
+		return fmt.Errorf("package %v does not exist", importPath)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		path := filepath.Join(packageDirectory, name)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		fset := token.NewFileSet()
+		decor := decorator.NewDecorator(fset)
+		file, err := decor.ParseFile(path, content, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+
+		for _, decl := range file.Decls {
+			declaration, ok := decl.(*dst.FuncDecl)
+			if !ok || language.QualifiedName(importPath, declaration) != function {
+				continue
+			}
+
+			contract.uri = "file://" + filepath.ToSlash(path)
+			contract.text = string(content)
+			contract.fields = fields(fset, decor.Ast.Nodes[declaration].(*ast.FuncDecl))
+			contract.model = model(file.Name.Name, declaration)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("package %v has no function %v", importPath, function[slash+1+dot+1:])
+}
+
+// filename returns the path of the file with the URI.
+func filename(uri string) string {
+	if parsed, err := url.Parse(uri); err == nil && parsed.Scheme == "file" {
+		return filepath.FromSlash(parsed.Path)
+	}
+	return uri
+}
+
+// line returns the offsets of the start and the end of the line with the number
+// counted from one.
+func line(text string, number int) (int, int) {
+	start := offset(text, Position{Line: number - 1})
+	end := strings.IndexByte(text[start:], '\n')
+	if end < 0 {
+		return start, len(text)
+	}
+	return start, start + end
+}
+
+// fields returns the fields of the execution model in the order and with the
+// names of language.Injector.Model.
+func fields(fset *token.FileSet, function *ast.FuncDecl) (fields []field) {
+	format := func(node ast.Node) string {
+		var buffer bytes.Buffer
+		printer.Fprint(&buffer, fset, node)
+		return buffer.String()
+	}
+
+	for _, parameter := range function.Type.Params.List {
+		for _, name := range parameter.Names {
+			fields = append(fields, field{
+				name:        name.Name,
+				typ:         format(parameter.Type),
+				declaration: fset.Position(name.Pos()).Offset,
+			})
+		}
+	}
+
+	if function.Type.Results == nil {
+		return fields
+	}
+
+	for idx, result := range function.Type.Results.List {
+		if len(result.Names) == 0 {
+			fields = append(fields, field{
+				name:        fmt.Sprintf("ret%v", idx),
+				typ:         format(result.Type),
+				declaration: fset.Position(result.Type.Pos()).Offset,
+				output:      true,
+			})
+		}
+		for _, name := range result.Names {
+			fields = append(fields, field{
+				name:        name.Name,
+				typ:         format(result.Type),
+				declaration: fset.Position(name.Pos()).Offset,
+				output:      true,
+			})
+		}
+	}
+
+	return fields
+}
+
+// model returns the source of the generated execution model of the function.
+func model(packageName string, function *dst.FuncDecl) string {
+	_, declaration := language.NewGoInjector().Model(function)
+	var buffer bytes.Buffer
+	err := decorator.Fprint(&buffer, &dst.File{
+		Name:  dst.NewIdent(packageName),
+		Decls: []dst.Decl{declaration},
+	})
+	if err != nil {
+		return ""
+	}
+	_, source, _ := strings.Cut(buffer.String(), "\n\n")
+	return strings.TrimSpace(source)
+}
+
+// analyse lexes and parses the contract text. Tokens are located in the text
+// by searching for their lexemes in order as they are copied verbatim by the
+// lexer. The source function maps offsets in the text to the document.
+func (contract *contract) analyse(text string, source func(int) int) {
+	fail := func(offset, end int, format string, args ...any) {
+		contract.diagnostics = append(contract.diagnostics, diagnostic{
+			offset:  source(offset),
+			end:     source(end),
+			message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	type located struct {
+		token  language.Token
+		offset int
+	}
+
+	var tokens []language.Token
+	var locations []located
+	cursor := 0
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				fail(cursor, len(text), "%v", recovered)
+			}
+		}()
+
+		for token := range language.LexString(text) {
+			tokens = append(tokens, token)
+			lexeme := token.Lexeme()
+			if lexeme == "" || lexeme == "\n" {
+				continue
+			}
+
+			switch token.Class() {
+			case language.ExpressionDelimiterToken, language.ScopeDelimiterToken:
+				// Delimiters may be implied by the end of a line.
+				if !strings.HasPrefix(strings.TrimLeft(text[cursor:], " \t"), lexeme) {
+					continue
+				}
+			}
+
+			idx := index(text[cursor:], lexeme)
+			if idx < 0 {
+				continue
+			}
+			locations = append(locations, located{token: token, offset: cursor + idx})
+			cursor += idx + len(lexeme)
+		}
+	}()
+
+	if len(contract.diagnostics) == 0 {
+		func() {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					fail(0, len(text), "%v", recovered)
+				}
+			}()
+
+			parser := language.NewParser(slices.Values(tokens))
+			parser.Parse()
+		}()
+	}
+
+	// Functions composed or typing variables are highlighted as namespaces and
+	// type the untyped variables since the previous separator.
+	previous := language.EofToken
+	composing := false
+	var untyped []string
+	for _, location := range locations {
+		class := location.token.Class()
+		length := len(location.token.Lexeme())
+		switch class {
+		case language.RegionToken, language.AssumeToken, language.GuaranteeToken,
+			language.ForallToken, language.DistinctToken, language.ExistsToken, language.ComposeToken,
+			language.AggregateToken:
+			contract.spans = append(contract.spans, span{source(location.offset), length, keywordType})
+			composing = class == language.ComposeToken
+			untyped = nil
+			previous = class
+			continue
+		case language.IdentifierToken:
+			typ := variableType
+			lexeme := location.token.Lexeme()
+			if previous == language.RegionToken || composing {
+				typ = namespaceType
+			} else if previous == language.TypeDelimiterToken {
+				typ = namespaceType
+				if contract.types == nil {
+					contract.types = make(map[string]string)
+				}
+				for _, variable := range untyped {
+					contract.types[variable] = lexeme
+				}
+				untyped = nil
+				previous = class
+			} else {
+				if !slices.Contains(contract.variables, lexeme) {
+					contract.variables = append(contract.variables, lexeme)
+				}
+				untyped = append(untyped, lexeme)
+			}
+			contract.spans = append(contract.spans, span{source(location.offset), length, typ})
+			continue
+		case language.SeparatorToken:
+			untyped = nil
+		case language.LabelToken:
+			contract.spans = append(contract.spans, span{source(location.offset), length, stringType})
+			continue
+		}
+		previous = class
+	}
+
+	// The expressions of aggregates may use duration literals.
+	aggregate := false
+	for _, location := range locations {
+		switch location.token.Class() {
+		case language.AggregateToken:
+			aggregate = true
+		case language.ExpressionToken:
+			code := location.token.Lexeme()
+			if aggregate {
+				code = language.MaskDurations(code)
+			}
+			contract.expression(code, location.offset, source, fail)
+			aggregate = false
+		}
+	}
+
+	slices.SortFunc(contract.spans, func(a, b span) int {
+		return a.offset - b.offset
+	})
+}
+
+// expression checks the Go expression and highlights its quantified variables
+// and their fields.
+func (contract *contract) expression(
+	code string, offset int, source func(int) int, fail func(int, int, string, ...any),
+) {
+	fset := token.NewFileSet()
+	expression, err := parser.ParseExprFrom(fset, "", code, 0)
+	if err != nil {
+		if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+			position := offset + min(list[0].Pos.Offset, len(code))
+			fail(position, offset+len(code), "%v", list[0].Msg)
+		} else {
+			fail(offset, offset+len(code), "%v", err)
+		}
+		return
+	}
+
+	at := func(pos token.Pos) int {
+		return offset + fset.Position(pos).Offset
+	}
+
+	ast.Inspect(expression, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		variable, ok := selector.X.(*ast.Ident)
+		if !ok || !slices.Contains(contract.variables, variable.Name) {
+			return true
+		}
+
+		contract.spans = append(contract.spans, span{source(at(variable.Pos())), len(variable.Name), variableType})
+		_, known := contract.typed(variable.Name)
+		if _, exists := contract.field(variable.Name, selector.Sel.Name); exists {
+			contract.spans = append(contract.spans, span{source(at(selector.Sel.Pos())), len(selector.Sel.Name), propertyType})
+		} else if known {
+			function := contract.function
+			if typed, exists := contract.types[variable.Name]; exists {
+				function = typed
+			}
+			fail(at(selector.Sel.Pos()), at(selector.Sel.End()),
+				"%v has no field %v in the execution model of %v", variable.Name, selector.Sel.Name, function)
+		}
+		return false
+	})
+}
+
+// index returns the offset of the first occurrence of the lexeme in the text.
+// Spaces of the lexeme also match the line breaks and backslashes continuing
+// an expression over multiple lines as the lexer replaces them by spaces.
+func index(text, lexeme string) int {
+	for start := 0; start+len(lexeme) <= len(text); start++ {
+		matches := true
+		for idx := 0; idx < len(lexeme) && matches; idx++ {
+			character := text[start+idx]
+			matches = character == lexeme[idx] ||
+				(lexeme[idx] == ' ' && (character == '\n' || character == '\\'))
+		}
+		if matches {
+			return start
+		}
+	}
+	return -1
+}
+
+// position converts a byte offset of the text to a protocol position which
+// counts characters in UTF-16 code units.
+func position(text string, offset int) Position {
+	offset = max(0, min(offset, len(text)))
+	line := strings.Count(text[:offset], "\n")
+	start := strings.LastIndex(text[:offset], "\n") + 1
+	return Position{
+		Line:      line,
+		Character: len(utf16.Encode([]rune(text[start:offset]))),
+	}
+}
+
+// offset converts a protocol position to a byte offset of the text.
+func offset(text string, position Position) int {
+	start := 0
+	for line := 0; line < position.Line; line++ {
+		next := strings.IndexByte(text[start:], '\n')
+		if next < 0 {
+			return len(text)
+		}
+		start += next + 1
+	}
+
+	units := 0
+	for idx, character := range text[start:] {
+		if units >= position.Character || character == '\n' {
+			return start + idx
+		}
+		units += len(utf16.Encode([]rune{character}))
+	}
+	return len(text)
+}
+
+// word returns the identifier around the offset and where it starts.
+func word(text string, offset int) (string, int) {
+	isIdentifier := func(character byte) bool {
+		return character == '_' || ('a' <= character && character <= 'z') ||
+			('A' <= character && character <= 'Z') || ('0' <= character && character <= '9')
+	}
+
+	start, end := offset, offset
+	for start > 0 && isIdentifier(text[start-1]) {
+		start--
+	}
+	for end < len(text) && isIdentifier(text[end]) {
+		end++
+	}
+	return text[start:end], start
+}
+
+// qualifier returns the identifier before the dot preceding the offset.
+func qualifier(text string, offset int) (string, bool) {
+	if offset == 0 || text[offset-1] != '.' {
+		return "", false
+	}
+	name, _ := word(text, offset-1)
+	return name, name != ""
+}
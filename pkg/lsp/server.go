@@ -0,0 +1,348 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Server is a language server for hyper-contracts in the doc comments of Go
+// functions. It communicates over JSON-RPC with Content-Length framing as
+// specified by the Language Server Protocol.
+type Server struct {
+	reader    *bufio.Reader
+	writer    io.Writer
+	documents map[string]*document
+}
+
+func NewServer(reader io.Reader, writer io.Writer) *Server {
+	return &Server{
+		reader:    bufio.NewReader(reader),
+		writer:    writer,
+		documents: make(map[string]*document),
+	}
+}
+
+// Serve handles messages until the client exits or the connection is closed.
+func (server *Server) Serve() error {
+	for {
+		request, err := server.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if request.Method == "exit" {
+			return nil
+		}
+
+		result, failure := server.handle(request)
+		// Notifications have no identifier and are not responded to.
+		if request.ID == nil {
+			continue
+		}
+
+		response := message{JSONRPC: "2.0", ID: request.ID, Result: result, Error: failure}
+		if result == nil && failure == nil {
+			// The result must be present, even if null, for successful requests.
+			response.Result = json.RawMessage("null")
+		}
+		if err := server.write(response); err != nil {
+			return err
+		}
+	}
+}
+
+func (server *Server) read() (message, error) {
+	headers, err := textproto.NewReader(server.reader).ReadMIMEHeader()
+	if err != nil {
+		return message{}, err
+	}
+
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return message{}, fmt.Errorf("invalid content length: %w", err)
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(server.reader, content); err != nil {
+		return message{}, err
+	}
+
+	var request message
+	err = json.Unmarshal(content, &request)
+	return request, err
+}
+
+func (server *Server) write(response message) error {
+	content, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(server.writer, "Content-Length: %v\r\n\r\n%s", len(content), content)
+	return err
+}
+
+func (server *Server) notify(method string, params any) error {
+	content, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return server.write(message{JSONRPC: "2.0", Method: method, Params: content})
+}
+
+func (server *Server) handle(request message) (any, *responseError) {
+	decode := func(params any) *responseError {
+		if err := json.Unmarshal(request.Params, params); err != nil {
+			return &responseError{Code: invalidParams, Message: err.Error()}
+		}
+		return nil
+	}
+
+	switch request.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":   1,
+				"hoverProvider":      true,
+				"definitionProvider": true,
+				"completionProvider": map[string]any{
+					"triggerCharacters": []string{"."},
+				},
+				"semanticTokensProvider": map[string]any{
+					"legend": map[string]any{
+						"tokenTypes":     tokenTypes,
+						"tokenModifiers": []string{},
+					},
+					"full": true,
+				},
+			},
+			"serverInfo": map[string]any{"name": "sopher"},
+		}, nil
+	case "initialized", "shutdown", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		server.open(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		// Documents are synchronised in full so the last change is the text.
+		if len(params.ContentChanges) > 0 {
+			server.open(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		delete(server.documents, params.TextDocument.URI)
+		server.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+	case "textDocument/semanticTokens/full":
+		var params semanticTokensParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		return server.semanticTokens(params.TextDocument.URI), nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		if hover, ok := server.hover(params); ok {
+			return hover, nil
+		}
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		return server.completion(params), nil
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		if location, ok := server.definition(params); ok {
+			return location, nil
+		}
+	default:
+		if request.ID != nil {
+			return nil, &responseError{Code: methodNotFound, Message: "method not found: " + request.Method}
+		}
+	}
+
+	return nil, nil
+}
+
+func (server *Server) open(uri, text string) {
+	document := newDocument(uri, text)
+	server.documents[uri] = document
+
+	diagnostics := []Diagnostic{}
+	for _, contract := range document.contracts {
+		for _, diagnostic := range contract.diagnostics {
+			diagnostics = append(diagnostics, Diagnostic{
+				Range: Range{
+					Start: position(text, diagnostic.offset),
+					End:   position(text, max(diagnostic.offset, diagnostic.end)),
+				},
+				Severity: SeverityError,
+				Source:   "sopher",
+				Message:  diagnostic.message,
+			})
+		}
+	}
+
+	server.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnostics,
+	})
+}
+
+// semanticTokens encodes the spans of all contracts relative to each other.
+func (server *Server) semanticTokens(uri string) SemanticTokens {
+	tokens := SemanticTokens{Data: []int{}}
+	document, exists := server.documents[uri]
+	if !exists {
+		return tokens
+	}
+
+	var spans []span
+	for _, contract := range document.contracts {
+		spans = append(spans, contract.spans...)
+	}
+	slices.SortFunc(spans, func(a, b span) int {
+		return a.offset - b.offset
+	})
+
+	previous := Position{}
+	for _, span := range spans {
+		start := position(document.text, span.offset)
+		end := position(document.text, span.offset+span.length)
+		character := start.Character
+		if start.Line == previous.Line {
+			character -= previous.Character
+		}
+		tokens.Data = append(tokens.Data,
+			start.Line-previous.Line, character, end.Character-start.Character, span.typ, 0)
+		previous = start
+	}
+
+	return tokens
+}
+
+// lookup returns the document, the contract and the offset of the position.
+func (server *Server) lookup(params textDocumentPositionParams) (*document, *contract, int, bool) {
+	document, exists := server.documents[params.TextDocument.URI]
+	if !exists {
+		return nil, nil, 0, false
+	}
+
+	at := offset(document.text, params.Position)
+	contract, ok := document.contract(at)
+	return document, contract, at, ok
+}
+
+func (server *Server) hover(params textDocumentPositionParams) (Hover, bool) {
+	document, contract, at, ok := server.lookup(params)
+	if !ok || contract.model == "" {
+		return Hover{}, false
+	}
+
+	var builder strings.Builder
+	name, start := word(document.text, at)
+	if variable, qualified := qualifier(document.text, start); qualified && slices.Contains(contract.variables, variable) {
+		if field, exists := contract.field(variable, name); exists {
+			kind := "input"
+			if field.output {
+				kind = "output"
+			}
+			fmt.Fprintf(&builder, "%v `%v %v` of the execution model\n\n", kind, field.name, field.typ)
+		}
+	} else if slices.Contains(contract.variables, name) {
+		fmt.Fprintf(&builder, "quantified execution `%v`\n\n", name)
+	}
+
+	fmt.Fprintf(&builder, "```go\n%v\n```", contract.model)
+
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: builder.String()},
+	}, true
+}
+
+func (server *Server) completion(params textDocumentPositionParams) []CompletionItem {
+	items := []CompletionItem{}
+	document, contract, at, ok := server.lookup(params)
+	if !ok {
+		return items
+	}
+
+	_, start := word(document.text, at)
+	if variable, qualified := qualifier(document.text, start); qualified {
+		if !slices.Contains(contract.variables, variable) {
+			return items
+		}
+		fields, _ := contract.typed(variable)
+		for _, field := range fields {
+			items = append(items, CompletionItem{Label: field.name, Kind: CompletionField, Detail: field.typ})
+		}
+		return items
+	}
+
+	for _, variable := range contract.variables {
+		items = append(items, CompletionItem{Label: variable, Kind: CompletionVariable, Detail: "execution"})
+	}
+	for _, keyword := range []string{"assume", "guarantee", "forall", "distinct", "exists", "region", "compose", "mean", "sum", "count", "percentile", "probability"} {
+		items = append(items, CompletionItem{Label: keyword, Kind: CompletionKeyword})
+	}
+	return items
+}
+
+func (server *Server) definition(params textDocumentPositionParams) (Location, bool) {
+	document, contract, at, ok := server.lookup(params)
+	if !ok {
+		return Location{}, false
+	}
+
+	name, start := word(document.text, at)
+	variable, qualified := qualifier(document.text, start)
+	if !qualified || !slices.Contains(contract.variables, variable) {
+		return Location{}, false
+	}
+
+	field, exists := contract.field(variable, name)
+	if !exists {
+		return Location{}, false
+	}
+
+	length := len(field.name)
+	if strings.HasPrefix(field.name, "ret") && !strings.HasPrefix(contract.text[field.declaration:], field.name) {
+		// Unnamed results are declared by their type.
+		length = len(field.typ)
+	}
+
+	return Location{
+		URI: contract.uri,
+		Range: Range{
+			Start: position(contract.text, field.declaration),
+			End:   position(contract.text, field.declaration+length),
+		},
+	}, true
+}
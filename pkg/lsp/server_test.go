@@ -0,0 +1,312 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const source = `package pins
+
+// assume: forall e. e.attempt >= 0
+// guarantee: forall e0 e1. e0.pin == e1.pin || e0.ret0 == e1.ret0 // Deterministic
+func CheckPIN(attempt int, pin int) bool {
+	return attempt < 3 && pin == 1234
+}
+
+// Plain documentation is not a contract.
+func Other() {}
+`
+
+// session runs the server on the requests and returns its messages.
+func session(t *testing.T, requests ...map[string]any) []message {
+	var input bytes.Buffer
+	for idx, request := range requests {
+		request["jsonrpc"] = "2.0"
+		if _, notification := request["notification"]; notification {
+			delete(request, "notification")
+		} else {
+			request["id"] = idx
+		}
+		content, err := json.Marshal(request)
+		assert.NoError(t, err)
+		fmt.Fprintf(&input, "Content-Length: %v\r\n\r\n%s", len(content), content)
+	}
+
+	var output bytes.Buffer
+	assert.NoError(t, NewServer(&input, &output).Serve())
+
+	var messages []message
+	reader := bufio.NewReader(&output)
+	for {
+		headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if err == io.EOF {
+			return messages
+		}
+		assert.NoError(t, err)
+		length, _ := strconv.Atoi(headers.Get("Content-Length"))
+		content := make([]byte, length)
+		_, err = io.ReadFull(reader, content)
+		assert.NoError(t, err)
+
+		var message message
+		assert.NoError(t, json.Unmarshal(content, &message))
+		if message.Result != nil {
+			// Results are decoded generically and re-encoded for assertions.
+			encoded, _ := json.Marshal(message.Result)
+			message.Result = string(encoded)
+		}
+		messages = append(messages, message)
+	}
+}
+
+func open(text string) map[string]any {
+	return map[string]any{
+		"notification": true,
+		"method":       "textDocument/didOpen",
+		"params": map[string]any{
+			"textDocument": map[string]any{"uri": "file:///pins.go", "text": text},
+		},
+	}
+}
+
+func at(method string, line, character int) map[string]any {
+	return map[string]any{
+		"method": method,
+		"params": map[string]any{
+			"textDocument": map[string]any{"uri": "file:///pins.go"},
+			"position":     map[string]any{"line": line, "character": character},
+		},
+	}
+}
+
+func TestServer(t *testing.T) {
+	messages := session(t,
+		map[string]any{"method": "initialize", "params": map[string]any{}},
+		open(source),
+		at("textDocument/hover", 3, 31),
+		at("textDocument/completion", 3, 31),
+		at("textDocument/definition", 3, 31),
+		map[string]any{"method": "shutdown"},
+		map[string]any{"method": "exit", "notification": true},
+	)
+	assert.Len(t, messages, 6)
+
+	assert.Contains(t, messages[0].Result, `"semanticTokensProvider"`)
+
+	assert.Equal(t, "textDocument/publishDiagnostics", messages[1].Method)
+	assert.Contains(t, string(messages[1].Params), `"diagnostics":[]`)
+
+	hover := messages[2].Result.(string)
+	assert.Contains(t, hover, "input `pin int` of the execution model")
+	assert.Contains(t, hover, "type CheckPIN_ExecutionModel struct")
+
+	completion := messages[3].Result.(string)
+	for _, field := range []string{"attempt", "pin", "ret0"} {
+		assert.Contains(t, completion, fmt.Sprintf(`"label":%q`, field))
+	}
+	assert.NotContains(t, completion, `"label":"guarantee"`)
+
+	assert.JSONEq(t, `{"uri":"file:///pins.go","range":{"start":{"line":4,"character":27},"end":{"line":4,"character":30}}}`, messages[4].Result.(string))
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		description string
+		contract    string
+		message     string
+	}{
+		{
+			description: "unknown field",
+			contract:    "// guarantee: forall e. e.pins == 0",
+			message:     "e has no field pins in the execution model of CheckPIN",
+		},
+		{
+			description: "invalid expression",
+			contract:    "// guarantee: forall e. e.pin ==",
+			message:     "expected operand",
+		},
+		{
+			description: "missing assertion",
+			contract:    "// guarantee:\n// region",
+			message:     "unknown assertion",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			text := strings.Replace(source, "// guarantee: forall e0 e1. e0.pin == e1.pin || e0.ret0 == e1.ret0", tt.contract, 1)
+			document := newDocument("file:///pins.go", text)
+			assert.Len(t, document.contracts, 1)
+			assert.NotEmpty(t, document.contracts[0].diagnostics)
+			assert.Contains(t, document.contracts[0].diagnostics[0].message, tt.message)
+		})
+	}
+
+	document := newDocument("file:///pins.go", strings.Replace(source, "e0.pin == e1.pin", "e0.pins == e1.pin", 1))
+	diagnostic := document.contracts[0].diagnostics[0]
+	assert.Equal(t, "pins", document.text[diagnostic.offset:diagnostic.end])
+
+	// Expressions continued over multiple lines are located in the document.
+	document = newDocument("file:///pins.go", strings.Replace(source, "e0.pin == e1.pin ||", "e0.pin == e1.pin ||\n//\t\te0.pins == e1.pin ||", 1))
+	assert.Len(t, document.contracts[0].diagnostics, 1)
+	diagnostic = document.contracts[0].diagnostics[0]
+	assert.Equal(t, "pins", document.text[diagnostic.offset:diagnostic.end])
+}
+
+func TestSemanticTokens(t *testing.T) {
+	server := NewServer(strings.NewReader(""), io.Discard)
+	decode := func(uri, text string) (decoded []string) {
+		server.open(uri, text)
+		tokens := server.semanticTokens(uri)
+
+		line, character := 0, 0
+		lines := strings.Split(text, "\n")
+		for idx := 0; idx < len(tokens.Data); idx += 5 {
+			if tokens.Data[idx] > 0 {
+				character = 0
+			}
+			line += tokens.Data[idx]
+			character += tokens.Data[idx+1]
+			text := lines[line][character : character+tokens.Data[idx+2]]
+			decoded = append(decoded, tokenTypes[tokens.Data[idx+3]]+":"+text)
+		}
+		return decoded
+	}
+
+	assert.Equal(t, []string{
+		"keyword:assume", "keyword:forall", "variable:e", "variable:e", "property:attempt",
+		"keyword:guarantee", "keyword:forall", "variable:e0", "variable:e1",
+		"variable:e0", "property:pin", "variable:e1", "property:pin",
+		"variable:e0", "property:ret0", "variable:e1", "property:ret0", "string:Deterministic",
+	}, decode("file:///pins.go", source))
+
+	// Universals over distinct executions are highlighted by their keyword.
+	distinct := strings.Replace(source, "forall e0 e1.", "forall distinct e0 e1.", 1)
+	assert.Equal(t, []string{
+		"keyword:guarantee", "keyword:forall", "keyword:distinct", "variable:e0", "variable:e1",
+	}, decode("file:///distinct.go", distinct)[5:10])
+}
+
+func TestSidecar(t *testing.T) {
+	directory := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(directory, "go.mod"), []byte("module example.com/pins\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(directory, "pins.go"), []byte(`package pins
+
+func CheckPIN(attempt int, pin string) bool {
+	return attempt <= 3
+}
+`), 0644))
+	uri := "file://" + filepath.ToSlash(filepath.Join(directory, "pins.sopher"))
+
+	sidecar := `// Contracts of the pins package.
+func example.com/pins.CheckPIN
+guarantee: forall e0 e1. e0.pin != e1.pin || e0.ret0 == e1.ret0
+`
+	document := newDocument(uri, sidecar)
+	if !assert.Len(t, document.contracts, 1) {
+		return
+	}
+	contract := document.contracts[0]
+	assert.Empty(t, contract.diagnostics)
+	assert.Contains(t, contract.model, "type CheckPIN_ExecutionModel struct")
+	assert.Equal(t, "file://"+filepath.ToSlash(filepath.Join(directory, "pins.go")), contract.uri)
+
+	field, exists := contract.field("e0", "pin")
+	assert.True(t, exists)
+	assert.Equal(t, "pin string", contract.text[field.declaration:field.declaration+len("pin string")])
+
+	document = newDocument(uri, strings.Replace(sidecar, "e0.pin !=", "e0.pins !=", 1))
+	diagnostic := document.contracts[0].diagnostics[0]
+	assert.Equal(t, "pins", document.text[diagnostic.offset:diagnostic.end])
+
+	document = newDocument(uri, strings.Replace(sidecar, "CheckPIN", "Check", 1))
+	diagnostic = document.contracts[0].diagnostics[0]
+	assert.Equal(t, "package example.com/pins has no function Check", diagnostic.message)
+	assert.Equal(t, "func example.com/pins.Check", document.text[diagnostic.offset:diagnostic.end])
+
+	document = newDocument(uri, strings.Replace(sidecar, "example.com/pins.CheckPIN", "CheckPIN", 1))
+	diagnostic = document.contracts[0].diagnostics[0]
+	assert.Equal(t, "CheckPIN is not a fully-qualified function name", diagnostic.message)
+	assert.Equal(t, "func CheckPIN", document.text[diagnostic.offset:diagnostic.end])
+
+	// Functions outside of a module are not resolved and their fields unknown.
+	document = newDocument("file:///pins.sopher", sidecar)
+	assert.Empty(t, document.contracts[0].diagnostics)
+	assert.True(t, document.contracts[0].unresolved)
+}
+
+func TestComposite(t *testing.T) {
+	text := `package storage
+
+func Read(path string) bool {
+	return path != ""
+}
+
+// compose: Read
+// guarantee: forall e, r: Read. r.path != e.path || !r.ret0
+func Erase(path string) {}
+`
+
+	server := NewServer(strings.NewReader(""), io.Discard)
+	server.open("file:///storage.go", text)
+	document := server.documents["file:///storage.go"]
+	if !assert.Len(t, document.contracts, 1) {
+		return
+	}
+	assert.Empty(t, document.contracts[0].diagnostics)
+
+	tokens := server.semanticTokens("file:///storage.go")
+	var decoded []string
+	line, character := 0, 0
+	lines := strings.Split(text, "\n")
+	for idx := 0; idx < len(tokens.Data); idx += 5 {
+		if tokens.Data[idx] > 0 {
+			character = 0
+		}
+		line += tokens.Data[idx]
+		character += tokens.Data[idx+1]
+		decoded = append(decoded, tokenTypes[tokens.Data[idx+3]]+":"+lines[line][character:character+tokens.Data[idx+2]])
+	}
+	assert.Equal(t, []string{
+		"keyword:compose", "namespace:Read",
+		"keyword:guarantee", "keyword:forall", "variable:e", "variable:r", "namespace:Read",
+		"variable:r", "property:path", "variable:e", "property:path", "variable:r", "property:ret0",
+	}, decoded)
+
+	// Typed variables are checked against the execution model of their function.
+	document = newDocument("file:///storage.go", strings.Replace(text, "!r.ret0", "!e.ret0", 1))
+	if assert.Len(t, document.contracts[0].diagnostics, 1) {
+		assert.Equal(t, "e has no field ret0 in the execution model of Erase", document.contracts[0].diagnostics[0].message)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	text := strings.Replace(source, "// assume: forall e. e.attempt >= 0", "// guarantee: percentile(95) e. e.attempt <= 1ms", 1)
+	document := newDocument("file:///pins.go", text)
+	if !assert.Len(t, document.contracts, 1) {
+		return
+	}
+	assert.Empty(t, document.contracts[0].diagnostics)
+
+	var highlighted []string
+	for _, span := range document.contracts[0].spans {
+		highlighted = append(highlighted, document.text[span.offset:span.offset+span.length])
+	}
+	assert.Equal(t, []string{"guarantee", "percentile", "e", "e", "attempt"}, highlighted[:5])
+
+	document = newDocument("file:///pins.go", strings.Replace(text, "e.attempt <=", "e.attempts <=", 1))
+	if assert.Len(t, document.contracts[0].diagnostics, 1) {
+		assert.Equal(t, "e has no field attempts in the execution model of CheckPIN", document.contracts[0].diagnostics[0].message)
+	}
+}
@@ -0,0 +1,242 @@
+package quick
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+)
+
+// Bias is the probability distribution over the strategies used when
+// generating numbers and strings. The remaining probability mass not covered
+// by the fields is spent on uniformly random values.
+type Bias struct {
+	// Boundary is the probability of choosing one of 0, 1, -1 or the
+	// minimum and maximum values of the type.
+	Boundary float64
+	// Small is the probability of choosing a value in [-size, size].
+	Small float64
+	// Interesting is the probability of choosing a value registered with
+	// Interesting or one of its immediate neighbours.
+	Interesting float64
+}
+
+// DefaultBias is the bias used by the generator.
+var DefaultBias = Bias{
+	Boundary:    0.2,
+	Small:       0.2,
+	Interesting: 0.2,
+}
+
+var (
+	interestingMutex   sync.RWMutex
+	interestingInts    []int64
+	interestingUints   []uint64
+	interestingFloats  []float64
+	interestingStrings []string
+)
+
+// Interesting registers values which are mixed into generation, typically
+// constants harvested from function bodies and contract expressions. Values
+// of unsupported kinds are ignored.
+func Interesting(values ...any) {
+	interestingMutex.Lock()
+	defer interestingMutex.Unlock()
+
+	for _, value := range values {
+		reflected := reflect.ValueOf(value)
+		switch reflected.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			number := reflected.Int()
+			interestingInts = append(interestingInts, number)
+			if number >= 0 {
+				interestingUints = append(interestingUints, uint64(number))
+			}
+			interestingFloats = append(interestingFloats, float64(number))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			number := reflected.Uint()
+			interestingUints = append(interestingUints, number)
+			if number <= math.MaxInt64 {
+				interestingInts = append(interestingInts, int64(number))
+			}
+			interestingFloats = append(interestingFloats, float64(number))
+		case reflect.Float32, reflect.Float64:
+			number := reflected.Float()
+			interestingFloats = append(interestingFloats, number)
+			if number == math.Trunc(number) && math.Abs(number) <= math.MaxInt64 {
+				interestingInts = append(interestingInts, int64(number))
+				if number >= 0 {
+					interestingUints = append(interestingUints, uint64(number))
+				}
+			}
+		case reflect.String:
+			interestingStrings = append(interestingStrings, reflected.String())
+		}
+	}
+}
+
+// ForgetInteresting removes all values registered with Interesting.
+func ForgetInteresting() {
+	interestingMutex.Lock()
+	defer interestingMutex.Unlock()
+
+	interestingInts = nil
+	interestingUints = nil
+	interestingFloats = nil
+	interestingStrings = nil
+}
+
+// strategy chooses between boundary, small, interesting and uniform values.
+type strategy uint8
+
+const (
+	boundaryStrategy = strategy(iota)
+	smallStrategy
+	interestingStrategy
+	uniformStrategy
+)
+
+func (bias Bias) choose(random *rand.Rand) strategy {
+	sample := random.Float64()
+	if sample < bias.Boundary {
+		return boundaryStrategy
+	}
+	sample -= bias.Boundary
+	if sample < bias.Small {
+		return smallStrategy
+	}
+	sample -= bias.Small
+	if sample < bias.Interesting {
+		return interestingStrategy
+	}
+	return uniformStrategy
+}
+
+func pick[T any](random *rand.Rand, candidates []T) (T, bool) {
+	if len(candidates) == 0 {
+		var zero T
+		return zero, false
+	}
+	return candidates[random.IntN(len(candidates))], true
+}
+
+// biasedInt returns a biased value within the bounds of the type and the
+// constraints or false if uniform generation should be used.
+func biasedInt(random *rand.Rand, size int, typ reflect.Type, constraints Constraints) (int64, bool) {
+	bits := typ.Bits()
+	lower := int64(-1) << (bits - 1)
+	upper := int64(uint64(1)<<(bits-1) - 1)
+
+	var candidates []int64
+	switch DefaultBias.choose(random) {
+	case boundaryStrategy:
+		candidates = []int64{0, 1, -1, lower, upper}
+		if constraints.min != nil {
+			candidates = append(candidates, int64(*constraints.min))
+		}
+		if constraints.max != nil {
+			candidates = append(candidates, int64(*constraints.max))
+		}
+	case smallStrategy:
+		candidates = []int64{int64(random.IntN(2*size+1) - size)}
+	case interestingStrategy:
+		interestingMutex.RLock()
+		if value, ok := pick(random, interestingInts); ok {
+			candidates = []int64{value - 1, value, value + 1}
+		}
+		interestingMutex.RUnlock()
+	}
+
+	value, ok := pick(random, candidates)
+	if !ok || !constraints.contains(float64(value)) || value < lower || value > upper {
+		return 0, false
+	}
+	return value, true
+}
+
+func biasedUint(random *rand.Rand, size int, typ reflect.Type, constraints Constraints) (uint64, bool) {
+	upper := uint64(math.MaxUint64) >> (64 - typ.Bits())
+
+	var candidates []uint64
+	switch DefaultBias.choose(random) {
+	case boundaryStrategy:
+		candidates = []uint64{0, 1, upper}
+		if constraints.min != nil && *constraints.min >= 0 {
+			candidates = append(candidates, uint64(*constraints.min))
+		}
+		if constraints.max != nil && *constraints.max >= 0 {
+			candidates = append(candidates, uint64(*constraints.max))
+		}
+	case smallStrategy:
+		candidates = []uint64{uint64(random.IntN(size + 1))}
+	case interestingStrategy:
+		interestingMutex.RLock()
+		if value, ok := pick(random, interestingUints); ok {
+			candidates = []uint64{value, value + 1}
+			if value > 0 {
+				candidates = append(candidates, value-1)
+			}
+		}
+		interestingMutex.RUnlock()
+	}
+
+	value, ok := pick(random, candidates)
+	if !ok || !constraints.contains(float64(value)) || value > upper {
+		return 0, false
+	}
+	return value, true
+}
+
+func biasedFloat(random *rand.Rand, size int, typ reflect.Type, constraints Constraints) (float64, bool) {
+	upper := math.MaxFloat64
+	if typ.Kind() == reflect.Float32 {
+		upper = math.MaxFloat32
+	}
+
+	var candidates []float64
+	switch DefaultBias.choose(random) {
+	case boundaryStrategy:
+		candidates = []float64{0, 1, -1, upper, -upper, math.SmallestNonzeroFloat32}
+		if constraints.min != nil {
+			candidates = append(candidates, *constraints.min)
+		}
+		if constraints.max != nil {
+			candidates = append(candidates, *constraints.max)
+		}
+	case smallStrategy:
+		candidates = []float64{(random.Float64()*2 - 1) * float64(size)}
+	case interestingStrategy:
+		interestingMutex.RLock()
+		if value, ok := pick(random, interestingFloats); ok {
+			candidates = []float64{value, math.Nextafter(value, math.Inf(-1)), math.Nextafter(value, math.Inf(1))}
+		}
+		interestingMutex.RUnlock()
+	}
+
+	value, ok := pick(random, candidates)
+	if typ.Kind() == reflect.Float32 {
+		value = float64(float32(value))
+	}
+	if !ok || !constraints.contains(value) || math.Abs(value) > upper {
+		return 0, false
+	}
+	return value, true
+}
+
+func biasedString(random *rand.Rand, constraints Constraints) (string, bool) {
+	var candidates []string
+	switch DefaultBias.choose(random) {
+	case boundaryStrategy:
+		candidates = []string{""}
+	case interestingStrategy:
+		interestingMutex.RLock()
+		candidates = interestingStrings
+		interestingMutex.RUnlock()
+	}
+
+	value, ok := pick(random, candidates)
+	if !ok || (constraints.len != nil && len([]rune(value)) != *constraints.len) {
+		return "", false
+	}
+	return value, true
+}
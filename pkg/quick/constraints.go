@@ -0,0 +1,240 @@
+package quick
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Constraints restricts the values the reflective generator produces for a
+// struct field. They are declared with the "quick" struct tag, e.g.
+//
+//	digits []uint `quick:"min=0,max=9,len=4"`
+//
+// The bounds "min" and "max" are inclusive and apply to numbers or, for
+// slices, arrays and maps, to their elements. The "len" constraint fixes the
+// length of slices, strings and maps. Generating or decoding a field panics if
+// its bounds are outside the range of its numbers or include none of them.
+type Constraints struct {
+	min, max *float64
+	len      *int
+}
+
+// ParseConstraints parses the value of a "quick" struct tag. It panics on
+// malformed tags as they are programming errors.
+func ParseConstraints(tag string) (constraints Constraints) {
+	if strings.TrimSpace(tag) == "" {
+		return constraints
+	}
+
+	for _, entry := range strings.Split(tag, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			panic(fmt.Sprintf("quick constraint \"%s\" expected to be on the form key=value", entry))
+		}
+
+		switch key {
+		case "min", "max":
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				panic(fmt.Sprintf("quick constraint \"%s\" expected a number", entry))
+			}
+			if key == "min" {
+				constraints.min = &number
+			} else {
+				constraints.max = &number
+			}
+		case "len":
+			length, err := strconv.Atoi(value)
+			if err != nil || length < 0 {
+				panic(fmt.Sprintf("quick constraint \"%s\" expected a non-negative integer", entry))
+			}
+			constraints.len = &length
+		default:
+			panic(fmt.Sprintf("unknown quick constraint \"%s\"", key))
+		}
+	}
+
+	if constraints.min != nil && constraints.max != nil && *constraints.min > *constraints.max {
+		panic(fmt.Sprintf("quick constraint \"%s\" has min greater than max", tag))
+	}
+
+	return constraints
+}
+
+func (constraints Constraints) bounded() bool {
+	return constraints.min != nil || constraints.max != nil
+}
+
+// contains reports whether the number is within the bounds.
+func (constraints Constraints) contains(number float64) bool {
+	if constraints.min != nil && number < *constraints.min {
+		return false
+	}
+	if constraints.max != nil && number > *constraints.max {
+		return false
+	}
+	return true
+}
+
+// elements returns the constraints applicable to the elements of a container.
+func (constraints Constraints) elements() Constraints {
+	return Constraints{
+		min: constraints.min,
+		max: constraints.max,
+	}
+}
+
+func (constraints Constraints) length(random *rand.Rand, size int) int {
+	if constraints.len != nil {
+		return *constraints.len
+	}
+	return random.IntN(size + 1)
+}
+
+// constraintsOf parses the constraints of the struct field and panics if
+// they do not fit its type.
+func constraintsOf(field reflect.StructField) Constraints {
+	constraints := ParseConstraints(field.Tag.Get("quick"))
+	constraints.check(field)
+	return constraints
+}
+
+// check panics unless the bounds are within the range of the numbers of the
+// field, or of its elements for containers, and include one of them.
+func (constraints Constraints) check(field reflect.StructField) {
+	if !constraints.bounded() {
+		return
+	}
+
+	typ := field.Type
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice ||
+		typ.Kind() == reflect.Array || typ.Kind() == reflect.Map {
+		typ = typ.Elem()
+	}
+
+	// The numbers of the type are within [lower, limit) for integers and
+	// [lower, limit] for floating-point numbers.
+	var lower, limit float64
+	integer := true
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		lower, limit = -math.Ldexp(1, typ.Bits()-1), math.Ldexp(1, typ.Bits()-1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		lower, limit = 0, math.Ldexp(1, typ.Bits())
+	case reflect.Float32:
+		integer = false
+		lower, limit = -math.MaxFloat32, math.MaxFloat32
+	case reflect.Float64:
+		integer = false
+		lower, limit = -math.MaxFloat64, math.MaxFloat64
+	default:
+		panic(fmt.Sprintf("quick constraints of field %s bound numbers but it is of type %v", field.Name, field.Type))
+	}
+
+	for _, bound := range []*float64{constraints.min, constraints.max} {
+		if bound != nil && (*bound < lower || *bound > limit || (integer && *bound == limit)) {
+			panic(fmt.Sprintf("quick constraints of field %s are outside the range of %v", field.Name, typ))
+		}
+	}
+
+	if constraints.min == nil || constraints.max == nil {
+		return
+	}
+	empty := math.Ceil(*constraints.min) > math.Floor(*constraints.max)
+	if !integer {
+		low, high := constraints.floatBounds(typ)
+		number := interpolate(low, high, 0, typ)
+		empty = number < low || number > high
+	}
+	if empty {
+		panic(fmt.Sprintf("quick constraints of field %s include no %v", field.Name, typ))
+	}
+}
+
+// floatBounds returns the inclusive bounds of the floating-point type narrowed
+// by the constraints.
+func (constraints Constraints) floatBounds(typ reflect.Type) (lower, upper float64) {
+	upper = math.MaxFloat64
+	if typ.Kind() == reflect.Float32 {
+		upper = math.MaxFloat32
+	}
+	lower = -upper
+	if constraints.min != nil {
+		lower = *constraints.min
+	}
+	if constraints.max != nil {
+		upper = *constraints.max
+	}
+	return lower, upper
+}
+
+// interpolate returns the number of the floating-point type the fraction of
+// the way from the lower to the upper bound. The bounds are scaled separately
+// as their difference may overflow.
+func interpolate(lower, upper, fraction float64, typ reflect.Type) float64 {
+	number := math.Min(math.Max(lower*(1-fraction)+upper*fraction, lower), upper)
+	if typ.Kind() == reflect.Float32 {
+		// Rounding to the type may move the number across a bound.
+		rounded := float32(number)
+		if float64(rounded) < lower {
+			rounded = math.Nextafter32(rounded, float32(math.Inf(1)))
+		} else if float64(rounded) > upper {
+			rounded = math.Nextafter32(rounded, float32(math.Inf(-1)))
+		}
+		number = float64(rounded)
+	}
+	return number
+}
+
+func (constraints Constraints) float(random *rand.Rand, typ reflect.Type) float64 {
+	lower, upper := constraints.floatBounds(typ)
+	return interpolate(lower, upper, random.Float64(), typ)
+}
+
+// intBounds returns the inclusive bounds of the signed type narrowed by the constraints.
+func (constraints Constraints) intBounds(typ reflect.Type) (lower, upper int64) {
+	bits := typ.Bits()
+	lower = int64(-1) << (bits - 1)
+	upper = int64(uint64(1)<<(bits-1) - 1)
+	if constraints.min != nil && *constraints.min > float64(lower) {
+		lower = int64(math.Ceil(*constraints.min))
+	}
+	if constraints.max != nil && *constraints.max < float64(upper) {
+		upper = int64(math.Floor(*constraints.max))
+	}
+	return lower, upper
+}
+
+// uintBounds returns the inclusive bounds of the unsigned type narrowed by the constraints.
+func (constraints Constraints) uintBounds(typ reflect.Type) (lower, upper uint64) {
+	lower, upper = 0, uint64(math.MaxUint64)>>(64-typ.Bits())
+	if constraints.min != nil && *constraints.min > 0 {
+		lower = uint64(math.Ceil(*constraints.min))
+	}
+	if constraints.max != nil && *constraints.max < float64(upper) {
+		upper = uint64(math.Floor(*constraints.max))
+	}
+	return lower, upper
+}
+
+func (constraints Constraints) int(random *rand.Rand, typ reflect.Type) int64 {
+	lower, upper := constraints.intBounds(typ)
+	span := uint64(upper - lower)
+	if span == math.MaxUint64 {
+		return int64(random.Uint64())
+	}
+	return lower + int64(random.Uint64N(span+1))
+}
+
+func (constraints Constraints) uint(random *rand.Rand, typ reflect.Type) uint64 {
+	lower, upper := constraints.uintBounds(typ)
+	span := upper - lower
+	if span == math.MaxUint64 {
+		return random.Uint64()
+	}
+	return lower + random.Uint64N(span+1)
+}
@@ -0,0 +1,161 @@
+package quick
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"unsafe"
+)
+
+// Decoder constructs values from a byte stream such as the one provided by
+// the Go fuzzer. Every value consumes a deterministic number of bytes so small
+// mutations of the stream result in small changes of the decoded values. An
+// exhausted stream decodes as zero bytes. Types implementing Generatable or
+// with a registered generator are generated from a random source seeded by
+// the next 16 bytes of the stream.
+type Decoder struct {
+	data []byte
+	size int
+}
+
+func NewDecoder(data []byte, size int) *Decoder {
+	return &Decoder{
+		data: data,
+		size: size,
+	}
+}
+
+// Decode constructs a value of type T from the stream.
+func Decode[T any](decoder *Decoder) T {
+	var value T
+	decoder.Decode(reflect.ValueOf(&value).Elem())
+	return value
+}
+
+// Exhausted reports whether all bytes of the stream have been consumed.
+func (decoder *Decoder) Exhausted() bool {
+	return len(decoder.data) == 0
+}
+
+func (decoder *Decoder) bytes(n int) []byte {
+	buffer := make([]byte, n)
+	consumed := copy(buffer, decoder.data)
+	decoder.data = decoder.data[consumed:]
+	return buffer
+}
+
+func (decoder *Decoder) uint64(bits int) uint64 {
+	buffer := make([]byte, 8)
+	copy(buffer, decoder.bytes(bits/8))
+	return binary.LittleEndian.Uint64(buffer)
+}
+
+func (decoder *Decoder) length(constraints Constraints) int {
+	if constraints.len != nil {
+		return *constraints.len
+	}
+	return int(decoder.uint64(8) % uint64(decoder.size+1))
+}
+
+// Decode assigns a value decoded from the stream to the settable value.
+func (decoder *Decoder) Decode(value reflect.Value) {
+	decoder.decode(value, Constraints{})
+}
+
+func (decoder *Decoder) decode(value reflect.Value, constraints Constraints) {
+	if generate, ok := generator(value.Type()); ok {
+		random := rand.New(rand.NewPCG(decoder.uint64(64), decoder.uint64(64)))
+		value.Set(generate(random, decoder.size))
+		return
+	}
+
+	switch kind := value.Kind(); kind {
+	case reflect.Bool:
+		value.SetBool(decoder.uint64(8)&1 == 1)
+	case reflect.Float32, reflect.Float64:
+		bits := value.Type().Bits()
+		var number float64
+		if bits == 32 {
+			number = float64(math.Float32frombits(uint32(decoder.uint64(32))))
+		} else {
+			number = math.Float64frombits(decoder.uint64(64))
+		}
+		// Comparing with the bounds also excludes NaN and the infinities.
+		if lower, upper := constraints.floatBounds(value.Type()); constraints.bounded() &&
+			!(number >= lower && number <= upper) {
+			fraction := math.Abs(math.Mod(number, 1))
+			if math.IsNaN(fraction) || math.IsInf(number, 0) {
+				fraction = 0
+			}
+			number = interpolate(lower, upper, fraction, value.Type())
+		}
+		value.SetFloat(number)
+	case reflect.Complex64, reflect.Complex128:
+		real := math.Float64frombits(decoder.uint64(64))
+		imaginary := math.Float64frombits(decoder.uint64(64))
+		value.SetComplex(complex(real, imaginary))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := value.Type().Bits()
+		// Sign extend the decoded bits to the width of the type.
+		number := int64(decoder.uint64(bits)<<(64-bits)) >> (64 - bits)
+		if constraints.bounded() && !constraints.contains(float64(number)) {
+			lower, upper := constraints.intBounds(value.Type())
+			number = lower + int64(uint64(number)%(uint64(upper-lower)+1))
+		}
+		value.SetInt(number)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		number := decoder.uint64(value.Type().Bits())
+		if constraints.bounded() && !constraints.contains(float64(number)) {
+			lower, upper := constraints.uintBounds(value.Type())
+			span := upper - lower
+			if span != math.MaxUint64 {
+				number = lower + number%(span+1)
+			}
+		}
+		value.SetUint(number)
+	case reflect.String:
+		length := decoder.length(constraints)
+		value.SetString(string(decoder.bytes(length)))
+	case reflect.Array:
+		for idx := 0; idx < value.Len(); idx++ {
+			decoder.decode(value.Index(idx), constraints.elements())
+		}
+	case reflect.Slice:
+		length := decoder.length(constraints)
+		slice := reflect.MakeSlice(value.Type(), length, length)
+		for idx := 0; idx < length; idx++ {
+			decoder.decode(slice.Index(idx), constraints.elements())
+		}
+		value.Set(slice)
+	case reflect.Map:
+		length := decoder.length(constraints)
+		mapping := reflect.MakeMapWithSize(value.Type(), length)
+		for idx := 0; idx < length; idx++ {
+			key := reflect.New(value.Type().Key()).Elem()
+			decoder.decode(key, Constraints{})
+			element := reflect.New(value.Type().Elem()).Elem()
+			decoder.decode(element, constraints.elements())
+			mapping.SetMapIndex(key, element)
+		}
+		value.Set(mapping)
+	case reflect.Struct:
+		n := value.NumField()
+		for i := 0; i < n; i++ {
+			field := value.Field(i)
+			fieldConstraints := constraintsOf(value.Type().Field(i))
+			if field.CanSet() {
+				decoder.decode(field, fieldConstraints)
+			} else {
+				fieldPtr := unsafe.Pointer(field.UnsafeAddr())
+				unsafeField := reflect.NewAt(field.Type(), fieldPtr).Elem()
+				decoder.decode(unsafeField, fieldConstraints)
+			}
+		}
+	case reflect.Ptr:
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		decoder.decode(value.Elem(), constraints)
+	}
+}
@@ -0,0 +1,83 @@
+package quick
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"sync"
+)
+
+// Generatable is implemented by types which know how to generate random
+// values of themselves, e.g. domain types with invariants the reflective
+// generator cannot infer from their kind.
+type Generatable[T any] interface {
+	Generate(rand *rand.Rand, size int) T
+}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[reflect.Type]func(random *rand.Rand, size int) reflect.Value{}
+)
+
+var randType = reflect.TypeOf((*rand.Rand)(nil))
+
+// Register adds a generator for values of type T. It is used for third-party
+// types which cannot implement Generatable and takes precedence over it.
+func Register[T any](generator func(rand *rand.Rand, size int) T) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	registry[typ] = func(random *rand.Rand, size int) reflect.Value {
+		return reflect.ValueOf(generator(random, size))
+	}
+}
+
+// Unregister removes the generator for values of type T.
+func Unregister[T any]() {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	delete(registry, reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// custom returns a value generated by either a registered generator or the
+// type's own Generate method if it implements Generatable.
+func custom(random *rand.Rand, size int, typ reflect.Type) (reflect.Value, bool) {
+	generate, exists := generator(typ)
+	if !exists {
+		return reflect.Value{}, false
+	}
+	return generate(random, size), true
+}
+
+// generator returns the registered generator of the type or, if it implements
+// Generatable, its own Generate method.
+func generator(typ reflect.Type) (func(random *rand.Rand, size int) reflect.Value, bool) {
+	registryMutex.RLock()
+	generate, exists := registry[typ]
+	registryMutex.RUnlock()
+	if exists {
+		return generate, true
+	}
+
+	method, exists := typ.MethodByName("Generate")
+	if !exists {
+		return nil, false
+	}
+
+	// The method expression includes the receiver as the first input.
+	signature := method.Type
+	if signature.NumIn() != 3 || signature.NumOut() != 1 ||
+		signature.In(1) != randType || signature.In(2).Kind() != reflect.Int ||
+		signature.Out(0) != typ {
+		return nil, false
+	}
+
+	return func(random *rand.Rand, size int) reflect.Value {
+		receiver := reflect.New(typ).Elem()
+		results := method.Func.Call([]reflect.Value{
+			receiver, reflect.ValueOf(random), reflect.ValueOf(size),
+		})
+		return results[0]
+	}, true
+}
@@ -7,6 +7,10 @@ import (
 	"unsafe"
 )
 
+// DefaultSize is the size hint used when generating values without an
+// explicit size. It bounds the length of slices, strings and maps.
+const DefaultSize = 32
+
 func New[T any]() T {
 	var value T
 	concrete := reflect.ValueOf(&value).Elem()
@@ -20,38 +24,121 @@ func Update[T any](value *T) {
 }
 
 func NewReflect(value reflect.Value) {
+	random := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	Generate(random, DefaultSize, value)
+}
+
+// NewWith generates a value of type T using the random source and size hint.
+func NewWith[T any](random *rand.Rand, size int) T {
+	var value T
+	concrete := reflect.ValueOf(&value).Elem()
+	Generate(random, size, concrete)
+	return value
+}
+
+// Generate assigns a random value to the settable value. Types implementing
+// Generatable or with a registered generator are delegated to, otherwise the
+// value is generated based on its kind.
+func Generate(random *rand.Rand, size int, value reflect.Value) {
+	generate(random, size, value, Constraints{})
+}
+
+func generate(random *rand.Rand, size int, value reflect.Value, constraints Constraints) {
+	if generated, ok := custom(random, size, value.Type()); ok {
+		value.Set(generated)
+		return
+	}
+
 	// TODO: Fix float generation to also include negative numbers.
 	switch kind := value.Kind(); kind {
 	case reflect.Bool:
-		value.SetBool(rand.Int()&1 == 0)
+		value.SetBool(random.Int()&1 == 0)
 	case reflect.Float32:
-		value.SetFloat(float64(rand.Float32() * math.MaxFloat32))
+		if biased, ok := biasedFloat(random, size, value.Type(), constraints); ok {
+			value.SetFloat(biased)
+		} else if constraints.bounded() {
+			value.SetFloat(constraints.float(random, value.Type()))
+		} else {
+			value.SetFloat(float64(random.Float32() * math.MaxFloat32))
+		}
 	case reflect.Float64:
-		value.SetFloat(rand.Float64() * math.MaxFloat64)
+		if biased, ok := biasedFloat(random, size, value.Type(), constraints); ok {
+			value.SetFloat(biased)
+		} else if constraints.bounded() {
+			value.SetFloat(constraints.float(random, value.Type()))
+		} else {
+			value.SetFloat(random.Float64() * math.MaxFloat64)
+		}
 	case reflect.Complex64:
-		value.SetComplex(complex(float64(rand.Float32()), float64(rand.Float32())))
+		value.SetComplex(complex(float64(random.Float32()), float64(random.Float32())))
 	case reflect.Complex128:
-		value.SetComplex(complex(rand.Float64(), rand.Float64()))
+		value.SetComplex(complex(random.Float64(), random.Float64()))
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		value.SetInt(rand.Int64())
+		if biased, ok := biasedInt(random, size, value.Type(), constraints); ok {
+			value.SetInt(biased)
+		} else if constraints.bounded() {
+			value.SetInt(constraints.int(random, value.Type()))
+		} else {
+			value.SetInt(random.Int64())
+		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		value.SetUint(rand.Uint64())
+		if biased, ok := biasedUint(random, size, value.Type(), constraints); ok {
+			value.SetUint(biased)
+		} else if constraints.bounded() {
+			value.SetUint(constraints.uint(random, value.Type()))
+		} else {
+			value.SetUint(random.Uint64())
+		}
+	case reflect.String:
+		if biased, ok := biasedString(random, constraints); ok {
+			value.SetString(biased)
+			break
+		}
+		length := constraints.length(random, size)
+		runes := make([]rune, length)
+		for idx := range runes {
+			runes[idx] = rune(' ' + random.IntN('~'-' '+1))
+		}
+		value.SetString(string(runes))
+	case reflect.Array:
+		for idx := 0; idx < value.Len(); idx++ {
+			generate(random, size, value.Index(idx), constraints.elements())
+		}
+	case reflect.Slice:
+		length := constraints.length(random, size)
+		slice := reflect.MakeSlice(value.Type(), length, length)
+		for idx := 0; idx < length; idx++ {
+			generate(random, size, slice.Index(idx), constraints.elements())
+		}
+		value.Set(slice)
+	case reflect.Map:
+		length := constraints.length(random, size)
+		mapping := reflect.MakeMapWithSize(value.Type(), length)
+		for idx := 0; idx < length; idx++ {
+			key := reflect.New(value.Type().Key()).Elem()
+			generate(random, size, key, Constraints{})
+			element := reflect.New(value.Type().Elem()).Elem()
+			generate(random, size, element, constraints.elements())
+			mapping.SetMapIndex(key, element)
+		}
+		value.Set(mapping)
 	case reflect.Struct:
 		n := value.NumField()
 		for i := 0; i < n; i++ {
 			field := value.Field(i)
+			fieldConstraints := constraintsOf(value.Type().Field(i))
 			if field.CanSet() {
-				NewReflect(field)
+				generate(random, size, field, fieldConstraints)
 			} else {
 				fieldPtr := unsafe.Pointer(field.UnsafeAddr())
 				unsafeField := reflect.NewAt(field.Type(), fieldPtr).Elem()
-				NewReflect(unsafeField)
+				generate(random, size, unsafeField, fieldConstraints)
 			}
 		}
 	case reflect.Ptr:
 		if value.IsNil() {
 			value.Set(reflect.New(value.Type().Elem()))
 		}
-		NewReflect(value.Elem())
+		generate(random, size, value.Elem(), constraints)
 	}
-}
\ No newline at end of file
+}
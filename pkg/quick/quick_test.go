@@ -0,0 +1,277 @@
+package quick
+
+import (
+	"bytes"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type digit uint8
+
+func (digit) Generate(rand *rand.Rand, size int) digit {
+	return digit(rand.IntN(10))
+}
+
+type celsius float64
+
+func TestGeneratable(t *testing.T) {
+	for range 100 {
+		value := New[digit]()
+		assert.LessOrEqual(t, value, digit(9))
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register(func(rand *rand.Rand, size int) celsius {
+		return celsius(-273.15 + rand.Float64()*100)
+	})
+	defer Unregister[celsius]()
+
+	for range 100 {
+		value := New[celsius]()
+		assert.GreaterOrEqual(t, float64(value), -273.15)
+		assert.LessOrEqual(t, float64(value), -173.15)
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	type Execution struct {
+		pin     []uint `quick:"min=0,max=9,len=4"`
+		attempt int8   `quick:"min=1,max=3"`
+		name    string `quick:"len=8"`
+		digits  [4]digit
+	}
+
+	for range 100 {
+		execution := New[Execution]()
+		assert.Len(t, execution.pin, 4)
+		for _, value := range execution.pin {
+			assert.LessOrEqual(t, value, uint(9))
+		}
+		assert.GreaterOrEqual(t, execution.attempt, int8(1))
+		assert.LessOrEqual(t, execution.attempt, int8(3))
+		assert.Len(t, execution.name, 8)
+		for _, value := range execution.digits {
+			assert.LessOrEqual(t, value, digit(9))
+		}
+	}
+}
+
+func TestParseConstraints(t *testing.T) {
+	tests := []struct {
+		description string
+		tag         string
+		panics      bool
+	}{
+		{
+			description: "empty",
+			tag:         "",
+		},
+		{
+			description: "bounds and length",
+			tag:         "min=0,max=9,len=4",
+		},
+		{
+			description: "negative bounds",
+			tag:         "min=-10, max=-1",
+		},
+		{
+			description: "missing value",
+			tag:         "min",
+			panics:      true,
+		},
+		{
+			description: "unknown key",
+			tag:         "step=2",
+			panics:      true,
+		},
+		{
+			description: "min greater than max",
+			tag:         "min=2,max=1",
+			panics:      true,
+		},
+		{
+			description: "negative length",
+			tag:         "len=-1",
+			panics:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if tt.panics {
+				assert.Panics(t, func() { ParseConstraints(tt.tag) })
+			} else {
+				assert.NotPanics(t, func() { ParseConstraints(tt.tag) })
+			}
+		})
+	}
+}
+
+func TestConstraintsOf(t *testing.T) {
+	type Execution struct {
+		above    int8    `quick:"min=128"`
+		negative []uint  `quick:"min=-1,max=9"`
+		wide     float32 `quick:"max=1e39"`
+		integral int     `quick:"min=1.2,max=1.8"`
+		narrow   float32 `quick:"min=0.1,max=0.1"`
+		text     string  `quick:"min=0"`
+		largest  int64   `quick:"max=9223372036854775807"`
+		fraction int     `quick:"min=0.5,max=2.5"`
+		exact    float32 `quick:"min=0.5,max=0.5"`
+	}
+
+	tests := []struct {
+		field  string
+		panics bool
+	}{
+		{field: "above", panics: true},
+		{field: "negative", panics: true},
+		{field: "wide", panics: true},
+		{field: "integral", panics: true},
+		{field: "narrow", panics: true},
+		{field: "text", panics: true},
+		{field: "largest", panics: true},
+		{field: "fraction"},
+		{field: "exact"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			field, _ := reflect.TypeOf(Execution{}).FieldByName(tt.field)
+			if tt.panics {
+				assert.Panics(t, func() { constraintsOf(field) })
+			} else {
+				assert.NotPanics(t, func() { constraintsOf(field) })
+			}
+		})
+	}
+}
+
+func TestConstraintsFitType(t *testing.T) {
+	type Execution struct {
+		ratio    float32 `quick:"min=0.1"`
+		negative float32 `quick:"max=-1e30"`
+		small    float32 `quick:"min=0.1,max=0.2"`
+		count    int     `quick:"min=0.5,max=2.5"`
+	}
+
+	for range 1000 {
+		execution := New[Execution]()
+		assert.GreaterOrEqual(t, float64(execution.ratio), 0.1)
+		assert.LessOrEqual(t, float64(execution.ratio), float64(math.MaxFloat32))
+		assert.LessOrEqual(t, float64(execution.negative), -1e30)
+		assert.GreaterOrEqual(t, float64(execution.negative), -float64(math.MaxFloat32))
+		assert.GreaterOrEqual(t, float64(execution.small), 0.1)
+		assert.LessOrEqual(t, float64(execution.small), 0.2)
+		assert.GreaterOrEqual(t, execution.count, 1)
+		assert.LessOrEqual(t, execution.count, 2)
+	}
+
+	for seed := range 256 {
+		data := []byte{byte(seed), byte(seed * 7), byte(seed * 13), 0x7F}
+		execution := Decode[Execution](NewDecoder(bytes.Repeat(data, 4), DefaultSize))
+		assert.GreaterOrEqual(t, float64(execution.ratio), 0.1)
+		assert.False(t, math.IsInf(float64(execution.ratio), 0))
+		assert.LessOrEqual(t, float64(execution.negative), -1e30)
+		assert.GreaterOrEqual(t, float64(execution.small), 0.1)
+		assert.LessOrEqual(t, float64(execution.small), 0.2)
+		assert.GreaterOrEqual(t, execution.count, 1)
+		assert.LessOrEqual(t, execution.count, 2)
+	}
+}
+
+func TestBoundaryValues(t *testing.T) {
+	seen := map[int64]bool{}
+	for range 1000 {
+		seen[New[int64]()] = true
+	}
+
+	assert.True(t, seen[0])
+	assert.True(t, seen[-1])
+	assert.True(t, seen[math.MinInt64])
+	assert.True(t, seen[math.MaxInt64])
+}
+
+func TestInteresting(t *testing.T) {
+	Interesting(100_000, "secret")
+	defer ForgetInteresting()
+
+	numbers, strings := map[int]bool{}, map[string]bool{}
+	for range 1000 {
+		numbers[New[int]()] = true
+		strings[New[string]()] = true
+	}
+
+	assert.True(t, numbers[99_999])
+	assert.True(t, numbers[100_000])
+	assert.True(t, numbers[100_001])
+	assert.True(t, strings["secret"])
+}
+
+func TestBiasRespectsConstraints(t *testing.T) {
+	type Execution struct {
+		value int `quick:"min=10,max=20"`
+	}
+
+	Interesting(100_000)
+	defer ForgetInteresting()
+
+	for range 1000 {
+		execution := New[Execution]()
+		assert.GreaterOrEqual(t, execution.value, 10)
+		assert.LessOrEqual(t, execution.value, 20)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type Execution struct {
+		attempt int16
+		pin     []uint8 `quick:"min=0,max=9,len=4"`
+		ok      bool
+	}
+
+	decoder := NewDecoder([]byte{0xFF, 0xFF, 12, 3, 4, 5, 1}, DefaultSize)
+	execution := Decode[Execution](decoder)
+
+	assert.Equal(t, int16(-1), execution.attempt)
+	assert.Equal(t, []uint8{2, 3, 4, 5}, execution.pin)
+	assert.True(t, execution.ok)
+	assert.True(t, decoder.Exhausted())
+
+	assert.Equal(t, Execution{pin: []uint8{0, 0, 0, 0}}, Decode[Execution](decoder))
+}
+
+func TestDecodeGenerators(t *testing.T) {
+	type pin []uint
+	Register(func(rand *rand.Rand, size int) pin {
+		return pin{rand.UintN(10), rand.UintN(10), rand.UintN(10), rand.UintN(10)}
+	})
+	defer Unregister[pin]()
+
+	type Execution struct {
+		pin   pin
+		digit digit
+		ok    bool
+	}
+
+	for seed := range 256 {
+		data := append(bytes.Repeat([]byte{byte(seed), byte(seed * 31)}, 16), 1)
+		execution := Decode[Execution](NewDecoder(data, DefaultSize))
+		assert.True(t, execution.ok)
+		assert.Len(t, execution.pin, 4)
+		for _, value := range execution.pin {
+			assert.LessOrEqual(t, value, uint(9))
+		}
+		assert.LessOrEqual(t, execution.digit, digit(9))
+
+		// Every generated value consumes the 16 bytes seeding its source.
+		decoder := NewDecoder(data, DefaultSize)
+		assert.Equal(t, execution, Decode[Execution](decoder))
+		assert.True(t, decoder.Exhausted())
+	}
+}